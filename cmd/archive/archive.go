@@ -2,18 +2,19 @@ package archive
 
 import (
 	"archive/tar"
-	"compress/gzip"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/destination"
+	"github.com/coyls/obs-cli/internal/ignore"
 	"github.com/coyls/obs-cli/internal/logger"
 )
 
@@ -25,12 +26,29 @@ var ArchiveCmd = &cobra.Command{
 var CreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a backup of all Obsidian vaults",
+	Long: `Create a backup of all Obsidian vaults on every configured destination
+(the USB key and the entries of 'config.archive.destinations').
+
+By default a full .tar.gz archive is written (see --format and --level for
+tar.zst, tar.xz and zip archives). With --incremental (or
+'config.archive.mode: incremental'), files are stored as deduplicated chunks
+in a repository on each destination and only new or modified files are written.
+
+Use --destination to back up to some destinations only.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runCreate()
 	},
 }
 
+var incremental bool
+
+const (
+	ModeFull        = "full"
+	ModeIncremental = "incremental"
+)
+
 func init() {
+	CreateCmd.Flags().BoolVarP(&incremental, "incremental", "i", false, "Store an incremental snapshot instead of a full archive")
 	ArchiveCmd.AddCommand(CreateCmd)
 }
 
@@ -44,93 +62,285 @@ func runCreate() error {
 		return fmt.Errorf("invalid config: %w", err)
 	}
 
-	// Validate archive specific configuration
-	if cfg.Config.Archive.UsbPath == "" {
-		return fmt.Errorf("USB path for archive is required. Please set 'config.archive.usb_path' in your configuration")
+	switch cfg.Config.Archive.Mode {
+	case "", ModeFull:
+	case ModeIncremental:
+		incremental = true
+	default:
+		return fmt.Errorf("unknown archive mode '%s' (expected '%s' or '%s')", cfg.Config.Archive.Mode, ModeFull, ModeIncremental)
+	}
+
+	configs, err := selectDestinations(cfg, true)
+	if err != nil {
+		return err
 	}
 
 	logger.PrintHeader("Backup Obsidian Vaults")
 
-	// Check USB path
-	usbPath := cfg.Config.Archive.UsbPath
-	if _, err := os.Stat(usbPath); os.IsNotExist(err) {
-		logger.Error("USB key is not connected at path: %s", usbPath)
-		return fmt.Errorf("usb key not found at configured path")
+	// Une destination indisponible n'empêche pas de sauvegarder sur les autres
+	var dests []destination.Destination
+	for _, c := range configs {
+		dest, err := openDestination(c)
+		if err != nil {
+			continue
+		}
+		defer dest.Close()
+		dests = append(dests, dest)
+	}
+	if len(dests) == 0 {
+		return fmt.Errorf("no backup destination available")
 	}
 
-	logger.Info("USB key is connected at: %s", usbPath)
+	var failed []string
+	if incremental {
+		for _, dest := range dests {
+			if err := createSnapshot(cfg, dest); err != nil {
+				logger.Error("Backup to '%s' failed: %s", dest.Name(), err.Error())
+				failed = append(failed, dest.Name())
+			}
+		}
+	} else if failed, err = createArchive(cfg, dests); err != nil {
+		return err
+	}
 
-	// Remove previous backup
-	previousBackup, err := findPreviousBackup(usbPath)
-	if err != nil {
-		return fmt.Errorf("failed to find previous backup: %w", err)
+	// Les destinations indisponibles sont aussi en échec
+	for _, c := range configs {
+		if !containsDestination(dests, c.Name) {
+			failed = append(failed, c.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("backup failed for destination(s): %s", strings.Join(failed, ", "))
 	}
+	return nil
+}
 
-	if previousBackup != "" {
-		logger.Info("Removing previous backup: %s", filepath.Base(previousBackup))
-		if err := os.Remove(previousBackup); err != nil {
-			return fmt.Errorf("failed to remove previous backup: %w", err)
+func containsDestination(dests []destination.Destination, name string) bool {
+	for _, d := range dests {
+		if d.Name() == name {
+			return true
 		}
-		logger.Success("Previous backup removed")
-	} else {
-		logger.Info("No previous backup found")
 	}
+	return false
+}
 
-	// Check available space
-	requiredSpace, err := calculateRequiredSpace(cfg.Config.Root)
+// createArchive crée et vérifie l'archive une seule fois, puis l'envoie sur chaque destination.
+// Retourne les destinations sur lesquelles l'envoi a échoué.
+func createArchive(cfg *config.Config, dests []destination.Destination) ([]string, error) {
+	filter, err := ignore.FromConfig(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to calculate required space: %w", err)
+		return nil, err
 	}
 
-	availableSpace, err := getAvailableSpace(usbPath)
+	// L'estimation applique les mêmes règles d'exclusion que l'archive
+	requiredSpace, err := calculateRequiredSpace(cfg.Config.Root, filter)
 	if err != nil {
-		return fmt.Errorf("failed to get available space: %w", err)
+		return nil, fmt.Errorf("failed to calculate required space: %w", err)
 	}
 
-	if availableSpace < requiredSpace {
-		logger.Error("Insufficient space on USB key")
-		logger.Info("Required space: %s", formatBytes(requiredSpace))
-		logger.Info("Available space: %s", formatBytes(availableSpace))
-		return fmt.Errorf("insufficient space")
+	format, level, err := archiveFormat(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	logger.Info("Sufficient space on USB key")
-	logger.Info("Required space: %s", formatBytes(requiredSpace))
-	logger.Info("Available space: %s", formatBytes(availableSpace))
+	name := backupPrefix + getTimestamp() + "." + format
+	if cfg.Config.Archive.Encryption.Enabled {
+		name += encryptedExtension
+	}
 
-	// Create backup
-	backupFile := filepath.Join(usbPath, fmt.Sprintf("backup-obsidian_%s.tar.gz", getTimestamp()))
+	// L'archive est construite sur la première destination locale, si elle a la place de la
+	// recevoir, plutôt que dans le dossier temporaire du système, souvent en mémoire
+	var staging *destination.Local
+	dir := ""
+	for _, dest := range dests {
+		if local, ok := dest.(*destination.Local); ok {
+			if makeSpace(local, cfg.Config.Archive.Retention, name, requiredSpace) == nil {
+				staging, dir = local, local.Location()
+			}
+			break
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-"+name+".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary archive: %w", err)
+	}
+	tmp.Close()
+	backupFile := tmp.Name()
+	defer os.Remove(backupFile)
+
+	// Sans identité, l'archive chiffrée ne peut pas être relue : elle est vérifiée en clair
+	// pendant son écriture, avant le chiffrement
+	var plain *streamVerifier
+	var plainOut io.Writer
+	if !canDecrypt(cfg) {
+		plain = newStreamVerifier(cfg)
+		defer plain.Close()
+		plainOut = plain
+	}
 
 	logger.Info("Creating backup of all vaults...")
-	if err := createBackup(cfg.Config.Root, backupFile); err != nil {
-		return fmt.Errorf("failed to create backup: %w", err)
+	if err := createBackup(cfg, cfg.Config.Root, filter, format, level, backupFile, plainOut); err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
 	}
 
 	logger.Info("Verifying archive integrity...")
-	if err := verifyBackup(backupFile); err != nil {
-		os.Remove(backupFile)
-		return fmt.Errorf("backup verification failed: %w", err)
+	if plain != nil {
+		err = plain.Result()
+	} else {
+		err = verifyBackup(cfg, backupFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("backup verification failed: %w", err)
+	}
+
+	// L'archive est déplacée sur la destination où elle a été construite une fois envoyée ailleurs
+	var failed []string
+	for _, dest := range dests {
+		if dest == destination.Destination(staging) {
+			continue
+		}
+		if err := uploadBackup(cfg, dest, backupFile, name, requiredSpace); err != nil {
+			logger.Error("Backup to '%s' failed: %s", dest.Name(), err.Error())
+			failed = append(failed, dest.Name())
+		}
+	}
+	if staging != nil {
+		if err := storeBackup(cfg, staging, backupFile, name); err != nil {
+			logger.Error("Backup to '%s' failed: %s", staging.Name(), err.Error())
+			failed = append(failed, staging.Name())
+		}
+	}
+	return failed, nil
+}
+
+// storeBackup déplace l'archive vérifiée sur la destination locale où elle a été construite,
+// dont l'espace a déjà été vérifié, puis applique la rétention
+func storeBackup(cfg *config.Config, dest *destination.Local, backupFile, name string) error {
+	if err := dest.Move(name, backupFile); err != nil {
+		return fmt.Errorf("failed to store backup: %w", err)
+	}
+
+	logger.Success("Backup of all vaults completed and verified on '%s'!", dest.Name())
+
+	if err := pruneBackups(dest, cfg.Config.Archive.Retention); err != nil {
+		return fmt.Errorf("failed to remove expired backups: %w", err)
+	}
+	return nil
+}
+
+// uploadBackup copie l'archive vérifiée sur une destination puis applique la rétention
+func uploadBackup(cfg *config.Config, dest destination.Destination, backupFile, name string, requiredSpace int64) error {
+	if err := makeSpace(dest, cfg.Config.Archive.Retention, name, requiredSpace); err != nil {
+		return err
+	}
+
+	file, err := os.Open(backupFile)
+	if err != nil {
+		return err
 	}
+	defer file.Close()
 
-	logger.Success("Backup of all vaults completed and verified!")
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Uploading backup to '%s'...", dest.Name())
+	if err := dest.Put(name, file); err != nil {
+		return fmt.Errorf("failed to upload backup: %w", err)
+	}
+
+	// Vérifier que l'archive a été entièrement écrite
+	obj, err := dest.Stat(name)
+	if err != nil {
+		return fmt.Errorf("failed to check uploaded backup: %w", err)
+	}
+	if obj.Size != info.Size() {
+		dest.Delete(name)
+		return fmt.Errorf("uploaded backup is incomplete (%s instead of %s)", formatBytes(obj.Size), formatBytes(info.Size()))
+	}
+
+	logger.Success("Backup of all vaults completed and verified on '%s'!", dest.Name())
+
+	// Les anciennes sauvegardes ne sont supprimées qu'une fois la nouvelle vérifiée
+	if err := pruneBackups(dest, cfg.Config.Archive.Retention); err != nil {
+		return fmt.Errorf("failed to remove expired backups: %w", err)
+	}
 	return nil
 }
 
-func findPreviousBackup(usbPath string) (string, error) {
-	pattern := filepath.Join(usbPath, "backup-obsidian_*.tar.gz")
-	matches, err := filepath.Glob(pattern)
+// makeSpace vérifie l'espace libre avant l'envoi de la sauvegarde name. S'il manque, les
+// sauvegardes que la rétention supprimera une fois name envoyée le sont avant l'envoi, sauf la
+// plus récente : une sauvegarde vérifiée reste disponible si l'envoi échoue.
+func makeSpace(dest destination.Destination, policy config.RetentionConfig, name string, requiredSpace int64) error {
+	availableSpace, err := dest.Available()
+	if err != nil {
+		return fmt.Errorf("failed to get available space: %w", err)
+	}
+	if availableSpace < 0 {
+		logger.Info("Available space on '%s' is unknown, skipping space check", dest.Name())
+		return nil
+	}
+	if availableSpace >= requiredSpace {
+		return checkSpace(dest.Name(), requiredSpace, availableSpace)
+	}
+
+	backups, err := listBackups(dest)
+	if err != nil {
+		return err
+	}
+	created, _ := parseBackupName(name)
+	withNew := append([]backup{{Path: name, Time: created}}, backups...)
+
+	var expired []backup
+	var freed int64
+	for _, b := range expiredBackups(withNew, policy) {
+		if b.Path == backups[0].Path {
+			continue
+		}
+		expired = append(expired, b)
+		freed += b.Size
+	}
+	if len(expired) == 0 || availableSpace+freed < requiredSpace {
+		return checkSpace(dest.Name(), requiredSpace, availableSpace+freed)
+	}
+
+	logger.Info("Removing expired backups first to free %s on '%s'", formatBytes(freed), dest.Name())
+	for _, b := range expired {
+		logger.Info("Removing expired backup: %s", b.Name())
+		if err := dest.Delete(b.Path); err != nil {
+			return fmt.Errorf("failed to remove expired backups: %w", err)
+		}
+	}
+	return checkDestinationSpace(dest, requiredSpace)
+}
+
+func pruneBackups(dest destination.Destination, policy config.RetentionConfig) error {
+	backups, err := listBackups(dest)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	expired := expiredBackups(backups, policy)
+	if len(expired) == 0 {
+		logger.Info("No expired backup to remove")
+		return nil
 	}
-	if len(matches) > 0 {
-		return matches[0], nil
+
+	for _, b := range expired {
+		logger.Info("Removing expired backup: %s", b.Name())
+		if err := dest.Delete(b.Path); err != nil {
+			return err
+		}
 	}
-	return "", nil
+	logger.Success("%d expired backup(s) removed", len(expired))
+	return nil
 }
 
-func calculateRequiredSpace(path string) (int64, error) {
+func calculateRequiredSpace(path string, filter *ignore.Matcher) (int64, error) {
 	var size int64
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+	err := ignore.Walk(path, filter, func(_ string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -144,12 +354,18 @@ func calculateRequiredSpace(path string) (int64, error) {
 	return size, err
 }
 
-func getAvailableSpace(path string) (int64, error) {
-	var stat syscall.Statfs_t
-	if err := syscall.Statfs(path, &stat); err != nil {
-		return 0, err
+func checkSpace(name string, requiredSpace, availableSpace int64) error {
+	if availableSpace < requiredSpace {
+		logger.Error("Insufficient space on '%s'", name)
+		logger.Info("Required space: %s", formatBytes(requiredSpace))
+		logger.Info("Available space: %s", formatBytes(availableSpace))
+		return fmt.Errorf("insufficient space")
 	}
-	return int64(stat.Bavail) * int64(stat.Bsize), nil
+
+	logger.Info("Sufficient space on '%s'", name)
+	logger.Info("Required space: %s", formatBytes(requiredSpace))
+	logger.Info("Available space: %s", formatBytes(availableSpace))
+	return nil
 }
 
 func formatBytes(bytes int64) string {
@@ -166,34 +382,36 @@ func formatBytes(bytes int64) string {
 }
 
 func getTimestamp() string {
-	return time.Now().Format("2006-01-02_15-04-05")
-}
-
-// cleanFileName nettoie le nom de fichier pour le rendre compatible avec les systèmes de fichiers
-func cleanFileName(name string) string {
-	// Remplacer les caractères problématiques par des tirets
-	replacer := strings.NewReplacer(
-		"?", "-",
-		"*", "-",
-		"<", "-",
-		">", "-",
-		"\"", "-",
-		"'", "-",
-	)
-	return replacer.Replace(name)
+	return time.Now().Format(timestampFormat)
 }
 
-func createBackup(sourcePath, targetFile string) error {
+// createBackup écrit l'archive dans targetFile. Si plain n'est pas nil, l'archive compressée y
+// est aussi écrite avant son chiffrement.
+func createBackup(cfg *config.Config, sourcePath string, filter *ignore.Matcher, format string, level int, targetFile string, plain io.Writer) error {
 	file, err := os.Create(targetFile)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	gw := gzip.NewWriter(file)
-	defer gw.Close()
+	// Le chiffrement s'applique à l'archive compressée
+	var out io.WriteCloser = file
+	if cfg.Config.Archive.Encryption.Enabled {
+		if out, err = encryptWriter(cfg, file); err != nil {
+			return fmt.Errorf("failed to encrypt backup: %w", err)
+		}
+		defer out.Close()
+	}
 
-	tw := tar.NewWriter(gw)
+	var archiveOut io.Writer = out
+	if plain != nil {
+		archiveOut = io.MultiWriter(out, plain)
+	}
+
+	tw, err := newArchiveWriter(archiveOut, format, level)
+	if err != nil {
+		return err
+	}
 	defer tw.Close()
 
 	// Convertir le chemin source en chemin absolu
@@ -202,7 +420,20 @@ func createBackup(sourcePath, targetFile string) error {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	return filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
+	manifest := newManifest()
+
+	// En mode portable, la table des noms est écrite avant les fichiers
+	var names *nameTable
+	if portableMode(cfg) {
+		if names, err = buildNameTable(sourcePath, filter); err != nil {
+			return err
+		}
+		if err := writeNames(tw, names.renamed()); err != nil {
+			return err
+		}
+	}
+
+	err = ignore.Walk(sourcePath, filter, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -219,16 +450,35 @@ func createBackup(sourcePath, targetFile string) error {
 			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
 		}
 
-		// Nettoyer le nom de fichier
-		dir := filepath.Dir(relPath)
-		base := filepath.Base(relPath)
-		cleanBase := cleanFileName(base)
-		cleanPath := filepath.Join(dir, cleanBase)
+		// Le dossier racine n'a pas d'entrée propre
+		if relPath == "." {
+			return nil
+		}
 
-		// Normaliser le chemin pour l'archive
-		cleanPath = filepath.ToSlash(cleanPath)
+		cleanPath := filepath.ToSlash(relPath)
+		if names != nil {
+			name, known := names.names[cleanPath]
+			if !known {
+				// Fichier apparu pendant la sauvegarde, absent de la table déjà écrite
+				if name = names.add(cleanPath); name != cleanPath {
+					logger.Info("Skipping %s, created during the backup", cleanPath)
+					if info.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+			}
+			cleanPath = name
+		}
 
-		header, err := tar.FileInfoHeader(info, cleanPath)
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return fmt.Errorf("failed to read link %s: %w", path, err)
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
 		if err != nil {
 			return fmt.Errorf("failed to create tar header for %s: %w", path, err)
 		}
@@ -240,49 +490,103 @@ func createBackup(sourcePath, targetFile string) error {
 			return fmt.Errorf("failed to write header for %s: %w", path, err)
 		}
 
-		if !info.IsDir() {
+		if info.Mode().IsRegular() {
 			file, err := os.Open(path)
 			if err != nil {
 				return fmt.Errorf("failed to open file %s: %w", path, err)
 			}
 			defer file.Close()
 
-			if _, err := io.Copy(tw, file); err != nil {
+			hash := sha256.New()
+			size, err := io.Copy(io.MultiWriter(tw, hash), file)
+			if err != nil {
 				return fmt.Errorf("failed to write file %s to archive: %w", path, err)
 			}
+			manifest.add(cleanPath, hash, size)
 		}
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// Le manifeste est la dernière entrée de l'archive
+	if err := manifest.write(tw); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if out != file {
+		if err := out.Close(); err != nil {
+			return err
+		}
+	}
+	return file.Close()
 }
 
-func verifyBackup(backupFile string) error {
+func verifyBackup(cfg *config.Config, backupFile string) error {
 	file, err := os.Open(backupFile)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	gz, err := gzip.NewReader(file)
+	return checkArchive(cfg, file)
+}
+
+// checkArchive vérifie que le contenu d'une archive correspond à son manifeste
+func checkArchive(cfg *config.Config, r io.Reader) error {
+	_, report, err := verifyArchive(cfg, r)
 	if err != nil {
 		return err
 	}
-	defer gz.Close()
-
-	tr := tar.NewReader(gz)
-	for {
-		_, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
+	if !report.hasManifest {
+		return fmt.Errorf("checksum manifest is missing")
 	}
-
+	if !report.ok() {
+		report.print()
+		return fmt.Errorf("archive content does not match its checksum manifest")
+	}
+	logger.Info("%d file(s) verified", report.files)
 	return nil
 }
 
+// streamVerifier vérifie une archive pendant son écriture, à mesure que ses octets lui sont écrits
+type streamVerifier struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func newStreamVerifier(cfg *config.Config) *streamVerifier {
+	pr, pw := io.Pipe()
+	v := &streamVerifier{pw: pw, done: make(chan error, 1)}
+	go func() {
+		err := checkArchive(cfg, pr)
+		// Lire la fin du flux pour ne jamais bloquer l'écriture de l'archive
+		io.Copy(io.Discard, pr)
+		v.done <- err
+	}()
+	return v
+}
+
+func (v *streamVerifier) Write(p []byte) (int, error) {
+	return v.pw.Write(p)
+}
+
+// Close termine le flux sans attendre le résultat
+func (v *streamVerifier) Close() error {
+	return v.pw.Close()
+}
+
+// Result termine le flux et retourne le résultat de la vérification
+func (v *streamVerifier) Result() error {
+	v.pw.Close()
+	return <-v.done
+}
+
 // GetCommand retourne la commande parent archive avec ses sous-commandes
 func GetCommand() *cobra.Command {
 	return ArchiveCmd
@@ -0,0 +1,221 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/destination"
+	"github.com/coyls/obs-cli/internal/ignore"
+)
+
+// recipientConfig retourne une configuration qui chiffre les archives pour une clé publique,
+// sans identité pour les relire
+func recipientConfig(t *testing.T, root string) *config.Config {
+	t.Helper()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{}
+	cfg.Config.Root = root
+	cfg.Config.Archive.Encryption = config.EncryptionConfig{
+		Enabled:    true,
+		Recipients: []string{identity.Recipient().String()},
+	}
+	return cfg
+}
+
+func TestCreateBackupVerifiesBeforeEncryption(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"Vault/Note.md":         "# Note\n",
+		"Vault/Folder/Other.md": "[[Note]]\n",
+		"Vault/image.png":       "\x89PNG",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := recipientConfig(t, root)
+	if canDecrypt(cfg) {
+		t.Fatal("a recipient-only configuration must not be able to decrypt")
+	}
+	filter, err := ignore.FromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "backup."+format+encryptedExtension)
+			plain := newStreamVerifier(cfg)
+			defer plain.Close()
+
+			if err := createBackup(cfg, root, filter, format, 0, target, plain); err != nil {
+				t.Fatalf("createBackup: %v", err)
+			}
+			if err := plain.Result(); err != nil {
+				t.Errorf("plaintext verification failed: %v", err)
+			}
+
+			data, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !crypt.IsEncryptedStream(data) {
+				t.Error("backup is not encrypted")
+			}
+		})
+	}
+}
+
+func TestStreamVerifierRejectsCorruptedArchive(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		manifest map[string]manifestFile
+	}{
+		{
+			name:     "changed file",
+			files:    map[string]string{"Vault/Note.md": "changed"},
+			manifest: map[string]manifestFile{"Vault/Note.md": {SHA256: "0000", Size: 7}},
+		},
+		{
+			name:     "missing file",
+			files:    map[string]string{},
+			manifest: map[string]manifestFile{"Vault/Note.md": {SHA256: "0000", Size: 7}},
+		},
+		{
+			name:  "missing manifest",
+			files: map[string]string{"Vault/Note.md": "note"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			for name, content := range tt.files {
+				tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: time.Now(), Format: tar.FormatUSTAR})
+				tw.Write([]byte(content))
+			}
+			if tt.manifest != nil {
+				data, _ := json.Marshal(manifest{Version: 1, Files: tt.manifest})
+				tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(data)), ModTime: time.Now(), Format: tar.FormatUSTAR})
+				tw.Write(data)
+			}
+			tw.Close()
+
+			v := newStreamVerifier(&config.Config{})
+			if _, err := v.Write(buf.Bytes()); err != nil {
+				t.Fatal(err)
+			}
+			if err := v.Result(); err == nil {
+				t.Error("corrupted archive was verified")
+			}
+		})
+	}
+}
+
+// fullDestination est une destination locale dont la capacité est limitée à capacity octets
+type fullDestination struct {
+	*destination.Local
+	root     string
+	capacity int64
+}
+
+func (d *fullDestination) Available() (int64, error) {
+	var used int64
+	err := filepath.Walk(d.root, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			used += info.Size()
+		}
+		return err
+	})
+	return d.capacity - used, err
+}
+
+func TestMakeSpacePrunesBeforeUpload(t *testing.T) {
+	now := time.Now()
+	name := func(days int) string {
+		return backupPrefix + now.AddDate(0, 0, -days).Format(timestampFormat) + "." + FormatTarGz
+	}
+
+	tests := []struct {
+		name     string
+		policy   config.RetentionConfig
+		capacity int64
+		wantErr  bool
+		kept     []string
+	}{
+		{
+			name:     "enough space",
+			policy:   config.RetentionConfig{Daily: 1},
+			capacity: 1000,
+			kept:     []string{name(1), name(2), name(3)},
+		},
+		{
+			name:     "expired generations freed",
+			policy:   config.RetentionConfig{Daily: 2},
+			capacity: 350,
+			kept:     []string{name(1)},
+		},
+		{
+			name:     "latest verified backup kept",
+			policy:   config.RetentionConfig{Daily: 1},
+			capacity: 350,
+			kept:     []string{name(1)},
+		},
+		{
+			name:     "nothing to prune",
+			policy:   config.RetentionConfig{Daily: 4},
+			capacity: 350,
+			wantErr:  true,
+			kept:     []string{name(1), name(2), name(3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dest := &fullDestination{Local: destination.NewLocal("test", root), root: root, capacity: tt.capacity}
+			for _, days := range []int{1, 2, 3} {
+				if err := os.WriteFile(filepath.Join(root, name(days)), make([]byte, 100), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := makeSpace(dest, tt.policy, name(0), 100)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("makeSpace error = %v, want error %v", err, tt.wantErr)
+			}
+
+			backups, err := listBackups(dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kept []string
+			for _, b := range backups {
+				kept = append(kept, b.Path)
+			}
+			if strings.Join(kept, ",") != strings.Join(tt.kept, ",") {
+				t.Errorf("kept %v, want %v", kept, tt.kept)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/destination"
+	"github.com/coyls/obs-cli/internal/logger"
+)
+
+// usbDestination est le nom de la destination créée à partir de 'config.archive.usb_path'
+const usbDestination = "usb"
+
+var destinationNames []string
+
+func init() {
+	for _, cmd := range []*cobra.Command{CreateCmd, ListCmd} {
+		cmd.Flags().StringSliceVarP(&destinationNames, "destination", "D", nil, "Use only this destination (repeatable, all by default)")
+	}
+	for _, cmd := range []*cobra.Command{ExtractCmd, RestoreCmd, VerifyCmd} {
+		cmd.Flags().StringSliceVarP(&destinationNames, "destination", "D", nil, "Read from this destination (first configured by default)")
+	}
+}
+
+// destinationConfigs retourne les destinations configurées, la clé USB en premier
+func destinationConfigs(cfg *config.Config) []config.DestinationConfig {
+	var configs []config.DestinationConfig
+	if cfg.Config.Archive.UsbPath != "" {
+		configs = append(configs, config.DestinationConfig{
+			Name: usbDestination,
+			Type: destination.TypeLocal,
+			Path: cfg.Config.Archive.UsbPath,
+		})
+	}
+	return append(configs, cfg.Config.Archive.Destinations...)
+}
+
+// selectDestinations retourne les destinations choisies avec --destination. Sans choix
+// explicite, toutes les destinations sont retournées si all est vrai, sinon la première.
+func selectDestinations(cfg *config.Config, all bool) ([]config.DestinationConfig, error) {
+	configs := destinationConfigs(cfg)
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no backup destination configured. Please set 'config.archive.usb_path' or 'config.archive.destinations' in your configuration")
+	}
+
+	if len(destinationNames) == 0 {
+		if all {
+			return configs, nil
+		}
+		return configs[:1], nil
+	}
+
+	var selected []config.DestinationConfig
+	for _, name := range destinationNames {
+		found := false
+		for _, c := range configs {
+			if c.Name == name {
+				selected = append(selected, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var names []string
+			for _, c := range configs {
+				names = append(names, c.Name)
+			}
+			return nil, fmt.Errorf("unknown destination '%s' (available: %s)", name, strings.Join(names, ", "))
+		}
+	}
+	return selected, nil
+}
+
+// openDestination se connecte à une destination. Une destination locale doit déjà exister :
+// un dossier absent signifie en général que le disque n'est pas branché.
+func openDestination(c config.DestinationConfig) (destination.Destination, error) {
+	if c.Type == "" || strings.EqualFold(c.Type, destination.TypeLocal) {
+		if _, err := os.Stat(c.Path); os.IsNotExist(err) {
+			if c.Name == usbDestination {
+				logger.Error("USB key is not connected at path: %s", c.Path)
+				return nil, fmt.Errorf("usb key not found at configured path")
+			}
+			logger.Error("Destination '%s' is not available at path: %s", c.Name, c.Path)
+			return nil, fmt.Errorf("destination '%s' not found at configured path", c.Name)
+		}
+	}
+
+	d, err := destination.New(c)
+	if err != nil {
+		logger.Error("Destination '%s' is not available: %s", c.Name, err.Error())
+		return nil, err
+	}
+
+	logger.Info("Destination '%s' is available at: %s", d.Name(), d.Location())
+	return d, nil
+}
+
+// openSourceDestination ouvre la destination à partir de laquelle une sauvegarde est relue
+func openSourceDestination(cfg *config.Config) (destination.Destination, error) {
+	configs, err := selectDestinations(cfg, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) > 1 {
+		return nil, fmt.Errorf("only one destination can be read at a time")
+	}
+	return openDestination(configs[0])
+}
+
+// backupReader ferme la destination avec la sauvegarde lue
+type backupReader struct {
+	io.ReadCloser
+	dest destination.Destination
+}
+
+func (b *backupReader) Close() error {
+	err := b.ReadCloser.Close()
+	if b.dest != nil {
+		b.dest.Close()
+	}
+	return err
+}
+
+// openBackup ouvre une sauvegarde : un fichier local si selector en désigne un, sinon
+// la sauvegarde choisie sur la destination (la plus récente si selector est vide)
+func openBackup(cfg *config.Config, selector string) (string, io.ReadCloser, error) {
+	if selector != "" && fileExists(selector) {
+		file, err := os.Open(selector)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to open backup file: %w", err)
+		}
+		return selector, file, nil
+	}
+
+	dest, err := openSourceDestination(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	b, err := resolveBackup(dest, selector)
+	if err != nil {
+		dest.Close()
+		logger.Error("%s", err.Error())
+		return "", nil, err
+	}
+
+	rc, err := dest.Get(b.Path)
+	if err != nil {
+		dest.Close()
+		return "", nil, fmt.Errorf("failed to open backup file: %w", err)
+	}
+	return b.Name(), &backupReader{ReadCloser: rc, dest: dest}, nil
+}
+
+// checkDestinationSpace vérifie l'espace libre d'une destination, s'il est connu
+func checkDestinationSpace(dest destination.Destination, requiredSpace int64) error {
+	availableSpace, err := dest.Available()
+	if err != nil {
+		return fmt.Errorf("failed to get available space: %w", err)
+	}
+	if availableSpace < 0 {
+		logger.Info("Available space on '%s' is unknown, skipping space check", dest.Name())
+		return nil
+	}
+	return checkSpace(dest.Name(), requiredSpace, availableSpace)
+}
@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"io"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/snapshot"
+)
+
+// encryptedExtension est ajoutée au nom des archives chiffrées
+const encryptedExtension = ".age"
+
+// passphrase est lue au plus une fois par commande (création puis vérification)
+var passphrase string
+
+func archivePassphrase(confirm bool) crypt.PassphraseFunc {
+	return func() (string, error) {
+		if passphrase == "" {
+			pass, err := crypt.ReadPassphrase(confirm)
+			if err != nil {
+				return "", err
+			}
+			passphrase = pass
+		}
+		return passphrase, nil
+	}
+}
+
+// canDecrypt indique si les sauvegardes créées avec cette configuration peuvent être relues :
+// avec des destinataires à clé publique, il faut aussi une identité pour déchiffrer
+func canDecrypt(cfg *config.Config) bool {
+	enc := cfg.Config.Archive.Encryption
+	return !enc.Enabled || len(enc.Recipients) == 0 || enc.IdentityFile != ""
+}
+
+func encryptWriter(cfg *config.Config, w io.Writer) (io.WriteCloser, error) {
+	return crypt.NewEncryptWriter(w, cfg.Config.Archive.Encryption.Recipients, archivePassphrase(true))
+}
+
+func decryptReader(cfg *config.Config, r io.Reader) (io.Reader, error) {
+	return crypt.NewDecryptReader(r, cfg.Config.Archive.Encryption.IdentityFile, archivePassphrase(false))
+}
+
+// repositoryOptions retourne les options de chiffrement du dépôt incrémental
+func repositoryOptions(cfg *config.Config) snapshot.Options {
+	opts := snapshot.Options{
+		Decrypt: func(r io.Reader) (io.Reader, error) {
+			return decryptReader(cfg, r)
+		},
+	}
+	if cfg.Config.Archive.Encryption.Enabled {
+		opts.Encrypt = func(w io.Writer) (io.WriteCloser, error) {
+			return encryptWriter(cfg, w)
+		}
+	}
+	return opts
+}
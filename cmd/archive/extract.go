@@ -1,37 +1,61 @@
-// !! ---------------
-// !! PAS TERMINER !!
-// !! ---------------
-
 package archive
 
 import (
 	"archive/tar"
-	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/destination"
 	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/safepath"
+)
+
+var (
+	restorePaths []string
+	inPlace      bool
+	assumeYes    bool
 )
 
 var ExtractCmd = &cobra.Command{
-	Use:   "extract",
-	Short: "Extract the latest backup from USB key",
+	Use:   "extract [backup]",
+	Short: "Extract a backup from a destination (latest by default)",
+	Long: `Extract a backup from the first destination (or --destination) into the
+configured extract path.
+
+The backup can be selected by its index in 'obs-cli archive list', by its
+timestamp (the most recent backup matching a prefix such as 2024-05-01 is used)
+or by its file name.
+
+Use --path to restore only a vault, folder or note, and --in-place to restore
+it back into the live vaults instead of the extract path.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runExtract()
+		selector := ""
+		if len(args) == 1 {
+			selector = args[0]
+		}
+		return runExtract(selector)
 	},
 }
 
 func init() {
+	for _, cmd := range []*cobra.Command{ExtractCmd, RestoreCmd} {
+		cmd.Flags().StringSliceVarP(&restorePaths, "path", "p", nil, "Restore only this vault, folder or note (repeatable)")
+		cmd.Flags().BoolVar(&inPlace, "in-place", false, "Restore into the live vaults instead of the extract path")
+		cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+	}
 	ArchiveCmd.AddCommand(ExtractCmd)
 }
 
-func runExtract() error {
+func runExtract(selector string) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
@@ -41,121 +65,349 @@ func runExtract() error {
 		return fmt.Errorf("invalid config: %w", err)
 	}
 
-	// Validate archive specific configuration
-	if cfg.Config.Archive.UsbPath == "" {
-		return fmt.Errorf("USB path for archive is required. Please set 'config.archive.usb_path' in your configuration")
-	}
-	if cfg.Config.Archive.ExtractPath == "" {
+	if cfg.Config.Archive.ExtractPath == "" && !inPlace {
 		return fmt.Errorf("extract path is required. Please set 'config.archive.extract_path' in your configuration")
 	}
 
 	logger.PrintHeader("Extract Obsidian Vaults Backup")
 
-	// Check USB path
-	usbPath := cfg.Config.Archive.UsbPath
-	if _, err := os.Stat(usbPath); os.IsNotExist(err) {
-		logger.Error("USB key is not connected at path: %s", usbPath)
-		return fmt.Errorf("usb key not found at configured path")
+	name, backupFile, err := openBackup(cfg, selector)
+	if err != nil {
+		return err
+	}
+	defer backupFile.Close()
+
+	logger.Info("Found backup: %s", name)
+
+	match, err := pathFilter(cfg, restorePaths)
+	if err != nil {
+		return err
+	}
+
+	targetDir, ok, err := prepareTarget(cfg)
+	if err != nil || !ok {
+		return err
+	}
+
+	logger.Info("Extracting backup to: %s", targetDir)
+
+	// Extract backup
+	extracted, err := extractBackup(cfg, backupFile, targetDir, match)
+	if err != nil {
+		return fmt.Errorf("failed to extract backup: %w", err)
+	}
+
+	if match != nil && extracted == 0 {
+		logger.Error("No file in backup matches: %s", strings.Join(restorePaths, ", "))
+		return fmt.Errorf("nothing to restore")
 	}
 
-	logger.Info("USB key is connected at: %s", usbPath)
+	logger.Success("%d file(s) extracted successfully!", extracted)
+	return nil
+}
 
-	// Find latest backup
-	backupFile, err := findPreviousBackup(usbPath)
+// resolveBackup retrouve une sauvegarde par index, timestamp ou nom (la plus récente si vide)
+func resolveBackup(dest destination.Destination, selector string) (backup, error) {
+	backups, err := listBackups(dest)
 	if err != nil {
-		return fmt.Errorf("failed to find backup: %w", err)
+		return backup{}, fmt.Errorf("failed to find backup: %w", err)
+	}
+	if len(backups) == 0 {
+		return backup{}, fmt.Errorf("no backup found on '%s'", dest.Name())
+	}
+
+	if selector == "" {
+		return backups[0], nil
 	}
 
-	if backupFile == "" {
-		logger.Error("No backup found on USB key")
-		return fmt.Errorf("no backup found")
+	if index, err := strconv.Atoi(selector); err == nil {
+		if index < 0 || index >= len(backups) {
+			return backup{}, fmt.Errorf("no backup at index %d (%d backups available)", index, len(backups))
+		}
+		return backups[index], nil
+	}
+
+	// Les sauvegardes sont triées de la plus récente à la plus ancienne
+	for _, b := range backups {
+		if b.Name() == selector || strings.HasPrefix(b.Time.Format(timestampFormat), selector) {
+			return b, nil
+		}
+	}
+
+	return backup{}, fmt.Errorf("no backup matches '%s'", selector)
+}
+
+// pathFilter convertit les chemins demandés (nom de vault, dossier ou note) en filtre sur les
+// chemins de l'archive. Retourne nil si tout doit être restauré.
+func pathFilter(cfg *config.Config, paths []string) (func(string) bool, error) {
+	if len(paths) == 0 {
+		return nil, nil
 	}
 
-	logger.Info("Found backup: %s", filepath.Base(backupFile))
+	var prefixes []string
+	for _, p := range paths {
+		if vaultConfig, exists := cfg.GetVaultConfig(p); exists {
+			p = vaultConfig.VaultPath
+		}
+		p = strings.Trim(path.Clean(filepath.ToSlash(p)), "/")
+		if p == "" || p == "." || !filepath.IsLocal(filepath.FromSlash(p)) {
+			return nil, fmt.Errorf("invalid restore path: %s", p)
+		}
+		prefixes = append(prefixes, p)
+	}
+
+	return func(name string) bool {
+		name = strings.Trim(path.Clean(name), "/")
+		for _, prefix := range prefixes {
+			if name == prefix || strings.HasPrefix(name, prefix+"/") {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// prepareTarget retourne le dossier de destination : le dossier d'extraction, ou la racine
+// des vaults avec --in-place. Retourne false si l'utilisateur annule.
+func prepareTarget(cfg *config.Config) (string, bool, error) {
+	if inPlace {
+		logger.Info("Files from the backup will overwrite the live vaults in %s", cfg.Config.Root)
+		if !confirm("Do you want to continue?") {
+			logger.Info("Operation cancelled")
+			return "", false, nil
+		}
+		return cfg.Config.Root, true, nil
+	}
 
-	// Check if backup directory exists
 	backupDir := cfg.Config.Archive.ExtractPath
+
+	// Une restauration sélective complète le dossier d'extraction sans le supprimer
+	if len(restorePaths) > 0 {
+		if err := os.MkdirAll(backupDir, 0755); err != nil {
+			return "", false, fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		return backupDir, true, nil
+	}
+
+	ok, err := prepareExtractDir(backupDir)
+	return backupDir, ok, err
+}
+
+func confirm(question string) bool {
+	if assumeYes {
+		return true
+	}
+
+	fmt.Printf("%s (y/N): ", question)
+
+	var response string
+	fmt.Scanln(&response)
+	return strings.ToLower(response) == "y"
+}
+
+// prepareExtractDir demande confirmation avant de remplacer un dossier d'extraction existant
+func prepareExtractDir(backupDir string) (bool, error) {
 	if _, err := os.Stat(backupDir); err == nil {
 		logger.Info("Backup directory already exists: %s", backupDir)
-		fmt.Print("Do you want to delete the existing directory and extract the backup? (y/N): ")
 
-		var response string
-		fmt.Scanln(&response)
-
-		if strings.ToLower(response) != "y" {
+		if !confirm("Do you want to delete the existing directory and extract the backup?") {
 			logger.Info("Operation cancelled")
-			return nil
+			return false, nil
 		}
 
 		logger.Info("Deleting existing directory...")
 		if err := os.RemoveAll(backupDir); err != nil {
-			return fmt.Errorf("failed to delete existing directory: %w", err)
+			return false, fmt.Errorf("failed to delete existing directory: %w", err)
 		}
 	}
 
 	// Create backup directory
 	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		return fmt.Errorf("failed to create backup directory: %w", err)
+		return false, fmt.Errorf("failed to create backup directory: %w", err)
 	}
+	return true, nil
+}
 
-	logger.Info("Extracting backup to: %s", backupDir)
-
-	// Extract backup
-	if err := extractBackup(backupFile, backupDir); err != nil {
-		return fmt.Errorf("failed to extract backup: %w", err)
+// safeEntryPath vérifie qu'une entrée de l'archive reste dans le dossier de destination
+func safeEntryPath(name string) (string, bool) {
+	clean := path.Clean(strings.TrimPrefix(name, "./"))
+	if clean == "." || !filepath.IsLocal(filepath.FromSlash(clean)) {
+		return "", false
 	}
+	return clean, true
+}
 
-	logger.Success("Backup extracted successfully!")
-	return nil
+// dirEntry est une entrée de l'archive dont l'application est différée
+type dirEntry struct {
+	path   string
+	header *tar.Header
 }
 
-func extractBackup(backupFile, targetDir string) error {
-	file, err := os.Open(backupFile)
+func extractBackup(cfg *config.Config, backupFile io.Reader, targetDir string, match func(string) bool) (int, error) {
+	tr, err := openArchive(cfg, backupFile)
 	if err != nil {
-		return fmt.Errorf("failed to open backup file: %w", err)
+		return 0, err
 	}
-	defer file.Close()
+	defer tr.Close()
 
-	gz, err := gzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
-	}
-	defer gz.Close()
+	var dirs []dirEntry
+	var symlinks []safepath.Symlink
+	extracted, rejected := 0, 0
+
+	// Une archive portable est restaurée sous ses noms portables, liens réécrits,
+	// sauf dans les vaults ou avec --original-names
+	useOriginal := originalNames || inPlace
+	var renamed map[string]string
+	var links *linkRewriter
 
-	tr := tar.NewReader(gz)
 	for {
 		header, err := tr.Next()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("failed to read tar header: %w", err)
+			return extracted, fmt.Errorf("failed to read tar header: %w", err)
+		}
+
+		if header.Name == manifestName {
+			continue
+		}
+
+		if header.Name == namesName {
+			if renamed, err = readNames(tr); err != nil {
+				return extracted, err
+			}
+			if !useOriginal && len(renamed) > 0 {
+				links = newLinkRewriter(cfg, renamed)
+			}
+			continue
+		}
+
+		name, ok := safeEntryPath(header.Name)
+		if !ok {
+			if path.Clean(header.Name) != "." {
+				logger.Error("Rejected unsafe entry: %s", header.Name)
+				rejected++
+			}
+			continue
+		}
+
+		original := name
+		if o, ok := renamed[name]; ok {
+			if original, ok = safeEntryPath(o); !ok {
+				logger.Error("Rejected unsafe entry: %s", o)
+				rejected++
+				continue
+			}
+		}
+
+		// Les liens sont résolus parmi tous les fichiers de l'archive, même non restaurés
+		if links != nil && (header.Typeflag == tar.TypeReg || header.Typeflag == tar.TypeSymlink) {
+			links.addFile(original)
+		}
+
+		if match != nil && !match(original) && !match(name) {
+			continue
 		}
 
-		targetPath := filepath.Join(targetDir, header.Name)
+		if useOriginal {
+			name = original
+		}
+
+		targetPath := filepath.Join(targetDir, filepath.FromSlash(name))
+		mode := header.FileInfo().Mode().Perm()
+
+		// Ne jamais créer un dossier ou écrire un fichier à travers un dossier qui est un lien
+		if header.Typeflag != tar.TypeSymlink && !safepath.Parents(targetDir, name, header.Typeflag == tar.TypeDir) {
+			logger.Error("Rejected entry through a symbolic link: %s", name)
+			rejected++
+			continue
+		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
 			if err := os.MkdirAll(targetPath, 0755); err != nil {
-				return fmt.Errorf("failed to create directory %s: %w", targetPath, err)
+				return extracted, fmt.Errorf("failed to create directory %s: %w", targetPath, err)
 			}
+			dirs = append(dirs, dirEntry{path: targetPath, header: header})
 		case tar.TypeReg:
 			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("failed to create directory for %s: %w", targetPath, err)
+				return extracted, fmt.Errorf("failed to create directory for %s: %w", targetPath, err)
 			}
 
-			outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
+			// Ne jamais écrire à travers un lien symbolique existant
+			if err := safepath.RemoveLink(targetPath); err != nil {
+				return extracted, err
+			}
+
+			outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
 			if err != nil {
-				return fmt.Errorf("failed to create file %s: %w", targetPath, err)
+				return extracted, fmt.Errorf("failed to create file %s: %w", targetPath, err)
+			}
+
+			if links != nil && strings.EqualFold(path.Ext(name), ".md") {
+				links.addNote(original, targetPath, header.ModTime)
 			}
 
 			if _, err := io.Copy(outFile, tr); err != nil {
 				outFile.Close()
-				return fmt.Errorf("failed to write file %s: %w", targetPath, err)
+				return extracted, fmt.Errorf("failed to write file %s: %w", targetPath, err)
+			}
+			if err := outFile.Close(); err != nil {
+				return extracted, fmt.Errorf("failed to write file %s: %w", targetPath, err)
+			}
+
+			if err := os.Chmod(targetPath, mode); err != nil {
+				return extracted, err
 			}
-			outFile.Close()
+			if err := os.Chtimes(targetPath, header.ModTime, header.ModTime); err != nil {
+				return extracted, err
+			}
+			extracted++
+		case tar.TypeSymlink:
+			// Les liens sont créés après les fichiers, qui ne peuvent donc pas les traverser
+			symlinks = append(symlinks, safepath.Symlink{Path: name, Target: header.Linkname})
+		default:
+			logger.Error("Rejected unsupported entry: %s", name)
+			rejected++
 		}
 	}
 
-	return nil
+	n, err := extractLinks(targetDir, symlinks)
+	rejected += n
+	if err != nil {
+		return extracted, err
+	}
+
+	if links != nil {
+		rewritten, err := links.apply()
+		if err != nil {
+			return extracted, err
+		}
+		if rewritten > 0 {
+			logger.Info("Links updated to the portable names in %d note(s)", rewritten)
+		}
+	}
+
+	// Les dates des dossiers sont appliquées en dernier, l'écriture des fichiers les modifiant
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Chmod(dirs[i].path, dirs[i].header.FileInfo().Mode().Perm())
+		os.Chtimes(dirs[i].path, dirs[i].header.ModTime, dirs[i].header.ModTime)
+	}
+
+	if rejected > 0 {
+		logger.Error("%d unsafe or unsupported entries were not extracted", rejected)
+	}
+
+	return extracted, nil
+}
+
+// extractLinks crée les liens symboliques de l'archive une fois les fichiers écrits et retourne
+// le nombre de liens rejetés
+func extractLinks(targetDir string, symlinks []safepath.Symlink) (int, error) {
+	rejected, err := safepath.CreateLinks(targetDir, symlinks)
+	for _, l := range rejected {
+		logger.Error("Rejected %s: %s -> %s", l.Reason, l.Path, l.Target)
+	}
+	return len(rejected), err
 }
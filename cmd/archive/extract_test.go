@@ -0,0 +1,251 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/config"
+)
+
+// entry est une entrée d'archive de test : un fichier, un dossier (nom terminé par /) ou un
+// lien symbolique si link est renseigné
+type entry struct {
+	name, link, content string
+}
+
+func buildTar(t *testing.T, entries []entry) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, ModTime: time.Now(), Format: tar.FormatUSTAR}
+		switch {
+		case e.link != "":
+			header.Typeflag, header.Linkname = tar.TypeSymlink, e.link
+		case strings.HasSuffix(e.name, "/"):
+			header.Typeflag, header.Mode = tar.TypeDir, 0755
+		default:
+			header.Typeflag, header.Size = tar.TypeReg, int64(len(e.content))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+// escapes retourne les fichiers créés à côté de target, et les liens extraits sous target qui en
+// sortent ; les liens de existing, créés avant l'extraction, sont ignorés
+func escapes(t *testing.T, root, target string, existing map[string]string) []string {
+	t.Helper()
+	var found []string
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "target" && e.Name() != "outside" {
+			found = append(found, e.Name())
+		}
+	}
+	outside, _ := os.ReadDir(filepath.Join(root, "outside"))
+	for _, e := range outside {
+		found = append(found, filepath.Join("outside", e.Name()))
+	}
+
+	real, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filepath.WalkDir(target, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.Type()&os.ModeSymlink == 0 {
+			return err
+		}
+		rel, _ := filepath.Rel(target, p)
+		if _, ok := existing[filepath.ToSlash(rel)]; ok {
+			return nil
+		}
+		resolved, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			return nil
+		}
+		if rel, err := filepath.Rel(real, resolved); err != nil || (rel != "." && !filepath.IsLocal(rel)) {
+			found = append(found, p+" -> "+resolved)
+		}
+		return nil
+	})
+	return found
+}
+
+func TestExtractBackupSymlinkEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+		// existing crée un lien sous le dossier de destination avant l'extraction
+		existing map[string]string
+		want     []string
+	}{
+		{
+			name: "chained links",
+			entries: []entry{
+				{name: "a/b", link: ".."},
+				{name: "a/b/c", link: ".."},
+				{name: "a/b/c/x.md", content: "escaped"},
+			},
+			want: []string{"a/b/c/x.md"},
+		},
+		{
+			name: "chained links with directories",
+			entries: []entry{
+				{name: "a/"},
+				{name: "a/b", link: ".."},
+				{name: "a/b/c", link: ".."},
+				{name: "a/b/c/x.md", content: "escaped"},
+				{name: "a/b/x.md", content: "escaped"},
+			},
+		},
+		{
+			name: "link through an earlier link",
+			entries: []entry{
+				{name: "a/up", link: ".."},
+				{name: "x", link: "a/up/.."},
+				{name: "x/y.md", content: "escaped"},
+			},
+		},
+		{
+			name: "link resolved by a later link",
+			entries: []entry{
+				{name: "x", link: "a/.."},
+				{name: "a", link: "."},
+			},
+		},
+		{
+			name: "link replacing a directory",
+			entries: []entry{
+				{name: "a/"},
+				{name: "x", link: "a/.."},
+				{name: "a", link: "."},
+			},
+		},
+		{
+			name:     "existing link in the target",
+			existing: map[string]string{"vault": "../outside"},
+			entries: []entry{
+				{name: "vault/note.md", content: "escaped"},
+				{name: "vault/sub/"},
+			},
+		},
+		{
+			name: "links inside the backup",
+			entries: []entry{
+				{name: "notes/a.md", content: "a"},
+				{name: "notes/link.md", link: "a.md"},
+				{name: "sub/up", link: "../notes"},
+			},
+			want: []string{"notes/a.md", "notes/link.md", "sub/up"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			target := filepath.Join(root, "target")
+			if err := os.MkdirAll(filepath.Join(root, "outside"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for name, link := range tt.existing {
+				if err := os.Symlink(link, filepath.Join(target, name)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, err := extractBackup(&config.Config{}, buildTar(t, tt.entries), target, nil); err != nil {
+				t.Fatalf("extractBackup: %v", err)
+			}
+
+			if found := escapes(t, root, target, tt.existing); len(found) > 0 {
+				t.Errorf("extraction escaped the target directory: %v", found)
+			}
+			for _, want := range tt.want {
+				if _, err := os.Lstat(filepath.Join(target, filepath.FromSlash(want))); err != nil {
+					t.Errorf("%s not extracted: %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractBackupPortableLinks(t *testing.T) {
+	files := []entry{
+		{name: "V/A/Note.md", content: "# Upper\n"},
+		{name: "V/A/note.md", content: "# Lower\n"},
+		{name: "V/B/note.md", content: "# Other\n"},
+		{name: "V/Index.md", content: "[[A/note]] [[A/Note]] [[B/note]] [b](B/note.md) [a](A/note.md)\n"},
+		{name: "V/B/Local.md", content: "[[note]] [[Note]]\n"},
+	}
+
+	table := newNameTable()
+	for _, dir := range []string{"V", "V/A", "V/B"} {
+		table.add(dir)
+	}
+	for _, f := range files {
+		table.add(f.name)
+	}
+	renamed := table.renamed()
+	if renamed["V/A/note-2.md"] != "V/A/note.md" {
+		t.Fatalf("unexpected name table %v", renamed)
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(renamed); err != nil {
+		t.Fatal(err)
+	}
+	entries := append([]entry{{name: namesName, content: buf.String()}}, files...)
+	for i := range entries[1:] {
+		if name, ok := table.names[entries[i+1].name]; ok {
+			entries[i+1].name = name
+		}
+	}
+
+	cfg := &config.Config{}
+	cfg.Config.Vaults = map[string]*config.VaultConfig{"V": {VaultPath: "V"}}
+	target := t.TempDir()
+	if _, err := extractBackup(cfg, buildTar(t, entries), target, nil); err != nil {
+		t.Fatalf("extractBackup: %v", err)
+	}
+
+	want := map[string]string{
+		"V/A/Note.md":   "# Upper\n",
+		"V/A/note-2.md": "# Lower\n",
+		"V/B/note.md":   "# Other\n",
+		"V/Index.md":    "[[A/note-2]] [[A/Note]] [[B/note]] [b](B/note.md) [a](A/note-2.md)\n",
+		"V/B/Local.md":  "[[note]] [[Note]]\n",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(target, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s not extracted: %v", name, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+)
+
+const (
+	FormatTarGz  = "tar.gz"
+	FormatTarZst = "tar.zst"
+	FormatTarXz  = "tar.xz"
+	FormatZip    = "zip"
+
+	// formatTar n'est jamais écrit, mais une archive tar non compressée peut être lue
+	formatTar = "tar"
+)
+
+// formats liste les formats d'archive supportés en écriture
+var formats = []string{FormatTarGz, FormatTarZst, FormatTarXz, FormatZip}
+
+var (
+	archiveFormatFlag string
+	compressionLevel  int
+)
+
+func init() {
+	CreateCmd.Flags().StringVarP(&archiveFormatFlag, "format", "f", "", "Archive format: "+strings.Join(formats, ", ")+" (default tar.gz)")
+	CreateCmd.Flags().IntVar(&compressionLevel, "level", 0, "Compression level (0 for the format default)")
+}
+
+// archiveFormat retourne le format et le niveau de compression choisis, les options de la
+// ligne de commande remplaçant la configuration
+func archiveFormat(cfg *config.Config) (string, int, error) {
+	format := cfg.Config.Archive.Format
+	if archiveFormatFlag != "" {
+		format = archiveFormatFlag
+	}
+	if format == "" {
+		format = FormatTarGz
+	}
+	format = strings.TrimPrefix(strings.ToLower(format), ".")
+
+	level := cfg.Config.Archive.Level
+	if compressionLevel != 0 {
+		level = compressionLevel
+	}
+
+	var maxLevel int
+	switch format {
+	case FormatTarGz, FormatTarXz, FormatZip:
+		maxLevel = 9
+	case FormatTarZst:
+		maxLevel = 22
+	default:
+		return "", 0, fmt.Errorf("unknown archive format '%s' (expected %s)", format, strings.Join(formats, ", "))
+	}
+	if level < 0 || level > maxLevel {
+		return "", 0, fmt.Errorf("invalid compression level %d for %s (expected 1 to %d, or 0 for the default)", level, format, maxLevel)
+	}
+	return format, level, nil
+}
+
+// archiveWriter écrit les entrées d'une archive à partir d'en-têtes tar, quel que soit le format
+type archiveWriter interface {
+	WriteHeader(header *tar.Header) error
+	io.Writer
+	// Close termine l'archive et la compression, sans fermer le flux sous-jacent
+	Close() error
+}
+
+// tarWriter écrit une archive tar dans un flux compressé
+type tarWriter struct {
+	*tar.Writer
+	compressor io.WriteCloser
+}
+
+func (t *tarWriter) Close() error {
+	if err := t.Writer.Close(); err != nil {
+		return err
+	}
+	return t.compressor.Close()
+}
+
+func newArchiveWriter(w io.Writer, format string, level int) (archiveWriter, error) {
+	var compressor io.WriteCloser
+	var err error
+
+	switch format {
+	case FormatTarGz:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		compressor, err = gzip.NewWriterLevel(w, level)
+	case FormatTarZst:
+		opts := []zstd.EOption{}
+		if level > 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		compressor, err = zstd.NewWriter(w, opts...)
+	case FormatTarXz:
+		compressor, err = xz.WriterConfig{DictCap: xzDictCap(level)}.NewWriter(w)
+	case FormatZip:
+		return newZipWriter(w, level), nil
+	default:
+		return nil, fmt.Errorf("unknown archive format '%s'", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tarWriter{Writer: tar.NewWriter(compressor), compressor: compressor}, nil
+}
+
+// xzDictCap retourne la taille de dictionnaire des préréglages de la commande xz (-1 à -9)
+func xzDictCap(level int) int {
+	sizes := []int{8 << 20, 1 << 20, 2 << 20, 4 << 20, 4 << 20, 8 << 20, 8 << 20, 16 << 20, 32 << 20, 64 << 20}
+	return sizes[level]
+}
+
+// zipWriter écrit une archive zip, lisible sans tar sur tous les systèmes
+type zipWriter struct {
+	zw *zip.Writer
+	w  io.Writer
+}
+
+func newZipWriter(w io.Writer, level int) *zipWriter {
+	zw := zip.NewWriter(w)
+	if level > 0 {
+		zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, level)
+		})
+	}
+	return &zipWriter{zw: zw}
+}
+
+func (z *zipWriter) WriteHeader(header *tar.Header) error {
+	fh := &zip.FileHeader{
+		Name:     header.Name,
+		Modified: header.ModTime,
+		Method:   zip.Deflate,
+	}
+	fh.SetMode(header.FileInfo().Mode())
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		fh.Name = strings.TrimSuffix(fh.Name, "/") + "/"
+		fh.Method = zip.Store
+	case tar.TypeSymlink:
+		// Comme la commande zip, la cible du lien est le contenu de l'entrée
+		fh.Method = zip.Store
+	}
+
+	w, err := z.zw.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+	z.w = w
+
+	if header.Typeflag == tar.TypeSymlink {
+		_, err = io.WriteString(w, header.Linkname)
+	}
+	return err
+}
+
+func (z *zipWriter) Write(p []byte) (int, error) {
+	if z.w == nil {
+		return 0, fmt.Errorf("zip: write before header")
+	}
+	return z.w.Write(p)
+}
+
+func (z *zipWriter) Close() error {
+	return z.zw.Close()
+}
+
+// detectFormat reconnaît le format d'une archive à partir de ses premiers octets
+func detectFormat(header []byte) (string, bool) {
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return FormatTarGz, true
+	case bytes.HasPrefix(header, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return FormatTarZst, true
+	case bytes.HasPrefix(header, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return FormatTarXz, true
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return FormatZip, true
+	case len(header) >= 262 && string(header[257:262]) == "ustar":
+		return formatTar, true
+	}
+	return "", false
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// archiveReader lit les entrées d'une sauvegarde, déchiffrée si besoin, sous forme d'en-têtes tar
+type archiveReader struct {
+	Format  string
+	next    func() (*tar.Header, error)
+	r       io.Reader
+	closers []io.Closer
+}
+
+// Next passe à l'entrée suivante, dont le contenu est ensuite lu avec Read
+func (a *archiveReader) Next() (*tar.Header, error) {
+	return a.next()
+}
+
+func (a *archiveReader) Read(p []byte) (int, error) {
+	if a.r == nil {
+		return 0, io.EOF
+	}
+	return a.r.Read(p)
+}
+
+func (a *archiveReader) Close() error {
+	var firstErr error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if err := a.closers[i].Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// openArchive lit une sauvegarde, que l'appelant reste chargé de fermer. Le chiffrement
+// puis le format sont détectés à partir des premiers octets, jamais à partir du nom.
+func openArchive(cfg *config.Config, backup io.Reader) (*archiveReader, error) {
+	a := &archiveReader{}
+
+	br := bufio.NewReader(backup)
+	var r io.Reader = br
+	header, _ := br.Peek(len(crypt.StreamMagic))
+	encrypted := crypt.IsEncryptedStream(header)
+	if encrypted {
+		var err error
+		if r, err = decryptReader(cfg, r); err != nil {
+			return nil, fmt.Errorf("failed to decrypt backup: %w", err)
+		}
+		br = bufio.NewReader(r)
+		r = br
+	}
+
+	header, _ = br.Peek(262)
+	format, ok := detectFormat(header)
+	if !ok {
+		return nil, fmt.Errorf("unknown archive format")
+	}
+	a.Format = format
+
+	if format == FormatZip {
+		var file *os.File
+		if f, isFile := backup.(*os.File); isFile && !encrypted {
+			file = f
+		}
+		if err := a.openZip(r, file); err != nil {
+			a.Close()
+			return nil, err
+		}
+		return a, nil
+	}
+
+	switch format {
+	case FormatTarGz:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		a.closers = append(a.closers, gz)
+		r = gz
+	case FormatTarZst:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		a.closers = append(a.closers, zr.IOReadCloser())
+		r = zr
+	case FormatTarXz:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create xz reader: %w", err)
+		}
+		r = xr
+	}
+
+	tr := tar.NewReader(r)
+	a.next = tr.Next
+	a.r = tr
+	return a, nil
+}
+
+// openZip lit une archive zip. Le format zip nécessitant un accès aléatoire, un flux
+// (sauvegarde distante ou chiffrée) est d'abord copié dans un fichier temporaire.
+func (a *archiveReader) openZip(r io.Reader, file *os.File) error {
+	if file != nil {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			file = nil
+		}
+	}
+
+	if file == nil {
+		tmp, err := os.CreateTemp("", "obs-cli-*.zip")
+		if err != nil {
+			return err
+		}
+		a.closers = append(a.closers, closerFunc(func() error {
+			tmp.Close()
+			return os.Remove(tmp.Name())
+		}))
+		if _, err := io.Copy(tmp, r); err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+		file = tmp
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return fmt.Errorf("failed to create zip reader: %w", err)
+	}
+
+	index := 0
+	var current io.ReadCloser
+	a.closers = append(a.closers, closerFunc(func() error {
+		if current != nil {
+			return current.Close()
+		}
+		return nil
+	}))
+
+	a.next = func() (*tar.Header, error) {
+		if current != nil {
+			current.Close()
+			current, a.r = nil, nil
+		}
+		if index >= len(zr.File) {
+			return nil, io.EOF
+		}
+		f := zr.File[index]
+		index++
+
+		header, err := zipHeader(f)
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeDir {
+			return header, nil
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		if header.Typeflag == tar.TypeSymlink {
+			target, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+			}
+			header.Linkname = string(target)
+			return header, nil
+		}
+
+		current, a.r = rc, rc
+		return header, nil
+	}
+	return nil
+}
+
+// zipHeader convertit une entrée zip en en-tête tar
+func zipHeader(f *zip.File) (*tar.Header, error) {
+	mode := f.Mode()
+	header := &tar.Header{
+		Name:    strings.TrimSuffix(f.Name, "/"),
+		Mode:    int64(mode.Perm()),
+		ModTime: f.Modified,
+		Size:    int64(f.UncompressedSize64),
+	}
+
+	switch {
+	case mode.IsDir() || strings.HasSuffix(f.Name, "/"):
+		header.Typeflag = tar.TypeDir
+		header.Size = 0
+	case mode&os.ModeSymlink != 0:
+		header.Typeflag = tar.TypeSymlink
+		header.Size = 0
+	case mode.IsRegular():
+		header.Typeflag = tar.TypeReg
+	default:
+		// Type non supporté, rejeté à l'extraction
+		header.Typeflag = tar.TypeChar
+	}
+	return header, nil
+}
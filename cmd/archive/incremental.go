@@ -0,0 +1,234 @@
+package archive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/destination"
+	"github.com/coyls/obs-cli/internal/ignore"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/snapshot"
+)
+
+// repositoryDir est le dossier du dépôt incrémental sur chaque destination
+const repositoryDir = "obs-cli-repo"
+
+var RestoreCmd = &cobra.Command{
+	Use:   "restore [snapshot]",
+	Short: "Restore an incremental snapshot from a destination (latest by default)",
+	Long: `Restore an incremental snapshot into the configured extract path.
+
+The snapshot can be selected by its index in 'obs-cli archive list' or by its
+identifier (the most recent snapshot matching a prefix such as 2024-05-01 is used).
+
+Use --path to restore only a vault, folder or note, and --in-place to restore
+it back into the live vaults instead of the extract path.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := ""
+		if len(args) == 1 {
+			id = args[0]
+		}
+		return runRestore(id)
+	},
+}
+
+func init() {
+	ArchiveCmd.AddCommand(RestoreCmd)
+}
+
+// repositoryBackend retourne le dossier du dépôt incrémental sur la destination
+func repositoryBackend(dest destination.Destination) destination.Destination {
+	return destination.WithPrefix(dest, repositoryDir)
+}
+
+func openRepository(cfg *config.Config, dest destination.Destination) (*snapshot.Repository, error) {
+	return snapshot.Open(repositoryBackend(dest), repositoryOptions(cfg))
+}
+
+func createSnapshot(cfg *config.Config, dest destination.Destination) error {
+	repo, err := openRepository(cfg, dest)
+	if err != nil {
+		return err
+	}
+
+	filter, err := ignore.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Seuls les fichiers nouveaux ou modifiés sont écrits sur la destination
+	requiredSpace, err := repo.PendingSize(cfg.Config.Root, filter)
+	if err != nil {
+		return fmt.Errorf("failed to calculate required space: %w", err)
+	}
+	requiredSpace += requiredSpace / 10
+
+	if err := checkDestinationSpace(dest, requiredSpace); err != nil {
+		return err
+	}
+
+	logger.Info("Creating incremental snapshot of all vaults on '%s'...", dest.Name())
+	snap, stats, err := repo.Create(cfg.Config.Root, filter)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	logger.Info("Files: %d (%d unchanged)", stats.Files, stats.UnchangedFiles)
+	logger.Info("Chunks: %d new, %d reused", stats.NewChunks, stats.ReusedChunks)
+	logger.Info("Written: %s", formatBytes(stats.BytesWritten))
+
+	logger.Info("Verifying snapshot integrity...")
+	if err := repo.Check(snap, false); err != nil {
+		repo.Forget(snap.ID)
+		return fmt.Errorf("snapshot verification failed: %w", err)
+	}
+
+	logger.Success("Snapshot %s completed and verified on '%s'!", snap.ID, dest.Name())
+
+	if err := pruneSnapshots(repo, cfg.Config.Archive.Retention); err != nil {
+		return fmt.Errorf("failed to remove expired snapshots: %w", err)
+	}
+	return nil
+}
+
+// snapshotBackups convertit les identifiants de snapshots pour leur appliquer la politique de rétention
+func snapshotBackups(ids []string) []backup {
+	var backups []backup
+	for _, id := range ids {
+		t, err := time.ParseInLocation(snapshot.IDFormat, id, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{Path: id, Time: t})
+	}
+	return backups
+}
+
+func pruneSnapshots(repo *snapshot.Repository, policy config.RetentionConfig) error {
+	ids, err := repo.IDs()
+	if err != nil {
+		return err
+	}
+
+	expired := expiredBackups(snapshotBackups(ids), policy)
+	if len(expired) == 0 {
+		logger.Info("No expired snapshot to remove")
+		return nil
+	}
+
+	for _, b := range expired {
+		logger.Info("Removing expired snapshot: %s", b.Path)
+		if err := repo.Forget(b.Path); err != nil {
+			return err
+		}
+	}
+
+	removed, err := repo.Prune()
+	if err != nil {
+		return err
+	}
+	logger.Success("%d expired snapshot(s) removed, %d unused chunk(s) freed", len(expired), removed)
+	return nil
+}
+
+func runRestore(id string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if cfg.Config.Archive.ExtractPath == "" && !inPlace {
+		return fmt.Errorf("extract path is required. Please set 'config.archive.extract_path' in your configuration")
+	}
+
+	logger.PrintHeader("Restore Obsidian Vaults Snapshot")
+
+	dest, err := openSourceDestination(cfg)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	if exists, err := snapshot.Exists(repositoryBackend(dest)); err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	} else if !exists {
+		logger.Error("No incremental repository found on '%s' at: %s", dest.Name(), dest.Location())
+		return fmt.Errorf("repository not found")
+	}
+
+	repo, err := openRepository(cfg, dest)
+	if err != nil {
+		return err
+	}
+
+	snap, err := resolveSnapshot(repo, id)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	logger.Info("Found snapshot: %s (%s)", snap.ID, formatBytes(snap.Size()))
+
+	match, err := pathFilter(cfg, restorePaths)
+	if err != nil {
+		return err
+	}
+
+	targetDir, ok, err := prepareTarget(cfg)
+	if err != nil || !ok {
+		return err
+	}
+
+	logger.Info("Restoring snapshot to: %s", targetDir)
+	restored, err := repo.Restore(snap, targetDir, match)
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	if match != nil && restored == 0 {
+		logger.Error("No file in snapshot matches: %s", strings.Join(restorePaths, ", "))
+		return fmt.Errorf("nothing to restore")
+	}
+
+	logger.Success("%d file(s) restored successfully!", restored)
+	return nil
+}
+
+// resolveSnapshot retrouve un snapshot par index ou identifiant (le plus récent si vide)
+func resolveSnapshot(repo *snapshot.Repository, selector string) (*snapshot.Snapshot, error) {
+	ids, err := repo.IDs()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no snapshot found in repository")
+	}
+
+	if selector == "" {
+		return repo.Load(ids[0])
+	}
+
+	if index, err := strconv.Atoi(selector); err == nil {
+		if index < 0 || index >= len(ids) {
+			return nil, fmt.Errorf("no snapshot at index %d (%d snapshots available)", index, len(ids))
+		}
+		return repo.Load(ids[index])
+	}
+
+	for _, id := range ids {
+		if strings.HasPrefix(id, selector) {
+			return repo.Load(id)
+		}
+	}
+	return nil, fmt.Errorf("no snapshot matches '%s'", selector)
+}
@@ -0,0 +1,144 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/destination"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/snapshot"
+)
+
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List backups and snapshots on each destination with their retention generations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runList()
+	},
+}
+
+func init() {
+	ArchiveCmd.AddCommand(ListCmd)
+}
+
+func runList() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	configs, err := selectDestinations(cfg, true)
+	if err != nil {
+		return err
+	}
+
+	logger.PrintHeader("List Obsidian Vaults Backups")
+
+	var failed []string
+	for i, c := range configs {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := listDestination(cfg, c); err != nil {
+			logger.Error("Failed to list '%s': %s", c.Name, err.Error())
+			failed = append(failed, c.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to list destination(s): %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func listDestination(cfg *config.Config, c config.DestinationConfig) error {
+	dest, err := openDestination(c)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	backups, err := listBackups(dest)
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	exists, err := snapshot.Exists(repositoryBackend(dest))
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	if exists {
+		if err := listSnapshots(cfg, dest); err != nil {
+			return err
+		}
+	}
+
+	if len(backups) == 0 {
+		logger.Info("No archive found on '%s'", dest.Name())
+		return nil
+	}
+
+	kept := generations(backups, cfg.Config.Archive.Retention)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tDATE\tSIZE\tGENERATIONS\tFILE")
+
+	var total int64
+	for i, b := range backups {
+		gens := "expired"
+		if g, ok := kept[b.Path]; ok {
+			gens = strings.Join(g, ",")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i, b.Time.Format("2006-01-02 15:04:05"), formatBytes(b.Size), gens, b.Name())
+		total += b.Size
+	}
+	w.Flush()
+
+	fmt.Println()
+	logger.Info("%d archive(s), %s total", len(backups), formatBytes(total))
+	return nil
+}
+
+func listSnapshots(cfg *config.Config, dest destination.Destination) error {
+	repo, err := openRepository(cfg, dest)
+	if err != nil {
+		return err
+	}
+
+	ids, err := repo.IDs()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	if len(ids) == 0 {
+		logger.Info("No snapshot found on '%s'", dest.Name())
+		return nil
+	}
+
+	kept := generations(snapshotBackups(ids), cfg.Config.Archive.Retention)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tSNAPSHOT\tFILES\tSIZE\tGENERATIONS")
+	for i, id := range ids {
+		snap, err := repo.Load(id)
+		if err != nil {
+			return err
+		}
+		gens := "expired"
+		if g, ok := kept[id]; ok {
+			gens = strings.Join(g, ",")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\n", i, id, len(snap.Entries), formatBytes(snap.Size()), gens)
+	}
+	w.Flush()
+
+	fmt.Println()
+	logger.Info("%d snapshot(s)", len(ids))
+	fmt.Println()
+	return nil
+}
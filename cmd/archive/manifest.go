@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"archive/tar"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"time"
+)
+
+// manifestName est le chemin du manifeste de checksums dans l'archive
+const manifestName = ".obs-cli/manifest.json"
+
+type manifestFile struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// manifest liste le SHA-256 de chaque fichier archivé pour détecter la corruption des données
+type manifest struct {
+	Version int                     `json:"version"`
+	Created time.Time               `json:"created"`
+	Files   map[string]manifestFile `json:"files"`
+}
+
+func newManifest() *manifest {
+	return &manifest{
+		Version: 1,
+		Created: time.Now(),
+		Files:   make(map[string]manifestFile),
+	}
+}
+
+func (m *manifest) add(path string, h hash.Hash, size int64) {
+	m.Files[path] = manifestFile{SHA256: hex.EncodeToString(h.Sum(nil)), Size: size}
+}
+
+func (m *manifest) write(tw archiveWriter) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	header := &tar.Header{
+		Name:    manifestName,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: m.Created,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write manifest header: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
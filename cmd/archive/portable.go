@@ -0,0 +1,281 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/ignore"
+	"github.com/coyls/obs-cli/internal/link"
+)
+
+// namesName est le chemin de la table des noms d'une archive portable. C'est la première
+// entrée de l'archive, pour que l'extraction la connaisse avant les fichiers.
+const namesName = ".obs-cli/names.json"
+
+var (
+	portable      bool
+	originalNames bool
+)
+
+func init() {
+	CreateCmd.Flags().BoolVar(&portable, "portable", false, "Use file names valid on every system and record the original names")
+	ExtractCmd.Flags().BoolVar(&originalNames, "original-names", false, "Restore the original file names of a portable archive instead of rewriting links")
+}
+
+func portableMode(cfg *config.Config) bool {
+	return portable || cfg.Config.Archive.Portable
+}
+
+// invalidChars ne sont pas acceptés dans un nom de fichier sur au moins un système courant
+const invalidChars = `<>:"\|?*`
+
+// reservedNames sont des noms de périphériques sous Windows, quelle que soit l'extension
+var reservedNames = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[1-9]|lpt[1-9])(\..*)?$`)
+
+// portableName remplace les caractères problématiques d'un nom de fichier par des tirets
+func portableName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r < 0x20 || strings.ContainsRune(invalidChars, r) {
+			b.WriteByte('-')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	clean := b.String()
+
+	// Windows ignore les points et espaces en fin de nom
+	if trimmed := strings.TrimRight(clean, ". "); trimmed != clean {
+		clean = trimmed + strings.Repeat("-", len(clean)-len(trimmed))
+	}
+	if reservedNames.MatchString(clean) {
+		clean = "_" + clean
+	}
+	return clean
+}
+
+// nameTable associe chaque chemin d'origine à son nom portable, sans collision
+// (y compris sur un système insensible à la casse)
+type nameTable struct {
+	names map[string]string
+	used  map[string]bool
+}
+
+func newNameTable() *nameTable {
+	return &nameTable{names: make(map[string]string), used: make(map[string]bool)}
+}
+
+// add attribue un nom portable à rel. Le dossier parent doit avoir été ajouté avant.
+func (t *nameTable) add(rel string) string {
+	if name, ok := t.names[rel]; ok {
+		return name
+	}
+
+	dir := ""
+	if parent := path.Dir(rel); parent != "." {
+		dir = t.names[parent] + "/"
+	}
+
+	base := portableName(path.Base(rel))
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	name := dir + base
+	for i := 2; t.used[strings.ToLower(name)]; i++ {
+		name = dir + stem + "-" + strconv.Itoa(i) + ext
+	}
+
+	t.names[rel] = name
+	t.used[strings.ToLower(name)] = true
+	return name
+}
+
+// renamed retourne les chemins modifiés (nom portable vers nom d'origine)
+func (t *nameTable) renamed() map[string]string {
+	renamed := make(map[string]string)
+	for original, name := range t.names {
+		if original != name {
+			renamed[name] = original
+		}
+	}
+	return renamed
+}
+
+// buildNameTable parcourt la source dans l'ordre de l'archive pour nommer chaque entrée
+func buildNameTable(sourcePath string, filter *ignore.Matcher) (*nameTable, error) {
+	table := newNameTable()
+	err := ignore.Walk(sourcePath, filter, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(sourcePath, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		table.add(filepath.ToSlash(rel))
+		return nil
+	})
+	return table, err
+}
+
+// writeNames écrit la table des noms modifiés dans l'archive
+func writeNames(tw archiveWriter, renamed map[string]string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(renamed); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+
+	header := &tar.Header{
+		Name:    namesName,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write name table header: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write name table: %w", err)
+	}
+	return nil
+}
+
+func readNames(r io.Reader) (map[string]string, error) {
+	var renamed map[string]string
+	if err := json.NewDecoder(r).Decode(&renamed); err != nil {
+		return nil, fmt.Errorf("invalid name table: %w", err)
+	}
+	return renamed, nil
+}
+
+// linkRewriter réécrit, une fois l'archive extraite sous ses noms portables, les liens des notes
+// vers les fichiers renommés. Les liens sont résolus comme dans Obsidian, vault par vault, sur
+// les chemins d'origine : seuls ceux qui mènent à un fichier renommé changent.
+type linkRewriter struct {
+	// portable associe chaque chemin d'origine renommé à son nom portable
+	portable map[string]string
+	// vaults sont les dossiers des vaults relatifs à la racine, les plus profonds d'abord
+	vaults []string
+	// files sont les chemins d'origine des fichiers de l'archive, extraits ou non
+	files []string
+	notes []extractedNote
+}
+
+// extractedNote est une note extraite dont les liens sont réécrits après l'extraction
+type extractedNote struct {
+	original string
+	path     string
+	modTime  time.Time
+}
+
+func newLinkRewriter(cfg *config.Config, renamed map[string]string) *linkRewriter {
+	l := &linkRewriter{portable: make(map[string]string, len(renamed))}
+	for name, original := range renamed {
+		l.portable[original] = name
+	}
+	for _, vaultConfig := range cfg.Config.Vaults {
+		if dir := strings.Trim(path.Clean(filepath.ToSlash(vaultConfig.VaultPath)), "/"); dir != "" && dir != "." {
+			l.vaults = append(l.vaults, dir)
+		}
+	}
+	sort.Slice(l.vaults, func(a, b int) bool { return strings.Count(l.vaults[a], "/") > strings.Count(l.vaults[b], "/") })
+	return l
+}
+
+// addFile déclare un fichier de l'archive, sous son chemin d'origine
+func (l *linkRewriter) addFile(original string) {
+	l.files = append(l.files, original)
+}
+
+// addNote déclare une note extraite à path, dont les liens seront réécrits
+func (l *linkRewriter) addNote(original, path string, modTime time.Time) {
+	l.notes = append(l.notes, extractedNote{original: original, path: path, modTime: modTime})
+}
+
+func (l *linkRewriter) portableName(original string) string {
+	if name, ok := l.portable[original]; ok {
+		return name
+	}
+	return original
+}
+
+// vault retourne le dossier du vault qui contient original, vide hors des vaults
+func (l *linkRewriter) vault(original string) string {
+	for _, dir := range l.vaults {
+		if strings.HasPrefix(original, dir+"/") {
+			return dir
+		}
+	}
+	return ""
+}
+
+// apply réécrit les notes extraites et retourne le nombre de notes modifiées
+func (l *linkRewriter) apply() (int, error) {
+	type vaultFiles struct {
+		files []string
+		moves map[string]string
+		notes map[string][]byte
+		paths map[string]extractedNote
+	}
+	vaults := make(map[string]*vaultFiles)
+	group := func(original string) (*vaultFiles, string, string) {
+		dir := l.vault(original)
+		v, ok := vaults[dir]
+		if !ok {
+			v = &vaultFiles{moves: make(map[string]string), notes: make(map[string][]byte), paths: make(map[string]extractedNote)}
+			vaults[dir] = v
+		}
+		if dir == "" {
+			return v, original, l.portableName(original)
+		}
+		return v, strings.TrimPrefix(original, dir+"/"), strings.TrimPrefix(l.portableName(original), l.portableName(dir)+"/")
+	}
+
+	for _, original := range l.files {
+		v, rel, name := group(original)
+		v.files = append(v.files, rel)
+		if name != rel {
+			v.moves[rel] = name
+		}
+	}
+	for _, note := range l.notes {
+		content, err := os.ReadFile(note.path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", note.path, err)
+		}
+		v, rel, _ := group(note.original)
+		v.notes[rel] = content
+		v.paths[rel] = note
+	}
+
+	rewritten := 0
+	for _, v := range vaults {
+		for _, rewrite := range link.Rewrite(v.notes, v.files, v.moves) {
+			note := v.paths[rewrite.Path]
+			if err := os.WriteFile(note.path, rewrite.Content, 0644); err != nil {
+				return rewritten, fmt.Errorf("failed to rewrite links of %s: %w", note.path, err)
+			}
+			if err := os.Chtimes(note.path, note.modTime, note.modTime); err != nil {
+				return rewritten, err
+			}
+			rewritten++
+		}
+	}
+	return rewritten, nil
+}
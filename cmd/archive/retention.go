@@ -0,0 +1,138 @@
+package archive
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/destination"
+)
+
+const (
+	backupPrefix    = "backup-obsidian_"
+	timestampFormat = "2006-01-02_15-04-05"
+)
+
+// backup est une archive présente sur une destination. Path est son nom sur la destination.
+type backup struct {
+	Path string
+	Time time.Time
+	Size int64
+}
+
+func (b backup) Name() string {
+	return path.Base(b.Path)
+}
+
+// listBackups retourne les sauvegardes présentes sur la destination, de la plus récente à la plus ancienne
+func listBackups(dest destination.Destination) ([]backup, error) {
+	names, err := dest.List(backupPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backup
+	for _, name := range names {
+		t, ok := parseBackupName(name)
+		if !ok {
+			// Fichier qui ne suit pas notre convention de nommage
+			continue
+		}
+
+		obj, err := dest.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+
+		backups = append(backups, backup{Path: name, Time: t, Size: obj.Size})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// parseBackupName extrait la date d'un nom de sauvegarde (backup-obsidian_<date>.<format>[.age])
+func parseBackupName(name string) (time.Time, bool) {
+	rest := strings.TrimPrefix(name, backupPrefix)
+	if rest == name || len(rest) < len(timestampFormat) {
+		return time.Time{}, false
+	}
+
+	ext := strings.TrimSuffix(rest[len(timestampFormat):], encryptedExtension)
+	if !slices.Contains(formats, strings.TrimPrefix(ext, ".")) {
+		return time.Time{}, false
+	}
+
+	t, err := time.ParseInLocation(timestampFormat, rest[:len(timestampFormat)], time.Local)
+	return t, err == nil
+}
+
+// generations retourne, pour chaque sauvegarde conservée, les générations qui la retiennent
+// ("daily", "weekly", "monthly"). Les sauvegardes absentes du résultat sont expirées.
+// La liste doit être triée de la plus récente à la plus ancienne.
+func generations(backups []backup, policy config.RetentionConfig) map[string][]string {
+	kept := make(map[string][]string)
+	if len(backups) == 0 {
+		return kept
+	}
+
+	if policy.Daily == 0 && policy.Weekly == 0 && policy.Monthly == 0 {
+		kept[backups[0].Path] = []string{"latest"}
+		return kept
+	}
+
+	rules := []struct {
+		name  string
+		count int
+		key   func(time.Time) string
+	}{
+		{"daily", policy.Daily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{"weekly", policy.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{"monthly", policy.Monthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+
+	for _, rule := range rules {
+		seen := make(map[string]bool)
+		for _, b := range backups {
+			if len(seen) >= rule.count {
+				break
+			}
+			key := rule.key(b.Time)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			kept[b.Path] = append(kept[b.Path], rule.name)
+		}
+	}
+
+	// La sauvegarde la plus récente n'est jamais supprimée
+	if _, ok := kept[backups[0].Path]; !ok {
+		kept[backups[0].Path] = []string{"latest"}
+	}
+
+	return kept
+}
+
+// expiredBackups retourne les sauvegardes qui ne sont retenues par aucune génération
+func expiredBackups(backups []backup, policy config.RetentionConfig) []backup {
+	kept := generations(backups, policy)
+
+	var expired []backup
+	for _, b := range backups {
+		if _, ok := kept[b.Path]; !ok {
+			expired = append(expired, b)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,281 @@
+package archive
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/ignore"
+	"github.com/coyls/obs-cli/internal/logger"
+)
+
+var verifyLive bool
+
+var VerifyCmd = &cobra.Command{
+	Use:   "verify [backup]",
+	Short: "Verify the content of a backup against its checksum manifest (latest by default)",
+	Long: `Verify re-hashes every file of a backup and compares it with the SHA-256
+manifest embedded at creation time, reporting added, changed and missing files.
+
+With --live, the backup is also compared with the current content of the vaults.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selector := ""
+		if len(args) == 1 {
+			selector = args[0]
+		}
+		return runVerify(selector)
+	},
+}
+
+func init() {
+	VerifyCmd.Flags().BoolVarP(&verifyLive, "live", "l", false, "Also compare the backup with the live vaults")
+	ArchiveCmd.AddCommand(VerifyCmd)
+}
+
+// verifyReport liste les différences entre un ensemble de fichiers attendu et un ensemble réel
+type verifyReport struct {
+	hasManifest bool
+	files       int
+	added       []string
+	changed     []string
+	missing     []string
+}
+
+func (r *verifyReport) ok() bool {
+	return len(r.added) == 0 && len(r.changed) == 0 && len(r.missing) == 0
+}
+
+func (r *verifyReport) print() {
+	for _, group := range []struct {
+		label string
+		files []string
+	}{
+		{"Added", r.added},
+		{"Changed", r.changed},
+		{"Missing", r.missing},
+	} {
+		if len(group.files) == 0 {
+			continue
+		}
+		logger.Error("%s (%d):", group.label, len(group.files))
+		for _, file := range group.files {
+			logger.Error("  - %s", file)
+		}
+	}
+}
+
+// compareHashes compare les checksums attendus (expected) aux checksums réels (actual)
+func compareHashes(expected, actual map[string]string) *verifyReport {
+	report := &verifyReport{files: len(actual)}
+	for path, hash := range actual {
+		want, ok := expected[path]
+		switch {
+		case !ok:
+			report.added = append(report.added, path)
+		case want != hash:
+			report.changed = append(report.changed, path)
+		}
+	}
+	for path := range expected {
+		if _, ok := actual[path]; !ok {
+			report.missing = append(report.missing, path)
+		}
+	}
+	sort.Strings(report.added)
+	sort.Strings(report.changed)
+	sort.Strings(report.missing)
+	return report
+}
+
+// verifyArchive relit chaque fichier de l'archive, calcule son SHA-256 et le compare au manifeste.
+// Retourne les checksums réels des fichiers de l'archive, sous leur nom d'origine.
+func verifyArchive(cfg *config.Config, backupFile io.Reader) (map[string]string, *verifyReport, error) {
+	tr, err := openArchive(cfg, backupFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tr.Close()
+
+	hashes := make(map[string]string)
+	var m *manifest
+	var renamed map[string]string
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if header.Name == manifestName {
+			m = &manifest{}
+			if err := json.NewDecoder(tr).Decode(m); err != nil {
+				return nil, nil, fmt.Errorf("invalid checksum manifest: %w", err)
+			}
+			continue
+		}
+
+		if header.Name == namesName {
+			if renamed, err = readNames(tr); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		hash := sha256.New()
+		if _, err := io.Copy(hash, tr); err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		hashes[header.Name] = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	if m == nil {
+		return originalHashes(hashes, renamed), &verifyReport{files: len(hashes)}, nil
+	}
+
+	expected := make(map[string]string, len(m.Files))
+	for path, f := range m.Files {
+		expected[path] = f.SHA256
+	}
+
+	report := compareHashes(expected, hashes)
+	report.hasManifest = true
+	return originalHashes(hashes, renamed), report, nil
+}
+
+// originalHashes renomme les fichiers d'une archive portable avec leur nom d'origine
+func originalHashes(hashes, renamed map[string]string) map[string]string {
+	if len(renamed) == 0 {
+		return hashes
+	}
+	original := make(map[string]string, len(hashes))
+	for name, hash := range hashes {
+		if o, ok := renamed[name]; ok {
+			name = o
+		}
+		original[name] = hash
+	}
+	return original
+}
+
+// liveHashes calcule le checksum des fichiers actuels, nommés par leur chemin relatif à root
+func liveHashes(root string, filter *ignore.Matcher) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := ignore.Walk(root, filter, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		hash := sha256.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		hashes[filepath.ToSlash(rel)] = hex.EncodeToString(hash.Sum(nil))
+		return nil
+	})
+	return hashes, err
+}
+
+func runVerify(file string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	logger.PrintHeader("Verify Obsidian Vaults Backup")
+
+	name, backupFile, err := openBackup(cfg, file)
+	if err != nil {
+		return err
+	}
+	defer backupFile.Close()
+
+	logger.Info("Verifying backup: %s", name)
+	hashes, report, err := verifyArchive(cfg, backupFile)
+	if err != nil {
+		logger.Error("Unable to read backup: %s", err.Error())
+		if errors.Is(err, crypt.ErrDecrypt) {
+			return err
+		}
+		return fmt.Errorf("backup is corrupted: %w", err)
+	}
+
+	failed := false
+	if !report.hasManifest {
+		logger.Error("No checksum manifest in backup, only the archive structure was checked")
+		failed = true
+	} else if !report.ok() {
+		logger.Error("Backup content does not match its checksum manifest")
+		report.print()
+		failed = true
+	} else {
+		logger.Success("%d file(s) match the checksum manifest", report.files)
+	}
+
+	if verifyLive {
+		logger.Info("Comparing backup with live vaults in %s...", cfg.Config.Root)
+		filter, err := ignore.FromConfig(cfg)
+		if err != nil {
+			return err
+		}
+
+		live, err := liveHashes(cfg.Config.Root, filter)
+		if err != nil {
+			return fmt.Errorf("failed to read live vaults: %w", err)
+		}
+
+		liveReport := compareHashes(hashes, live)
+		if liveReport.ok() {
+			logger.Success("Live vaults are identical to the backup")
+		} else {
+			logger.Info("Live vaults differ from the backup:")
+			liveReport.print()
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("backup verification failed")
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
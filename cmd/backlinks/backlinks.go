@@ -0,0 +1,255 @@
+package backlinks
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vaultName  string
+	noUnlinked bool
+)
+
+var backlinksCmd = &cobra.Command{
+	Use:   "backlinks <note>",
+	Short: "List the notes linking to a note, with the line of each link",
+	Long: `The backlinks command lists the links and embeds pointing at a note, grouped
+by note with the line that contains them, as in Obsidian's backlinks pane.
+
+It also lists the unlinked mentions: lines of other notes where the note's name
+or one of its aliases appears as plain text, outside links and code.
+
+A note is a path relative to the vault (the .md extension can be omitted), a note
+name as in a [[link]], or a path on disk.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeBacklinks(args[0])
+	},
+}
+
+// mention est une ligne d'une note qui cite la note recherchée
+type mention struct {
+	Path    string
+	Line    int
+	Context string
+}
+
+var inlineCode = regexp.MustCompile("`+[^`\n]*`+")
+
+func executeBacklinks(arg string) error {
+	logger.PrintHeader("Backlinks")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	v, err := vault.Open(cfg, vaultName)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	index, err := v.Load()
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	if index.Stats.CacheError != nil {
+		logger.Warning("%s", index.Stats.CacheError.Error())
+	}
+
+	target, err := findNote(v, index, arg)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	contents := make(map[string][]string)
+	lines := func(path string) ([]string, error) {
+		if content, ok := contents[path]; ok {
+			return content, nil
+		}
+		data, err := os.ReadFile(v.Abs(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		contents[path] = strings.Split(string(data), "\n")
+		return contents[path], nil
+	}
+
+	var linked []mention
+	for _, ref := range index.Backlinks(target) {
+		if ref.Source == target {
+			continue
+		}
+		content, err := lines(ref.Source)
+		if err != nil {
+			logger.Error("%s", err.Error())
+			return err
+		}
+		if n := len(linked); n > 0 && linked[n-1].Path == ref.Source && linked[n-1].Line == ref.Link.Line {
+			continue
+		}
+		linked = append(linked, mention{Path: ref.Source, Line: ref.Link.Line, Context: contextLine(content, ref.Link.Line)})
+	}
+
+	logger.Info("%d linked mention(s) of %s", len(linked), target)
+	printMentions(linked)
+
+	if noUnlinked {
+		return nil
+	}
+	note, _ := index.Note(target)
+	unlinked, err := unlinkedMentions(v, index, note)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	fmt.Println()
+	logger.Info("%d unlinked mention(s) of %s", len(unlinked), strings.Join(terms(note), ", "))
+	printMentions(unlinked)
+	return nil
+}
+
+// findNote retourne le chemin relatif au vault de la note désignée par arg
+func findNote(v *vault.Vault, index *vault.Index, arg string) (string, error) {
+	if _, err := os.Stat(arg); err == nil {
+		if rel, ok := v.Rel(arg); ok {
+			if _, ok := index.Note(rel); ok {
+				return rel, nil
+			}
+		}
+	}
+	if note, ok := index.Find(arg); ok {
+		return note.Path, nil
+	}
+	return "", fmt.Errorf("note not found in vault '%s': %s", v.Name, arg)
+}
+
+// terms retourne le nom de la note et ses alias
+func terms(note *vault.Note) []string {
+	return append([]string{note.Name()}, note.Aliases...)
+}
+
+// unlinkedMentions cherche le nom et les alias de la note dans le texte des autres notes, hors
+// frontmatter, liens et code
+func unlinkedMentions(v *vault.Vault, index *vault.Index, target *vault.Note) ([]mention, error) {
+	var wanted []string
+	for _, term := range terms(target) {
+		if term = strings.ToLower(strings.TrimSpace(term)); term != "" {
+			wanted = append(wanted, term)
+		}
+	}
+
+	var mentions []mention
+	for _, note := range index.Notes() {
+		if note.Path == target.Path || note.Encrypted {
+			continue
+		}
+		data, err := os.ReadFile(v.Abs(note.Path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", note.Path, err)
+		}
+
+		// Les liens sont masqués sans changer la position du texte
+		masked := append([]byte(nil), data...)
+		for _, l := range note.Links {
+			if l.End <= len(masked) {
+				for i := l.Start; i < l.End; i++ {
+					masked[i] = ' '
+				}
+			}
+		}
+
+		front, _ := crypt.SplitFrontmatter(data)
+		firstLine := strings.Count(string(front), "\n")
+		original := strings.Split(string(data), "\n")
+		inCode := false
+		for i, line := range strings.Split(string(masked), "\n") {
+			if i < firstLine {
+				continue
+			}
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				inCode = !inCode
+				continue
+			}
+			if inCode {
+				continue
+			}
+			line = inlineCode.ReplaceAllStringFunc(line, func(code string) string {
+				return strings.Repeat(" ", len(code))
+			})
+
+			lower := strings.ToLower(line)
+			for _, term := range wanted {
+				if containsWord(lower, term) {
+					mentions = append(mentions, mention{Path: note.Path, Line: i + 1, Context: strings.TrimSpace(original[i])})
+					break
+				}
+			}
+		}
+	}
+	return mentions, nil
+}
+
+// containsWord indique si text contient term entre deux séparateurs de mots
+func containsWord(text, term string) bool {
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(term)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(text) || !isWordRune(after)) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// contextLine retourne la ligne number (à partir de 1) sans espaces autour
+func contextLine(lines []string, number int) string {
+	if number < 1 || number > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[number-1])
+}
+
+// printMentions affiche les mentions regroupées par note
+func printMentions(mentions []mention) {
+	current := ""
+	for _, m := range mentions {
+		if m.Path != current {
+			current = m.Path
+			fmt.Printf("  %s%s%s\n", logger.ColorBlue, current, logger.ColorReset)
+		}
+		fmt.Printf("    %d: %s\n", m.Line, m.Context)
+	}
+}
+
+func init() {
+	backlinksCmd.Flags().StringVar(&vaultName, "vault", "", "Vault of the note (default vault by default)")
+	backlinksCmd.Flags().BoolVar(&noUnlinked, "no-unlinked", false, "Do not search for unlinked mentions")
+}
+
+// GetCommand returns the backlinks command for root command integration
+func GetCommand() *cobra.Command {
+	return backlinksCmd
+}
@@ -0,0 +1,94 @@
+package backlinks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/coyls/obs-cli/internal/vault"
+)
+
+func TestContainsWord(t *testing.T) {
+	tests := []struct {
+		text string
+		term string
+		want bool
+	}{
+		{"émile est venu", "émile", true},
+		{"avec émile.", "émile", true},
+		{"l'émile, oui", "émile", true},
+		{"(émile)", "émile", true},
+		{"rémile", "émile", false},
+		{"émilette", "émile", false},
+		{"émile2", "émile", false},
+		{"émile_b", "émile", false},
+		{"émilette puis émile", "émile", true},
+		{"zoé", "zo", false},
+		{"le maître arrive", "le maître", true},
+		{"le maîtres", "le maître", false},
+		{"", "émile", false},
+	}
+
+	for _, tt := range tests {
+		if got := containsWord(tt.text, tt.term); got != tt.want {
+			t.Errorf("containsWord(%q, %q) = %v, want %v", tt.text, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestUnlinkedMentions(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"People/Émile.md": "---\naliases: [Le Maître]\n---\nÉmile se cite lui-même.\n",
+		"Journal.md": "---\ntitle: Émile\n---\n" +
+			"Émile est venu.\n" +
+			"Cet Émilette ne compte pas.\n" +
+			"[[Émile]] seulement, puis [[People/Émile|Émile lui-même]].\n" +
+			"Du code `Émile` en ligne.\n" +
+			"```\n" +
+			"Émile dans un bloc\n" +
+			"```\n" +
+			"~~~\n" +
+			"Émile dans un autre bloc\n" +
+			"~~~\n" +
+			"Le repas avec le maître.\n" +
+			"Un lien [[Émile]] puis émile en texte.\n",
+		"Other.md": "RÉMILE n'est pas Émile12.\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v := &vault.Vault{Name: "test", Path: dir}
+	index, err := v.Rebuild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, ok := index.Note("People/Émile.md")
+	if !ok {
+		t.Fatal("People/Émile.md not indexed")
+	}
+
+	got, err := unlinkedMentions(v, index, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []mention{
+		{Path: "Journal.md", Line: 4, Context: "Émile est venu."},
+		{Path: "Journal.md", Line: 14, Context: "Le repas avec le maître."},
+		{Path: "Journal.md", Line: 15, Context: "Un lien [[Émile]] puis émile en texte."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mentions\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/coyls/obs-cli/internal/config"
 	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -29,13 +30,13 @@ func executeCallouts(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	vaultConfig, exists := cfg.GetVaultConfig(cfg.Config.DefaultVault)
-	if !exists {
-		logger.Error("Default vault configuration not found")
-		return fmt.Errorf("default vault configuration not found")
+	v, err := vault.Open(cfg, "")
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
 	}
 
-	calloutsPath := filepath.Join(cfg.Config.Root, vaultConfig.VaultPath, ".obsidian", "snippets", "snippet.css")
+	calloutsPath := v.Abs(".obsidian/snippets/snippet.css")
 
 	if _, err := os.Stat(calloutsPath); os.IsNotExist(err) {
 		snippetsDir := filepath.Dir(calloutsPath)
@@ -50,16 +51,7 @@ func executeCallouts(cmd *cobra.Command, args []string) error {
 		logger.Info("Created new callouts file at: %s", calloutsPath)
 	}
 
-	editor := cfg.Config.DefaultEditor
-	if editor == "" {
-		if envEditor := os.Getenv("EDITOR"); envEditor != "" {
-			editor = envEditor
-		} else {
-			editor = "nano"
-		}
-	}
-
-	editorCmd := exec.Command(editor, calloutsPath)
+	editorCmd := exec.Command(cfg.Editor(), calloutsPath)
 	editorCmd.Stdin = os.Stdin
 	editorCmd.Stdout = os.Stdout
 	editorCmd.Stderr = os.Stderr
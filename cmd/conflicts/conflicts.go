@@ -0,0 +1,231 @@
+package conflicts
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/conflict"
+	"github.com/coyls/obs-cli/internal/git"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vaults    []string
+	allVaults bool
+	listOnly  bool
+	strategy  string
+)
+
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "List and resolve conflicts left by pull",
+	Long: `The conflicts command lists the files still in conflict after a pull and
+asks how to resolve each of them:
+
+  o  keep the local version (ours)
+  t  keep the remote version (theirs)
+  b  keep both: the remote version, plus a copy of the local one
+  u  union: keep the lines of both versions (Markdown notes)
+  m  merge the body and the frontmatter of both versions (Markdown notes)
+  e  edit the file with its conflict markers in the default editor
+  s  skip
+
+When every conflict of a repository is resolved, the merge is committed (or the
+rebase started by 'obs-cli sync' continues).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeConflicts()
+	},
+}
+
+// choices associe chaque réponse du prompt à une stratégie
+var choices = map[string]string{
+	"o": conflict.StrategyOurs,
+	"t": conflict.StrategyTheirs,
+	"b": conflict.StrategyKeepBoth,
+	"u": conflict.StrategyUnion,
+	"m": conflict.StrategyMerge,
+	"s": conflict.StrategyManual,
+}
+
+const choiceEdit = "edit"
+
+var stdin = bufio.NewReader(os.Stdin)
+
+func executeConflicts() error {
+	logger.PrintHeader("Resolve conflicts")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if strategy != "" && !conflict.ValidStrategy(strategy) {
+		return fmt.Errorf("unknown conflict strategy '%s' (expected %s)", strategy, strings.Join(conflict.Strategies, ", "))
+	}
+
+	names, err := cfg.SelectVaults(vaults, allVaults)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	repos, err := git.Repositories(cfg, names)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	remaining := 0
+	for _, repo := range repos {
+		left, err := resolveRepository(cfg, repo)
+		if err != nil {
+			logger.Error("%s: %s", repo.Name(), err.Error())
+			return err
+		}
+		remaining += left
+	}
+
+	if remaining > 0 {
+		return fmt.Errorf("%d conflict(s) left", remaining)
+	}
+	return nil
+}
+
+// resolveRepository traite les conflits d'un dépôt et retourne le nombre de fichiers restant en conflit
+func resolveRepository(cfg *config.Config, repo *git.Repository) (int, error) {
+	conflicts, err := repo.GetConflicts()
+	if err != nil {
+		return 0, err
+	}
+	if len(conflicts) == 0 {
+		logger.Success("%s: no conflict", repo.Name())
+		return 0, nil
+	}
+
+	resolver, err := conflict.NewResolver(repo.Git, repo.Config.Conflicts)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Info("%s: %d file(s) in conflict", repo.Name(), len(conflicts))
+	if listOnly {
+		for _, file := range conflicts {
+			logger.Info("  - %s (%s)", file, resolver.Strategy(file))
+		}
+		return len(conflicts), nil
+	}
+
+	remaining := 0
+	for _, file := range conflicts {
+		choice := strategy
+		if choice == "" {
+			if choice, err = ask(file, resolver.Strategy(file)); err != nil {
+				return 0, err
+			}
+		}
+
+		var resolved bool
+		if choice == choiceEdit {
+			resolved, err = editConflict(cfg, repo, file)
+		} else {
+			resolved, err = resolver.Resolve(file, choice)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case resolved:
+			logger.Success("  - %s (%s)", file, choice)
+		case choice == conflict.StrategyManual:
+			logger.Info("  - %s skipped", file)
+			remaining++
+		default:
+			logger.Error("  - %s: %s could not resolve the conflict", file, choice)
+			remaining++
+		}
+	}
+
+	if remaining == 0 && (repo.IsMerging() || repo.IsRebasing()) {
+		if err := repo.Continue(); err != nil {
+			// Un rebase s'arrête de nouveau si le commit suivant est lui aussi en conflit
+			if conflicts, _ := repo.GetConflicts(); len(conflicts) > 0 {
+				return resolveRepository(cfg, repo)
+			}
+			return 0, err
+		}
+		logger.Success("%s: conflicts resolved and committed", repo.Name())
+	}
+	return remaining, nil
+}
+
+// ask demande la résolution d'un fichier. La réponse par défaut est la stratégie configurée.
+func ask(file, configured string) (string, error) {
+	fallback := "s"
+	for key, name := range choices {
+		if name == configured {
+			fallback = key
+		}
+	}
+
+	for {
+		fmt.Printf("%s [o]urs, [t]heirs, [b]oth, [u]nion, [m]erge, [e]dit, [s]kip (default %s): ", file, fallback)
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			// Entrée fermée : les fichiers restants sont ignorés
+			fmt.Println()
+			return conflict.StrategyManual, nil
+		}
+
+		answer := strings.ToLower(strings.TrimSpace(line))
+		if answer == "" {
+			answer = fallback
+		}
+		if answer == "e" {
+			return choiceEdit, nil
+		}
+		if choice, ok := choices[answer]; ok {
+			return choice, nil
+		}
+	}
+}
+
+// editConflict ouvre le fichier avec ses marqueurs de conflit dans l'éditeur. Il est marqué
+// comme résolu s'il ne contient plus de marqueur.
+func editConflict(cfg *config.Config, repo *git.Repository, file string) (bool, error) {
+	path := filepath.Join(repo.Path(), filepath.FromSlash(file))
+
+	editorCmd := exec.Command(cfg.Editor(), path)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return false, fmt.Errorf("failed to open editor: %w", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if conflict.HasMarkers(content) {
+		return false, nil
+	}
+	return true, repo.Add(file)
+}
+
+func init() {
+	conflictsCmd.Flags().StringArrayVar(&vaults, "vault", nil, "Vault to check (repeatable, default vault by default)")
+	conflictsCmd.Flags().BoolVar(&allVaults, "all", false, "Check every configured vault")
+	conflictsCmd.Flags().BoolVarP(&listOnly, "list", "l", false, "Only list the files in conflict")
+	conflictsCmd.Flags().StringVarP(&strategy, "strategy", "s", "", "Resolve every conflict with this strategy: "+strings.Join(conflict.Strategies, ", "))
+}
+
+// GetCommand returns the conflicts command for root command integration
+func GetCommand() *cobra.Command {
+	return conflictsCmd
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/coyls/obs-cli/internal/config"
 	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -47,22 +48,22 @@ func executeCopy(source string) error {
 		fmt.Printf("Vault trouvé: %s\n", key)
 	}
 
-	vaultConfig, exists := cfg.GetVaultConfig(cfg.Config.DefaultVault)
-	if !exists {
-		logger.Error("Default vault configuration not found")
-		return fmt.Errorf("default vault configuration not found")
+	v, err := vault.Open(cfg, "")
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
 	}
 
 	if destination == "" {
-		if vaultConfig.Commands.Cp.DefaultTargetPath == "" {
+		if v.Config.Commands.Cp.DefaultTargetPath == "" {
 			logger.Error("No destination specified and no default path configured")
 			return fmt.Errorf("no destination specified and no default path configured")
 		}
-		destination = vaultConfig.Commands.Cp.DefaultTargetPath
+		destination = v.Config.Commands.Cp.DefaultTargetPath
 		logger.Info("Using default destination: %s", destination)
 	}
 
-	destPath := filepath.Join(cfg.Config.Root, vaultConfig.VaultPath, destination)
+	destPath := filepath.Join(v.Path, destination)
 	if _, err := os.Stat(destPath); os.IsNotExist(err) {
 		logger.Info("Creating destination directory: %s", destPath)
 		if err := os.MkdirAll(destPath, 0755); err != nil {
@@ -0,0 +1,105 @@
+package decrypt
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt private notes in the Obsidian vault",
+	Long: `The decrypt command restores the body of every note previously encrypted
+with 'obs-cli encrypt' in the default vault.
+
+The passphrase is read from the OBS_CLI_PASSPHRASE environment variable
+or prompted interactively.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeDecrypt()
+	},
+}
+
+func executeDecrypt() error {
+	logger.PrintHeader("Decrypt private notes")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	v, err := vault.Open(cfg, "")
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	vaultPath := v.Path
+
+	logger.Info("Searching encrypted notes in %s...", vaultPath)
+	encrypted := make(map[string][]byte)
+	var notes []string
+	err = crypt.WalkNotes(vaultPath, func(path string, content []byte) error {
+		if crypt.IsEncrypted(content) {
+			notes = append(notes, path)
+			encrypted[path] = content
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	if len(notes) == 0 {
+		logger.Info("No encrypted note found")
+		return nil
+	}
+	logger.Info("%d encrypted note(s) to decrypt", len(notes))
+
+	passphrase, err := crypt.ReadPassphrase(false)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	cipher, err := crypt.NewCipher(passphrase)
+	if err != nil {
+		return err
+	}
+
+	// Tout déchiffrer en mémoire avant d'écrire : une mauvaise passphrase ne modifie rien
+	decrypted := make(map[string][]byte, len(notes))
+	for _, note := range notes {
+		data, err := crypt.DecryptNote(encrypted[note], cipher)
+		if err != nil {
+			if errors.Is(err, crypt.ErrDecrypt) {
+				logger.Error("Unable to decrypt %s: wrong passphrase or corrupted note", note)
+			} else {
+				logger.Error("Unable to decrypt %s: %s", note, err.Error())
+			}
+			return err
+		}
+		decrypted[note] = data
+	}
+
+	for _, note := range notes {
+		if err := crypt.WriteFileAtomic(note, decrypted[note]); err != nil {
+			logger.Error("Failed to write %s: %s", note, err.Error())
+			return err
+		}
+		rel, _ := filepath.Rel(vaultPath, note)
+		logger.Info("  - %s", rel)
+	}
+
+	logger.Success("%d note(s) decrypted!", len(notes))
+	return nil
+}
+
+func GetCommand() *cobra.Command {
+	return decryptCmd
+}
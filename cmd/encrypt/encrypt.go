@@ -0,0 +1,98 @@
+package encrypt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt private notes in the Obsidian vault",
+	Long: `The encrypt command encrypts the body of every note tagged #private
+(or with 'private: true' in its frontmatter) in the default vault.
+The frontmatter is left readable so Obsidian can still index the note.
+
+The passphrase is read from the OBS_CLI_PASSPHRASE environment variable
+or prompted interactively.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeEncrypt()
+	},
+}
+
+func executeEncrypt() error {
+	logger.PrintHeader("Encrypt private notes")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	v, err := vault.Open(cfg, "")
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	vaultPath := v.Path
+
+	logger.Info("Searching private notes in %s...", vaultPath)
+	notes, err := crypt.FindPlaintextPrivate(vaultPath)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	if len(notes) == 0 {
+		logger.Info("No plaintext private note found")
+		return nil
+	}
+	logger.Info("%d private note(s) to encrypt", len(notes))
+
+	passphrase, err := crypt.ReadPassphrase(true)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	cipher, err := crypt.NewCipher(passphrase)
+	if err != nil {
+		return err
+	}
+
+	// Tout chiffrer en mémoire avant d'écrire pour ne pas laisser le vault à moitié chiffré
+	encrypted := make(map[string][]byte, len(notes))
+	for _, note := range notes {
+		content, err := os.ReadFile(note)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", note, err)
+		}
+		data, err := crypt.EncryptNote(content, cipher)
+		if err != nil {
+			logger.Error("Failed to encrypt %s: %s", note, err.Error())
+			return err
+		}
+		encrypted[note] = data
+	}
+
+	for _, note := range notes {
+		if err := crypt.WriteFileAtomic(note, encrypted[note]); err != nil {
+			logger.Error("Failed to write %s: %s", note, err.Error())
+			return err
+		}
+		rel, _ := filepath.Rel(vaultPath, note)
+		logger.Info("  - %s", rel)
+	}
+
+	logger.Success("%d note(s) encrypted!", len(notes))
+	return nil
+}
+
+func GetCommand() *cobra.Command {
+	return encryptCmd
+}
@@ -0,0 +1,217 @@
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vaultName   string
+	format      string
+	output      string
+	attachments bool
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Export the link graph of the vault",
+	Long: `The graph command exports the links between the notes of the vault, for
+analysis with external tools: one node per note (with its tags), one edge per
+pair of linked notes with the number of links. --attachments adds the linked
+attachments as nodes.
+
+Formats:
+  dot      Graphviz (dot -Tsvg graph.dot > graph.svg)
+  graphml  GraphML, for Gephi, yEd or Cytoscape
+  json     {"nodes": [...], "edges": [...]}
+
+The graph is written to standard output, or to --output.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeGraph()
+	},
+}
+
+func executeGraph() error {
+	var write func(io.Writer, string, *vault.Graph) error
+	switch format {
+	case "dot":
+		write = writeDOT
+	case "graphml":
+		write = writeGraphML
+	case "json":
+		write = writeJSON
+	default:
+		return fmt.Errorf("unknown format '%s' (dot, graphml or json)", format)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	v, err := vault.Open(cfg, vaultName)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	index, err := v.Load()
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	if index.Stats.CacheError != nil {
+		logger.Warning("%s", index.Stats.CacheError.Error())
+	}
+	graph := index.Graph(attachments)
+
+	if output == "" {
+		return write(os.Stdout, v.Name, graph)
+	}
+
+	var buf strings.Builder
+	if err := write(&buf, v.Name, graph); err != nil {
+		return err
+	}
+	if err := crypt.WriteFileAtomic(output, []byte(buf.String())); err != nil {
+		logger.Error("Failed to write %s: %s", output, err.Error())
+		return err
+	}
+	logger.Success("Graph of %d node(s) and %d edge(s) written to %s", len(graph.Nodes), len(graph.Edges), output)
+	return nil
+}
+
+// writeDOT écrit le graphe au format Graphviz
+func writeDOT(w io.Writer, name string, graph *vault.Graph) error {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", quote(name))
+	for _, node := range graph.Nodes {
+		shape := "ellipse"
+		if node.Attachment {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", quote(node.ID), quote(node.Name), shape)
+	}
+	for _, edge := range graph.Edges {
+		attributes := fmt.Sprintf("weight=%d", edge.Links)
+		if edge.Embeds == edge.Links {
+			attributes += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", quote(edge.Source), quote(edge.Target), attributes)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// writeGraphML écrit le graphe au format GraphML
+func writeGraphML(w io.Writer, name string, graph *vault.Graph) error {
+	doc := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", Name: "name", Type: "string"},
+			{ID: "type", For: "node", Name: "type", Type: "string"},
+			{ID: "tags", For: "node", Name: "tags", Type: "string"},
+			{ID: "links", For: "edge", Name: "links", Type: "int"},
+			{ID: "embeds", For: "edge", Name: "embeds", Type: "int"},
+		},
+		Graph: graphMLGraph{ID: name, EdgeDefault: "directed"},
+	}
+	for _, node := range graph.Nodes {
+		kind := "note"
+		if node.Attachment {
+			kind = "attachment"
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: node.ID, Data: []graphMLData{
+			{Key: "name", Value: node.Name},
+			{Key: "type", Value: kind},
+			{Key: "tags", Value: strings.Join(node.Tags, " ")},
+		}})
+	}
+	for _, edge := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: edge.Source, Target: edge.Target, Data: []graphMLData{
+			{Key: "links", Value: fmt.Sprint(edge.Links)},
+			{Key: "embeds", Value: fmt.Sprint(edge.Embeds)},
+		}})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeJSON écrit le graphe en JSON
+func writeJSON(w io.Writer, _ string, graph *vault.Graph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(graph)
+}
+
+func init() {
+	graphCmd.Flags().StringVar(&vaultName, "vault", "", "Vault to export (default vault by default)")
+	graphCmd.Flags().StringVarP(&format, "format", "f", "json", "Output format: dot, graphml or json")
+	graphCmd.Flags().StringVarP(&output, "output", "o", "", "File to write the graph to (standard output by default)")
+	graphCmd.Flags().BoolVar(&attachments, "attachments", false, "Include the linked attachments as nodes")
+}
+
+// GetCommand returns the graph command for root command integration
+func GetCommand() *cobra.Command {
+	return graphCmd
+}
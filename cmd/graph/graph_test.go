@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coyls/obs-cli/internal/vault"
+)
+
+// testGraph contient des noms à échapper dans chaque format
+func testGraph() *vault.Graph {
+	return &vault.Graph{
+		Nodes: []vault.Node{
+			{ID: `Say "hi".md`, Name: `Say "hi"`, Tags: []string{"a", "b/c"}},
+			{ID: `back\slash.md`, Name: `back\slash`},
+			{ID: "R&D <draft>.md", Name: "R&D <draft>"},
+			{ID: "img/logo.png", Name: "logo.png", Attachment: true},
+		},
+		Edges: []vault.Edge{
+			{Source: `Say "hi".md`, Target: `back\slash.md`, Links: 2, Embeds: 1},
+			{Source: `back\slash.md`, Target: "img/logo.png", Links: 1, Embeds: 1},
+			{Source: "R&D <draft>.md", Target: `Say "hi".md`, Links: 1},
+		},
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var b strings.Builder
+	if err := writeDOT(&b, `My "vault"`, testGraph()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph "My \"vault\"" {
+  "Say \"hi\".md" [label="Say \"hi\"", shape=ellipse];
+  "back\\slash.md" [label="back\\slash", shape=ellipse];
+  "R&D <draft>.md" [label="R&D <draft>", shape=ellipse];
+  "img/logo.png" [label="logo.png", shape=box];
+  "Say \"hi\".md" -> "back\\slash.md" [weight=2];
+  "back\\slash.md" -> "img/logo.png" [weight=1, style=dashed];
+  "R&D <draft>.md" -> "Say \"hi\".md" [weight=1];
+}
+`
+	if b.String() != want {
+		t.Errorf("DOT output:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	var b strings.Builder
+	if err := writeGraphML(&b, "R&D", testGraph()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), xml.Header) {
+		t.Errorf("output does not start with the XML header:\n%s", b.String())
+	}
+
+	var doc graphML
+	if err := xml.Unmarshal([]byte(b.String()), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, b.String())
+	}
+	if doc.XMLName.Local != "graphml" || doc.Graph.ID != "R&D" || doc.Graph.EdgeDefault != "directed" {
+		t.Errorf("graph %+v %+v", doc.XMLName, doc.Graph)
+	}
+	if len(doc.Keys) != 5 {
+		t.Errorf("%d keys, want 5", len(doc.Keys))
+	}
+
+	wantNodes := []graphMLNode{
+		{ID: `Say "hi".md`, Data: []graphMLData{{"name", `Say "hi"`}, {"type", "note"}, {"tags", "a b/c"}}},
+		{ID: `back\slash.md`, Data: []graphMLData{{"name", `back\slash`}, {"type", "note"}, {"tags", ""}}},
+		{ID: "R&D <draft>.md", Data: []graphMLData{{"name", "R&D <draft>"}, {"type", "note"}, {"tags", ""}}},
+		{ID: "img/logo.png", Data: []graphMLData{{"name", "logo.png"}, {"type", "attachment"}, {"tags", ""}}},
+	}
+	if !reflect.DeepEqual(doc.Graph.Nodes, wantNodes) {
+		t.Errorf("nodes\n%+v\nwant\n%+v", doc.Graph.Nodes, wantNodes)
+	}
+
+	wantEdges := []graphMLEdge{
+		{Source: `Say "hi".md`, Target: `back\slash.md`, Data: []graphMLData{{"links", "2"}, {"embeds", "1"}}},
+		{Source: `back\slash.md`, Target: "img/logo.png", Data: []graphMLData{{"links", "1"}, {"embeds", "1"}}},
+		{Source: "R&D <draft>.md", Target: `Say "hi".md`, Data: []graphMLData{{"links", "1"}, {"embeds", "0"}}},
+	}
+	if !reflect.DeepEqual(doc.Graph.Edges, wantEdges) {
+		t.Errorf("edges\n%+v\nwant\n%+v", doc.Graph.Edges, wantEdges)
+	}
+}
@@ -0,0 +1,348 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/diff"
+	"github.com/coyls/obs-cli/internal/git"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// contextLines est le nombre de lignes inchangées affichées autour des modifications
+	contextLines = 2
+	// defaultLogLimit est le nombre de commits listés sans note
+	defaultLogLimit = 20
+)
+
+var (
+	vaultName string
+	limit     int
+	diffSpec  string
+)
+
+var historyCmd = &cobra.Command{
+	Use:     "history [note]",
+	Aliases: []string{"log"},
+	Short:   "List the versions of a note, or the latest changes of the vault",
+	Long: `The history command lists the commits that changed a note, following its
+renames. Without a note, it lists the latest commits of the vault with the notes
+they changed.
+
+A note is a path relative to the vault (the .md extension can be omitted) or a
+path to the file. A version is a commit (at least 4 characters of its hash) or a
+date (YYYY-MM-DD or "YYYY-MM-DD HH:MM"), which selects the last version saved
+at that date.
+
+--diff shows what changed: the properties of the frontmatter one by one, then
+the lines of the note, word by word when a line was edited.
+  --diff A      changes made by version A
+  --diff A..B   changes between versions A and B
+  --diff A..    changes since version A, including uncommitted ones
+
+Examples:
+  obs-cli history Projects/Roadmap
+  obs-cli history Projects/Roadmap --diff 2024-05-01..
+  obs-cli log -n 5`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			if diffSpec != "" {
+				return fmt.Errorf("--diff requires a note")
+			}
+			return executeLog()
+		}
+		return executeHistory(args[0])
+	},
+}
+
+func executeLog() error {
+	logger.PrintHeader("Vault History")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	v, err := vault.Open(cfg, vaultName)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	repos, err := git.Repositories(cfg, []string{v.Name})
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	repo := repos[0]
+
+	dir, err := filepath.Rel(repo.Path(), v.Path)
+	if err != nil {
+		return err
+	}
+	dir = filepath.ToSlash(dir)
+
+	n := limit
+	if n == 0 {
+		n = defaultLogLimit
+	}
+	commits, err := repo.Log(dir, n)
+	if err != nil {
+		git.LogError(logger.Scope{}, err)
+		return err
+	}
+	if len(commits) == 0 {
+		logger.Info("No history for vault '%s'", v.Name)
+		return nil
+	}
+
+	for i, commit := range commits {
+		if i > 0 {
+			fmt.Println()
+		}
+		printCommit(commit)
+		for _, change := range commit.Changes {
+			fmt.Printf("    %s\n", describeChange(change, dir))
+		}
+	}
+	return nil
+}
+
+func executeHistory(arg string) error {
+	logger.PrintHeader("Note History")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	note, err := ResolveNote(cfg, vaultName, arg)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	history, err := NoteHistory(note)
+	if err != nil {
+		return err
+	}
+
+	if diffSpec != "" {
+		return showDiff(note, history, diffSpec)
+	}
+
+	if limit > 0 && len(history) > limit {
+		history = history[:limit]
+	}
+	logger.Info("History of %s:", note.Name)
+	fmt.Println()
+	// Les anciens chemins sont affichés relativement au vault
+	prefix := strings.TrimSuffix(note.Path, note.Name)
+	for _, commit := range history {
+		printCommit(commit)
+		change := commit.Changes[0]
+		switch {
+		case change.Kind() == git.ChangeRenamed:
+			fmt.Printf("    renamed from %s\n", strings.TrimPrefix(change.OldPath, prefix))
+		case change.Kind() != git.ChangeModified:
+			fmt.Printf("    %s\n", change.Kind())
+		}
+	}
+	return nil
+}
+
+// NoteHistory retourne les versions enregistrées d'une note, de la plus récente à la plus ancienne
+func NoteHistory(note *Note) ([]git.Commit, error) {
+	commits, err := note.Repository.History(note.Path)
+	if err != nil {
+		git.LogError(logger.Scope{}, err)
+		return nil, err
+	}
+
+	var history []git.Commit
+	for _, commit := range commits {
+		if len(commit.Changes) > 0 {
+			history = append(history, commit)
+		}
+	}
+	if len(history) == 0 {
+		logger.Error("No history for %s", note.Name)
+		return nil, fmt.Errorf("%s has never been committed", note.Name)
+	}
+	return history, nil
+}
+
+func printCommit(commit git.Commit) {
+	fmt.Printf("%s%s%s  %s  %s  %s\n", logger.ColorYellow, commit.ShortHash(), logger.ColorReset,
+		commit.Date.Local().Format("2006-01-02 15:04"), commit.Author, commit.Subject)
+}
+
+// describeChange décrit le changement d'un fichier du vault, avec les marques du message de commit
+func describeChange(change git.Change, dir string) string {
+	rel := func(path string) string {
+		if dir == "." {
+			return path
+		}
+		return strings.TrimPrefix(path, dir+"/")
+	}
+	switch change.Kind() {
+	case git.ChangeCreated:
+		return "+ " + rel(change.Path)
+	case git.ChangeDeleted:
+		return "- " + rel(change.Path)
+	case git.ChangeRenamed:
+		return "> " + rel(change.OldPath) + " -> " + rel(change.Path)
+	}
+	return "~ " + rel(change.Path)
+}
+
+// showDiff affiche les changements entre deux versions de la note
+func showDiff(note *Note, history []git.Commit, spec string) error {
+	from, to, hasTo := strings.Cut(spec, "..")
+
+	var newer int
+	var newContent []byte
+	var newLabel string
+	switch {
+	case hasTo && to == "":
+		content, err := os.ReadFile(note.Abs)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		newContent, newLabel = content, "working copy"
+	default:
+		target := from
+		if hasTo {
+			target = to
+		}
+		var err error
+		if newer, err = FindVersion(history, target); err != nil {
+			logger.Error("%s", err.Error())
+			return err
+		}
+		if !deleted(history[newer]) {
+			if newContent, err = Content(note.Repository, history, newer); err != nil {
+				logger.Error("%s", err.Error())
+				return err
+			}
+		}
+		newLabel = describeVersion(history[newer])
+	}
+
+	var oldContent []byte
+	var oldLabel string
+	older := newer + 1
+	if hasTo {
+		var err error
+		if older, err = FindVersion(history, from); err != nil {
+			logger.Error("%s", err.Error())
+			return err
+		}
+	}
+	if older < len(history) {
+		if !deleted(history[older]) {
+			var err error
+			if oldContent, err = Content(note.Repository, history, older); err != nil {
+				logger.Error("%s", err.Error())
+				return err
+			}
+		}
+		oldLabel = describeVersion(history[older])
+	} else {
+		oldLabel = "creation"
+	}
+
+	logger.Info("%s: %s -> %s", note.Name, oldLabel, newLabel)
+	fmt.Println()
+
+	changes := diff.Notes(oldContent, newContent, contextLines)
+	if changes.Empty() {
+		logger.Info("No changes")
+		return nil
+	}
+	printDiff(changes)
+	return nil
+}
+
+func describeVersion(commit git.Commit) string {
+	return fmt.Sprintf("%s (%s)", commit.ShortHash(), commit.Date.Local().Format(time.DateTime))
+}
+
+func printDiff(changes diff.Note) {
+	if len(changes.Properties) > 0 {
+		fmt.Printf("%sProperties%s\n", logger.ColorBlue, logger.ColorReset)
+		for _, property := range changes.Properties {
+			switch property.Kind {
+			case diff.PropertyAdded:
+				fmt.Printf("%s+ %s: %s%s\n", logger.ColorGreen, property.Key, property.New, logger.ColorReset)
+			case diff.PropertyRemoved:
+				fmt.Printf("%s- %s: %s%s\n", logger.ColorRed, property.Key, property.Old, logger.ColorReset)
+			default:
+				fmt.Printf("%s~ %s: %s -> %s%s\n", logger.ColorYellow, property.Key, property.Old, property.New, logger.ColorReset)
+			}
+		}
+		fmt.Println()
+	}
+
+	for _, hunk := range changes.Hunks {
+		header := fmt.Sprintf("Line %d", hunk.Line)
+		if hunk.Heading != "" {
+			header += ", in " + hunk.Heading
+		}
+		fmt.Printf("%s%s%s\n", logger.ColorBlue, header, logger.ColorReset)
+
+		for _, line := range hunk.Lines {
+			switch {
+			case line.Words != nil:
+				fmt.Printf("%s~%s %s\n", logger.ColorYellow, logger.ColorReset, formatWords(line.Words))
+			case line.Op == diff.Insert:
+				fmt.Printf("%s+ %s%s\n", logger.ColorGreen, line.Text, logger.ColorReset)
+			case line.Op == diff.Delete:
+				fmt.Printf("%s- %s%s\n", logger.ColorRed, line.Text, logger.ColorReset)
+			default:
+				fmt.Printf("  %s\n", line.Text)
+			}
+		}
+		fmt.Println()
+	}
+}
+
+// formatWords affiche une ligne modifiée : [-mots supprimés-]{+mots ajoutés+}
+func formatWords(words []diff.Edit) string {
+	var b strings.Builder
+	for i := 0; i < len(words); i++ {
+		op := words[i].Op
+		var text strings.Builder
+		for ; i < len(words) && words[i].Op == op; i++ {
+			text.WriteString(words[i].Text)
+		}
+		i--
+
+		switch op {
+		case diff.Delete:
+			b.WriteString(logger.ColorRed + "[-" + text.String() + "-]" + logger.ColorReset)
+		case diff.Insert:
+			b.WriteString(logger.ColorGreen + "{+" + text.String() + "+}" + logger.ColorReset)
+		default:
+			b.WriteString(text.String())
+		}
+	}
+	return b.String()
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&vaultName, "vault", "", "Vault of the note (default vault by default)")
+	historyCmd.Flags().IntVarP(&limit, "limit", "n", 0, "Maximum number of commits to list (all by default, 20 without a note)")
+	historyCmd.Flags().StringVar(&diffSpec, "diff", "", "Show the changes of a version (A), between two versions (A..B) or since a version (A..)")
+}
+
+// GetCommand returns the history command for root command integration
+func GetCommand() *cobra.Command {
+	return historyCmd
+}
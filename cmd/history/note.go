@@ -0,0 +1,137 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/git"
+	"github.com/coyls/obs-cli/internal/vault"
+)
+
+// Note est une note d'un vault désignée sur la ligne de commande
+type Note struct {
+	Repository *git.Repository
+	// Path est le chemin de la note dans le dépôt, au format de git
+	Path string
+	// Abs est le chemin de la note sur le disque ; le fichier peut ne plus exister
+	Abs string
+	// Name est le chemin de la note relatif au vault
+	Name string
+}
+
+// ResolveNote trouve une note du vault à partir d'un chemin existant (absolu ou relatif au dossier
+// courant) ou d'un chemin relatif au vault, l'extension .md pouvant être omise
+func ResolveNote(cfg *config.Config, vaultName, arg string) (*Note, error) {
+	v, err := vault.Open(cfg, vaultName)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := git.Repositories(cfg, []string{v.Name})
+	if err != nil {
+		return nil, err
+	}
+	repo := repos[0]
+
+	abs := arg
+	if _, err := os.Stat(arg); err != nil {
+		abs = v.Abs(arg)
+		if filepath.Ext(abs) == "" {
+			abs += ".md"
+		}
+	}
+	if abs, err = resolvePath(abs); err != nil {
+		return nil, err
+	}
+
+	name, err := filepath.Rel(v.Path, abs)
+	if err != nil || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s is not in vault '%s'", arg, v.Name)
+	}
+	path, err := filepath.Rel(repo.Path(), abs)
+	if err != nil {
+		return nil, err
+	}
+	return &Note{Repository: repo, Path: filepath.ToSlash(path), Abs: abs, Name: filepath.ToSlash(name)}, nil
+}
+
+// resolvePath rend un chemin absolu et résout les liens symboliques de son dossier, qui existe
+// même si la note a été supprimée
+func resolvePath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	dir, file := filepath.Split(path)
+	for dir != filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, file), nil
+		}
+		file = filepath.Join(filepath.Base(dir), file)
+		dir = filepath.Dir(filepath.Clean(dir))
+	}
+	return path, nil
+}
+
+// dateLayouts sont les formats de date acceptés pour désigner une version
+var dateLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"}
+
+// FindVersion retourne l'index dans history de la version désignée par spec : un préfixe de hash
+// ou une date, qui désigne la dernière version enregistrée à cette date (jusqu'à la fin du jour
+// si l'heure est omise)
+func FindVersion(history []git.Commit, spec string) (int, error) {
+	for _, layout := range dateLayouts {
+		date, err := time.ParseInLocation(layout, spec, time.Local)
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			date = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		for i, commit := range history {
+			if !commit.Date.After(date) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("the note did not exist on %s", spec)
+	}
+
+	if len(spec) < 4 {
+		return 0, fmt.Errorf("'%s' is neither a date (YYYY-MM-DD [HH:MM]) nor a commit (at least 4 characters)", spec)
+	}
+	found := -1
+	for i, commit := range history {
+		if strings.HasPrefix(commit.Hash, strings.ToLower(spec)) {
+			if found >= 0 {
+				return 0, fmt.Errorf("commit '%s' is ambiguous", spec)
+			}
+			found = i
+		}
+	}
+	if found < 0 {
+		return 0, fmt.Errorf("commit '%s' is not in the history of the note", spec)
+	}
+	return found, nil
+}
+
+// notePath retourne le chemin de la note dans un commit de son historique
+func notePath(commit git.Commit) string {
+	return commit.Changes[0].Path
+}
+
+// deleted indique si la note a été supprimée par ce commit
+func deleted(commit git.Commit) bool {
+	return commit.Changes[0].Kind() == git.ChangeDeleted
+}
+
+// Content retourne la note telle qu'elle était après le commit history[index]
+func Content(repo *git.Repository, history []git.Commit, index int) ([]byte, error) {
+	commit := history[index]
+	if deleted(commit) {
+		return nil, fmt.Errorf("the note was deleted by %s", commit.ShortHash())
+	}
+	return repo.FileAt(commit.Hash, notePath(commit))
+}
@@ -0,0 +1,86 @@
+package index
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vaults    []string
+	allVaults bool
+)
+
+var IndexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the cache of parsed notes",
+	Long: `Commands that read note content (links, backlinks, graph) use an index of the
+vault: frontmatter, headings, block ids, tags and links of every note. It is kept
+in the user cache directory and only the notes whose size or modification time
+changed are parsed again. A corrupted cache is detected and rebuilt
+automatically.`,
+}
+
+var RebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Parse every note again and replace the index cache",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeRebuild()
+	},
+}
+
+func executeRebuild() error {
+	logger.PrintHeader("Rebuild Index")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	names, err := cfg.SelectVaults(vaults, allVaults)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	for _, name := range names {
+		v, err := vault.Open(cfg, name)
+		if err != nil {
+			logger.Error("%s", err.Error())
+			return err
+		}
+
+		log := logger.NewScope(v.Name)
+		start := time.Now()
+		index, err := v.Rebuild()
+		if err != nil {
+			log.Error("%s", err.Error())
+			return err
+		}
+		if index.Stats.CacheError != nil {
+			log.Error("%s", index.Stats.CacheError.Error())
+			return index.Stats.CacheError
+		}
+
+		path, _ := v.CachePath()
+		log.Success("%d note(s) and %d file(s) indexed in %s", index.Stats.Notes, len(index.Files()),
+			time.Since(start).Round(time.Millisecond))
+		log.Info("Cache: %s", path)
+	}
+	return nil
+}
+
+func init() {
+	IndexCmd.AddCommand(RebuildCmd)
+	RebuildCmd.Flags().StringArrayVar(&vaults, "vault", nil, "Vault to index (repeatable, default vault by default)")
+	RebuildCmd.Flags().BoolVar(&allVaults, "all", false, "Index every configured vault")
+}
+
+// GetCommand returns the index command for root command integration
+func GetCommand() *cobra.Command {
+	return IndexCmd
+}
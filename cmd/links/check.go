@@ -0,0 +1,308 @@
+package links
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/link"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vaults    []string
+	allVaults bool
+	fix       bool
+	assumeYes bool
+)
+
+var CheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Report the links and embeds that lead nowhere",
+	Long: `The check command resolves every [[wikilink]], ![[embed]], heading link
+([[Note#Heading]]), block reference ([[Note#^id]]) and Markdown link of the vault
+as Obsidian does, and prints the unresolved ones as 'file:line: problem'. It
+exits with an error when one is found, so it can run in CI.
+
+With --fix, the closest existing note, attachment, heading or block is suggested
+for each unresolved link and written in the note once confirmed (--yes accepts
+every suggestion).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeCheck()
+	},
+}
+
+// problem est un lien qui ne mène nulle part
+type problem struct {
+	Path    string
+	Link    link.Link
+	Message string
+	// Start et End délimitent le texte à remplacer par Replacement pour appliquer la suggestion
+	Start, End  int
+	Replacement string
+	// Suggestion est le lien corrigé, tel qu'il s'affiche ; vide sans suggestion
+	Suggestion string
+}
+
+func executeCheck() error {
+	logger.PrintHeader("Check Links")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	names, err := cfg.SelectVaults(vaults, allVaults)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	unresolved := 0
+	for _, name := range names {
+		v, err := vault.Open(cfg, name)
+		if err != nil {
+			logger.Error("%s", err.Error())
+			return err
+		}
+		log := logger.NewScope(v.Name)
+
+		index, err := v.Load()
+		if err != nil {
+			log.Error("%s", err.Error())
+			return err
+		}
+		if index.Stats.CacheError != nil {
+			log.Warning("%s", index.Stats.CacheError.Error())
+		}
+
+		problems := checkVault(index)
+		for _, p := range problems {
+			fmt.Printf("%s:%d: %s\n", p.Path, p.Link.Line, p.Message)
+		}
+
+		fixed := 0
+		if fix && len(problems) > 0 {
+			if fixed, err = fixProblems(v, problems); err != nil {
+				log.Error("%s", err.Error())
+				return err
+			}
+		}
+
+		remaining := len(problems) - fixed
+		unresolved += remaining
+		if fixed > 0 {
+			log.Success("%d link(s) fixed", fixed)
+		}
+		if len(problems) == 0 {
+			log.Success("All links of %d note(s) resolve", index.Stats.Notes)
+		} else if remaining > 0 {
+			log.Error("%d unresolved link(s)", remaining)
+		}
+	}
+
+	if unresolved > 0 {
+		return fmt.Errorf("%d unresolved link(s)", unresolved)
+	}
+	return nil
+}
+
+// checkVault résout les liens de toutes les notes
+func checkVault(index *vault.Index) []problem {
+	var problems []problem
+	for _, note := range index.Notes() {
+		for _, l := range note.Links {
+			if p, ok := checkLink(index, note, l); ok {
+				problems = append(problems, p)
+			}
+		}
+	}
+	return problems
+}
+
+// checkLink vérifie qu'un lien mène à un fichier et, pour une note, à un titre ou un bloc existant
+func checkLink(index *vault.Index, note *vault.Note, l link.Link) (problem, bool) {
+	p := problem{Path: note.Path, Link: l}
+
+	target := note.Path
+	if l.Target != "" {
+		resolved, ok := index.Resolve(note.Path, l.Target)
+		if !ok {
+			p.Message = fmt.Sprintf("unresolved %s %s", kind(l), format(l, l.Target, l.Fragment))
+			if file, ok := closestFile(index, l.Target); ok {
+				p.Start, p.End = l.TargetStart, l.TargetEnd
+				p.Replacement = index.LinkText(note.Path, file, l)
+				p.Suggestion = format(l, p.Replacement, l.Fragment)
+			}
+			return p, true
+		}
+		target = resolved
+	}
+
+	if l.Fragment == "" {
+		return p, false
+	}
+	dest, ok := index.Note(target)
+	if !ok || dest.Encrypted {
+		return p, false
+	}
+	fragment := l.Fragment
+	if l.Markdown {
+		if decoded, err := url.PathUnescape(fragment); err == nil {
+			fragment = decoded
+		}
+	}
+
+	// Le fragment suit la cible et son '#' dans le texte du lien
+	p.Start = l.TargetEnd + 1
+	p.End = p.Start + len(l.Fragment)
+
+	var replacement string
+	if strings.HasPrefix(fragment, "^") {
+		if _, ok := dest.Block(fragment); ok {
+			return p, false
+		}
+		p.Message = fmt.Sprintf("block not found %s", format(l, l.Target, l.Fragment))
+		var ids []string
+		for _, block := range dest.Blocks {
+			ids = append(ids, block.ID)
+		}
+		if id, ok := closest(strings.TrimPrefix(fragment, "^"), ids); ok {
+			replacement = "^" + id
+		}
+	} else {
+		if _, ok := dest.Heading(fragment); ok {
+			return p, false
+		}
+		p.Message = fmt.Sprintf("heading not found %s", format(l, l.Target, l.Fragment))
+		if i := strings.LastIndex(fragment, "#"); i >= 0 {
+			fragment = fragment[i+1:]
+		}
+		var headings []string
+		for _, heading := range dest.Headings {
+			headings = append(headings, heading.Text)
+		}
+		if heading, ok := closest(fragment, headings); ok {
+			replacement = heading
+		}
+	}
+
+	if replacement != "" {
+		if l.Markdown && !l.Angle {
+			replacement = strings.ReplaceAll(replacement, " ", "%20")
+		}
+		p.Replacement = replacement
+		p.Suggestion = format(l, content(l), replacement)
+	}
+	return p, true
+}
+
+// content retourne la cible d'un lien telle qu'elle est écrite
+func content(l link.Link) string {
+	if l.Markdown && !l.Angle {
+		return strings.ReplaceAll(l.Target, " ", "%20")
+	}
+	return l.Target
+}
+
+func kind(l link.Link) string {
+	if l.Embed {
+		return "embed"
+	}
+	return "link"
+}
+
+// format affiche un lien avec la cible et le fragment donnés
+func format(l link.Link, target, fragment string) string {
+	if fragment != "" {
+		target += "#" + fragment
+	}
+	prefix := ""
+	if l.Embed {
+		prefix = "!"
+	}
+	if l.Markdown {
+		if l.Angle {
+			target = "<" + target + ">"
+		}
+		return fmt.Sprintf("%s[%s](%s)", prefix, l.Alias, target)
+	}
+	return fmt.Sprintf("%s[[%s]]", prefix, target)
+}
+
+// fixProblems propose la suggestion de chaque lien et réécrit les notes avec celles acceptées.
+// Il retourne le nombre de liens corrigés.
+func fixProblems(v *vault.Vault, problems []problem) (int, error) {
+	stdin := bufio.NewReader(os.Stdin)
+	accepted := make(map[string][]problem)
+	for _, p := range problems {
+		if p.Suggestion == "" {
+			continue
+		}
+		question := fmt.Sprintf("%s:%d: replace %s with %s?", p.Path, p.Link.Line,
+			format(p.Link, content(p.Link), p.Link.Fragment), p.Suggestion)
+		if confirm(stdin, question) {
+			accepted[p.Path] = append(accepted[p.Path], p)
+		}
+	}
+
+	fixed := 0
+	paths := make([]string, 0, len(accepted))
+	for path := range accepted {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(v.Abs(path))
+		if err != nil {
+			return fixed, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		// Les liens sont remplacés du dernier au premier pour garder les positions valides
+		edits := accepted[path]
+		sort.Slice(edits, func(a, b int) bool { return edits[a].Start > edits[b].Start })
+		for _, p := range edits {
+			if p.End > len(data) {
+				return fixed, fmt.Errorf("%s changed during the check, run it again", path)
+			}
+			data = append(data[:p.Start], append([]byte(p.Replacement), data[p.End:]...)...)
+		}
+
+		if err := crypt.WriteFileAtomic(v.Abs(path), data); err != nil {
+			return fixed, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fixed += len(edits)
+	}
+	return fixed, nil
+}
+
+func confirm(stdin *bufio.Reader, question string) bool {
+	if assumeYes {
+		fmt.Printf("%s yes\n", question)
+		return true
+	}
+
+	fmt.Printf("%s (y/N): ", question)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Println()
+		return false
+	}
+	return strings.ToLower(strings.TrimSpace(line)) == "y"
+}
+
+func init() {
+	LinksCmd.AddCommand(CheckCmd)
+	CheckCmd.Flags().StringArrayVar(&vaults, "vault", nil, "Vault to check (repeatable, default vault by default)")
+	CheckCmd.Flags().BoolVar(&allVaults, "all", false, "Check every configured vault")
+	CheckCmd.Flags().BoolVar(&fix, "fix", false, "Suggest the closest existing target for each unresolved link and apply the accepted ones")
+	CheckCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "With --fix, apply every suggestion without asking")
+}
@@ -0,0 +1,163 @@
+package links
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coyls/obs-cli/internal/vault"
+)
+
+// loadFixture copie le vault testdata/vault dans un dossier temporaire, que fixProblems peut modifier
+func loadFixture(t *testing.T) (*vault.Vault, *vault.Index) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS("testdata/vault")); err != nil {
+		t.Fatal(err)
+	}
+	v := &vault.Vault{Name: "test", Path: dir}
+	index, err := v.Rebuild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v, index
+}
+
+func TestCheckVault(t *testing.T) {
+	_, index := loadFixture(t)
+
+	want := []string{
+		"Home.md:2: unresolved link [[Project Plam]] -> [[Project Plan]]",
+		"Home.md:2: heading not found [[Projects/Project Plan#Next Step]] -> [[Projects/Project Plan#Next Steps]]",
+		"Home.md:3: unresolved embed ![[diagramm.png]] -> ![[diagram.png]]",
+		"Home.md:3: block not found [[Project Plan#^sumary]] -> [[Project Plan#^summary]]",
+		"Home.md:4: unresolved link [plan](Projects/Project Plam.md) -> [plan](Projects/Project%20Plan.md)",
+		"Home.md:4: heading not found [steps](Projects/Project Plan.md#Next%20Step) -> [steps](Projects/Project%20Plan.md#Next%20Steps)",
+		"Home.md:5: unresolved link [[Nothing like it]]",
+		"Home.md:5: heading not found [angle](<Projects/Project Plan.md#Next Step>) -> [angle](<Projects/Project Plan.md#Next Steps>)",
+	}
+
+	var got []string
+	for _, p := range checkVault(index) {
+		line := fmt.Sprintf("%s:%d: %s", p.Path, p.Link.Line, p.Message)
+		if p.Suggestion != "" {
+			line += " -> " + p.Suggestion
+		}
+		got = append(got, line)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFixProblems(t *testing.T) {
+	v, index := loadFixture(t)
+	assumeYes = true
+	t.Cleanup(func() { assumeYes = false })
+
+	problems := checkVault(index)
+	fixed, err := fixProblems(v, problems)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed != 7 {
+		t.Errorf("%d links fixed, want 7", fixed)
+	}
+
+	want := `# Home
+[[Project Plan]] and [[Projects/Project Plan#Next Steps]]
+![[diagram.png]] and [[Project Plan#^summary]]
+[plan](Projects/Project%20Plan.md) and [steps](Projects/Project%20Plan.md#Next%20Steps)
+[angle](<Projects/Project Plan.md#Next Steps>) and [[Nothing like it]]
+[[Project Plan#Next Steps]], [[Project Plan#^summary]] and ![[diagram.png]]
+`
+	data, err := os.ReadFile(filepath.Join(v.Path, "Home.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("Home.md after the fix:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Seul le lien sans suggestion reste cassé
+	index, err = v.Rebuild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining := checkVault(index); len(remaining) != 1 || remaining[0].Link.Target != "Nothing like it" {
+		t.Errorf("remaining problems %+v", remaining)
+	}
+}
+
+func TestClosest(t *testing.T) {
+	tests := []struct {
+		want       string
+		candidates []string
+		result     string
+	}{
+		{"Next Step", []string{"Introduction", "Next Steps"}, "Next Steps"},
+		{"next steps", []string{"Next Steps"}, "Next Steps"},
+		{"sumary", []string{"summary", "summit"}, "summary"},
+		{"Résumé", []string{"Resume"}, "Resume"},
+		{"Plan", []string{"Part"}, ""},
+		{"ab", []string{"xy"}, ""},
+		{"anything", nil, ""},
+	}
+
+	for _, tt := range tests {
+		result, ok := closest(tt.want, tt.candidates)
+		if result != tt.result || ok != (tt.result != "") {
+			t.Errorf("closest(%q, %q) = %q, %v, want %q", tt.want, tt.candidates, result, ok, tt.result)
+		}
+	}
+}
+
+func TestClosestFile(t *testing.T) {
+	_, index := loadFixture(t)
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"Project Plam", "Projects/Project Plan.md"},
+		{"Projects/Project Plam.md", "Projects/Project Plan.md"},
+		{"home", "Home.md"},
+		{"diagramm.png", "diagram.png"},
+		{"diagramm", ""},
+		{"Nothing like it", ""},
+	}
+
+	for _, tt := range tests {
+		file, ok := closestFile(index, tt.target)
+		if file != tt.want || ok != (tt.want != "") {
+			t.Errorf("closestFile(%q) = %q, %v, want %q", tt.target, file, ok, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"plan", "plam", 1},
+		{"été", "ete", 2},
+		{"journée", "journee", 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := levenshtein(tt.b, tt.a); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package links
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var LinksCmd = &cobra.Command{
+	Use:   "links",
+	Short: "Check the links between notes",
+}
+
+// GetCommand returns the links command for root command integration
+func GetCommand() *cobra.Command {
+	return LinksCmd
+}
@@ -0,0 +1,74 @@
+package links
+
+import (
+	"path"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/vault"
+)
+
+// closestFile retourne le fichier du vault dont le nom est le plus proche de la cible d'un lien
+// cassé ; à distance égale, le plus proche de la racine
+func closestFile(index *vault.Index, target string) (string, bool) {
+	want := strings.ToLower(path.Base(target))
+	withExt := strings.Contains(want, ".") && !strings.HasSuffix(want, ".md")
+	want = strings.TrimSuffix(want, ".md")
+
+	best, bestDistance := "", -1
+	for _, file := range index.Files() {
+		name := strings.ToLower(path.Base(file))
+		if vault.IsNote(file) && !withExt {
+			name = strings.TrimSuffix(name, ".md")
+		}
+		distance := levenshtein(want, name)
+		if !acceptable(want, distance) {
+			continue
+		}
+		if bestDistance < 0 || distance < bestDistance ||
+			(distance == bestDistance && strings.Count(file, "/") < strings.Count(best, "/")) {
+			best, bestDistance = file, distance
+		}
+	}
+	return best, bestDistance >= 0
+}
+
+// closest retourne le texte de candidates le plus proche de want
+func closest(want string, candidates []string) (string, bool) {
+	want = strings.ToLower(want)
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		distance := levenshtein(want, strings.ToLower(candidate))
+		if acceptable(want, distance) && (bestDistance < 0 || distance < bestDistance) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best, bestDistance >= 0
+}
+
+// acceptable indique si une suggestion est assez proche : au plus un tiers des caractères
+// modifiés, et au moins un
+func acceptable(want string, distance int) bool {
+	return distance <= max(1, len([]rune(want))/3)
+}
+
+// levenshtein retourne le nombre de caractères à insérer, supprimer ou remplacer pour passer de a à b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -4,34 +4,58 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/link"
 	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
 	"github.com/spf13/cobra"
 )
 
 var (
 	destination string
+	vaultName   string
+	dryRun      bool
 )
 
 var mvCmd = &cobra.Command{
-	Use:   "mv [source_file]",
-	Short: "Move a file to the Obsidian vault",
+	Use:   "mv [source] [destination]",
+	Short: "Move a file to the Obsidian vault, or a note within it",
 	Long: `The mv command moves a file from anywhere on your system to your Obsidian vault.
 If no destination is specified, the file will be moved to the default directory defined in the configuration.
 
+When the source is a note, a file or a folder of the vault (a path on disk, or a
+path relative to the vault where the .md extension may be omitted), it is moved
+within the vault and every [[wikilink]], ![[embed]] and Markdown link pointing at
+it is rewritten, as Obsidian does: links keep their style (shortest name,
+relative path or path from the vault root) and only change when they would no
+longer lead to the same file. The destination is the new path relative to the
+vault, or a folder with -d or a trailing '/'. --dry-run lists the links that
+would change without touching any file.
+
 Example:
-  obs-cli mv ~/Downloads/image.png -d Assets/new`,
-	Args: cobra.ExactArgs(1),
+  obs-cli mv ~/Downloads/image.png -d Assets/new
+  obs-cli mv "Inbox/Meeting notes" Projects/Acme/Kickoff --dry-run
+  obs-cli mv Drafts/ Archives/`,
+	Args: cobra.RangeArgs(1, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
 			return cmd.Help()
 		}
-		return executeMove(args[0])
+		if len(args) == 2 {
+			if destination != "" {
+				return fmt.Errorf("give the destination either as an argument or with --destination")
+			}
+			return executeMove(args[0], args[1], false)
+		}
+		return executeMove(args[0], destination, true)
 	},
 }
 
-func executeMove(source string) error {
+func executeMove(source, dest string, intoDir bool) error {
 	logger.PrintHeader("Move file to Obsidian vault")
 
 	cfg, err := config.LoadConfig()
@@ -39,27 +63,35 @@ func executeMove(source string) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	v, err := vault.Open(cfg, vaultName)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	if rel, ok := inVault(v, source); ok {
+		if dest == "" {
+			logger.Error("No destination specified")
+			return fmt.Errorf("no destination specified for a move within the vault")
+		}
+		return moveInVault(v, rel, dest, intoDir)
+	}
+
 	if _, err := os.Stat(source); os.IsNotExist(err) {
 		logger.Error("Source file not found: %s", source)
 		return fmt.Errorf("source file not found: %s", source)
 	}
 
-	vaultConfig, exists := cfg.GetVaultConfig(cfg.Config.DefaultVault)
-	if !exists {
-		logger.Error("Default vault configuration not found")
-		return fmt.Errorf("default vault configuration not found")
-	}
-
-	if destination == "" {
-		if vaultConfig.Commands.Mv.DefaultTargetPath == "" {
+	if dest == "" {
+		if v.Config.Commands.Mv.DefaultTargetPath == "" {
 			logger.Error("No destination specified and no default path configured")
 			return fmt.Errorf("no destination specified and no default path configured")
 		}
-		destination = vaultConfig.Commands.Mv.DefaultTargetPath
-		logger.Info("Using default destination: %s", destination)
+		dest = v.Config.Commands.Mv.DefaultTargetPath
+		logger.Info("Using default destination: %s", dest)
 	}
 
-	destPath := filepath.Join(cfg.Config.Root, vaultConfig.VaultPath, destination)
+	destPath := filepath.Join(v.Path, dest)
 	if _, err := os.Stat(destPath); os.IsNotExist(err) {
 		logger.Info("Creating destination directory: %s", destPath)
 		if err := os.MkdirAll(destPath, 0755); err != nil {
@@ -86,8 +118,149 @@ func executeMove(source string) error {
 	return nil
 }
 
+// inVault retourne le chemin relatif au vault de source s'il désigne un fichier ou un dossier du
+// vault : un chemin existant (absolu ou relatif au dossier courant) dans le vault, ou un chemin
+// relatif au vault, l'extension .md pouvant être omise
+func inVault(v *vault.Vault, source string) (string, bool) {
+	var candidates []string
+	if _, err := os.Stat(source); err == nil {
+		candidates = append(candidates, source)
+	} else if !filepath.IsAbs(source) {
+		candidates = append(candidates, v.Abs(source), v.Abs(source+".md"))
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err != nil {
+			continue
+		}
+		if rel, ok := v.Rel(candidate); ok && rel != "." {
+			return rel, true
+		}
+	}
+	return "", false
+}
+
+// moveInVault déplace un fichier ou un dossier du vault et réécrit les liens qui y mènent
+func moveInVault(v *vault.Vault, source, dest string, intoDir bool) error {
+	sourceAbs := v.Abs(source)
+	info, err := os.Stat(sourceAbs)
+	if err != nil {
+		logger.Error("Source file not found: %s", source)
+		return err
+	}
+
+	target := filepath.ToSlash(filepath.Clean(strings.TrimPrefix(dest, "/")))
+	destInfo, err := os.Stat(v.Abs(target))
+	if intoDir || strings.HasSuffix(dest, "/") || (err == nil && destInfo.IsDir()) {
+		target = filepath.ToSlash(filepath.Join(target, filepath.Base(sourceAbs)))
+	} else if !info.IsDir() && filepath.Ext(target) == "" {
+		target += filepath.Ext(source)
+	}
+	if target == "." || target == ".." || strings.HasPrefix(target, "../") {
+		logger.Error("Destination is outside the vault: %s", dest)
+		return fmt.Errorf("destination is outside the vault: %s", dest)
+	}
+	if target == source || strings.HasPrefix(target, source+"/") {
+		logger.Error("Cannot move %s into itself", source)
+		return fmt.Errorf("cannot move %s to %s", source, target)
+	}
+	targetAbs := v.Abs(target)
+	if _, err := os.Lstat(targetAbs); err == nil {
+		logger.Error("File already exists in destination: %s", target)
+		return fmt.Errorf("file already exists in destination: %s", target)
+	}
+
+	files, err := v.Files()
+	if err != nil {
+		logger.Error("Failed to list vault files: %s", err.Error())
+		return fmt.Errorf("failed to list vault files: %w", err)
+	}
+
+	moves := make(map[string]string)
+	notes := make(map[string][]byte)
+	for _, file := range files {
+		if file == source {
+			moves[file] = target
+		} else if info.IsDir() && strings.HasPrefix(file, source+"/") {
+			moves[file] = target + strings.TrimPrefix(file, source)
+		}
+		if vault.IsNote(file) {
+			content, err := os.ReadFile(v.Abs(file))
+			if err != nil {
+				logger.Error("Failed to read %s: %s", file, err.Error())
+				return err
+			}
+			notes[file] = content
+		}
+	}
+	rewrites := link.Rewrite(notes, files, moves)
+
+	logger.Info("Moving %s to %s", source, target)
+	if info.IsDir() {
+		logger.Info("%d file(s) in the folder", len(moves))
+	}
+	printRewrites(rewrites)
+
+	if dryRun {
+		logger.Info("Dry run: no file was changed")
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetAbs), 0755); err != nil {
+		logger.Error("Failed to create destination directory: %s", err.Error())
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	if err := os.Rename(sourceAbs, targetAbs); err != nil {
+		logger.Error("Failed to move file: %s", err.Error())
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+
+	failed := 0
+	for _, rewrite := range rewrites {
+		path := v.Abs(rewrite.NewPath)
+		if err := crypt.WriteFileAtomic(path, rewrite.Content); err != nil {
+			logger.Error("Failed to update links in %s: %s", rewrite.NewPath, err.Error())
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to update links in %d note(s)", failed)
+	}
+
+	logger.Success("Moved %s to %s, %d note(s) updated", source, target, len(rewrites))
+	return nil
+}
+
+// printRewrites affiche les liens réécrits, par note
+func printRewrites(rewrites []link.FileRewrite) {
+	if len(rewrites) == 0 {
+		logger.Info("No link to update")
+		return
+	}
+
+	count := 0
+	for _, rewrite := range rewrites {
+		count += len(rewrite.Replacements)
+	}
+	logger.Info("%d link(s) to update in %d note(s):", count, len(rewrites))
+
+	sort.Slice(rewrites, func(a, b int) bool { return rewrites[a].NewPath < rewrites[b].NewPath })
+	for _, rewrite := range rewrites {
+		if rewrite.NewPath != rewrite.Path {
+			fmt.Printf("  %s (moved to %s)\n", rewrite.Path, rewrite.NewPath)
+		} else {
+			fmt.Printf("  %s\n", rewrite.Path)
+		}
+		for _, replacement := range rewrite.Replacements {
+			fmt.Printf("    line %d: %s -> %s\n", replacement.Line, replacement.Old, replacement.New)
+		}
+	}
+}
+
 func init() {
 	mvCmd.Flags().StringVarP(&destination, "destination", "d", "", "Destination directory in the vault (optional)")
+	mvCmd.Flags().StringVar(&vaultName, "vault", "", "Vault to move into or within (default vault by default)")
+	mvCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the links that would be rewritten without moving anything")
 }
 
 func GetCommand() *cobra.Command {
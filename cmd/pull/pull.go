@@ -1,82 +1,147 @@
 package pull
 
 import (
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/conflict"
 	"github.com/coyls/obs-cli/internal/git"
 	"github.com/coyls/obs-cli/internal/logger"
 	"github.com/spf13/cobra"
 )
 
+var (
+	vaults    []string
+	allVaults bool
+)
+
 var pullCmd = &cobra.Command{
 	Use:   "pull",
-	Short: "Pull changes from GitHub",
-	Long: `The pull command synchronizes your Obsidian vault with the remote GitHub repository.
-It fetches and applies the latest changes locally.`,
+	Short: "Pull changes from the remote repository",
+	Long: `The pull command synchronizes your Obsidian vault with the remote git repository.
+It fetches and applies the latest changes locally.
+
+Each vault is pulled from the repository that contains it, or from the one set in
+'git.path'. With --vault or --all, the repositories are pulled in parallel.
+The remote and the branch are read from the 'git' section of the vault configuration.
+Conflicts are resolved with the strategies of 'git.conflicts'; the remaining ones
+are listed and can be resolved with 'obs-cli conflicts'.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return executePull()
 	},
 }
 
 func executePull() error {
-	logger.PrintHeader("Pull Obsidian from GitHub")
+	logger.PrintHeader("Pull Obsidian")
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	gitClient := git.New(cfg.Config.Root)
-
-	logger.Info("Checking current branch...")
-	currentBranch, err := gitClient.GetCurrentBranch()
+	names, err := cfg.SelectVaults(vaults, allVaults)
 	if err != nil {
-		logger.Error(err.Error())
+		logger.Error("%s", err.Error())
 		return err
 	}
-
-	if currentBranch != "main" {
-		logger.Error("You are not on the main branch (current branch: %s)", currentBranch)
-		return nil
-	}
-
-	logger.Info("Checking remote changes...")
-	if err := gitClient.Fetch(); err != nil {
-		logger.Error("Error while checking for changes")
+	repos, err := git.Repositories(cfg, names)
+	if err != nil {
+		logger.Error("%s", err.Error())
 		return err
 	}
-	logger.Success("Check completed")
-
-	logger.Info("Fetching changes...")
-	if err := gitClient.Pull(); err != nil {
-		if strings.Contains(err.Error(), "conflict") {
-			logger.Error("Conflicts detected!")
-			logger.Info("Conflicting files:")
-
-			conflicts, err := gitClient.GetConflicts()
-			if err != nil {
-				logger.Error("Unable to list conflicts")
-				return err
-			}
-
-			for _, conflict := range conflicts {
-				logger.Info("  - %s", conflict)
-			}
-
-			logger.Info("\nResolve conflicts manually and commit changes")
-		} else {
-			logger.Error(err.Error())
-		}
+
+	results := git.RunAll(repos, pullRepository)
+	if err := git.PrintSummary(results); err != nil {
 		return err
 	}
 
-	logger.Success("Pull successful!")
 	logger.Success("Synchronization completed!")
 	return nil
 }
 
+// pullRepository récupère et applique les changements distants d'un dépôt
+func pullRepository(repo *git.Repository, log logger.Scope) (string, error) {
+	resolver, err := conflict.NewResolver(repo.Git, repo.Config.Conflicts)
+	if err != nil {
+		log.Error("%s", err.Error())
+		return "", err
+	}
+
+	log.Info("Checking current branch...")
+	currentBranch, err := repo.GetCurrentBranch()
+	if err != nil {
+		log.Error("%s", err.Error())
+		return "", err
+	}
+
+	if currentBranch != repo.Branch() {
+		log.Error("You are not on the %s branch (current branch: %s)", repo.Branch(), currentBranch)
+		return "", fmt.Errorf("not on the %s branch (current branch: %s)", repo.Branch(), currentBranch)
+	}
+
+	log.Info("Checking remote changes...")
+	if err := repo.Fetch(); err != nil {
+		git.LogError(log, err)
+		return "", err
+	}
+	log.Success("Check completed")
+
+	log.Info("Fetching changes...")
+	if err := repo.Pull(); err != nil {
+		if !errors.Is(err, git.ErrConflict) {
+			git.LogError(log, err)
+			return "", err
+		}
+		conflicts, conflictErr := repo.GetConflicts()
+		if conflictErr != nil || len(conflicts) == 0 {
+			git.LogError(log, err)
+			return "", err
+		}
+
+		log.Error("Conflicts detected!")
+		return resolveConflicts(repo, resolver, conflicts, log)
+	}
+	log.Success("Pull successful!")
+	return fmt.Sprintf("up to date with %s/%s", repo.Remote(), repo.Branch()), nil
+}
+
+// resolveConflicts applique les stratégies configurées puis termine la fusion si tous les
+// conflits sont résolus
+func resolveConflicts(repo *git.Repository, resolver *conflict.Resolver, conflicts []string, log logger.Scope) (string, error) {
+	resolved, remaining, err := resolver.ResolveAll(conflicts)
+	for _, file := range conflicts {
+		if strategy, ok := resolved[file]; ok {
+			log.Success("  - %s (%s)", file, strategy)
+		}
+	}
+	if err != nil {
+		log.Error("%s", err.Error())
+		return "", err
+	}
+
+	if len(remaining) > 0 {
+		log.Info("Conflicting files:")
+		for _, file := range remaining {
+			log.Info("  - %s", file)
+		}
+		log.Info("Run 'obs-cli conflicts' to resolve them")
+		return "", fmt.Errorf("%d conflict(s) to resolve", len(remaining))
+	}
+
+	if err := repo.Continue(); err != nil {
+		log.Error("%s", err.Error())
+		return "", err
+	}
+	log.Success("Conflicts resolved and merged")
+	return fmt.Sprintf("merged %s/%s, %d conflict(s) resolved", repo.Remote(), repo.Branch(), len(resolved)), nil
+}
+
+func init() {
+	pullCmd.Flags().StringArrayVar(&vaults, "vault", nil, "Vault to pull (repeatable, default vault by default)")
+	pullCmd.Flags().BoolVar(&allVaults, "all", false, "Pull every configured vault")
+}
+
 func GetCommand() *cobra.Command {
 	return pullCmd
 }
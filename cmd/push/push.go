@@ -2,80 +2,272 @@ package push
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
 
+	"github.com/coyls/obs-cli/internal/check"
 	"github.com/coyls/obs-cli/internal/config"
 	"github.com/coyls/obs-cli/internal/git"
 	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
 	"github.com/spf13/cobra"
 )
 
 var (
-	force bool
+	force       bool
+	vaults      []string
+	allVaults   bool
+	message     string
+	editMessage bool
+	noVerify    bool
 )
 
 var pushCmd = &cobra.Command{
 	Use:   "push",
-	Short: "Push changes to GitHub",
-	Long: `The push command synchronizes your Obsidian vault with the remote GitHub repository.
-It performs a commit and pushes the changes.`,
+	Short: "Push changes to the remote repository",
+	Long: `The push command synchronizes your Obsidian vault with the remote git repository.
+It performs a commit and pushes the changes.
+
+Each vault is pushed to the repository that contains it, or to the one set in
+'git.path'. With --vault or --all, the repositories are pushed in parallel.
+The remote, the branch and the commit message template are read from the 'git'
+section of the vault configuration. By default the message summarizes the
+created, modified, deleted and renamed notes by folder. --message replaces it
+and --edit opens it in the default editor before committing.
+
+Before committing, the changed files are checked: files above the size limit,
+private notes in plaintext, secrets (API keys, tokens, private keys) and conflict
+markers block the push; broken [[links]] are reported as warnings. The checks
+are configured in 'git.checks' and skipped with --no-verify.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return executePush()
 	},
 }
 
 func executePush() error {
-	logger.PrintHeader("Push Obsidian to GitHub")
+	logger.PrintHeader("Push Obsidian")
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	gitClient := git.New(cfg.Config.Root)
-
-	logger.Info("Checking for changes...")
-	hasChanges, err := gitClient.HasChanges()
+	names, err := cfg.SelectVaults(vaults, allVaults)
 	if err != nil {
 		logger.Error("%s", err.Error())
 		return err
 	}
+	repos, err := git.Repositories(cfg, names)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	results := git.RunAll(repos, func(repo *git.Repository, log logger.Scope) (string, error) {
+		return pushRepository(cfg, repo, log)
+	})
+	if err := git.PrintSummary(results); err != nil {
+		return err
+	}
+
+	logger.Success("Synchronization completed!")
+	return nil
+}
+
+// pushRepository commite et pousse les changements d'un dépôt
+func pushRepository(cfg *config.Config, repo *git.Repository, log logger.Scope) (string, error) {
+	log.Info("Checking for changes...")
+	hasChanges, err := repo.HasChanges()
+	if err != nil {
+		log.Error("%s", err.Error())
+		return "", err
+	}
 
 	if !hasChanges && !force {
-		logger.Info("No changes to add")
-		return nil
+		log.Info("No changes to add")
+		return "no changes", nil
 	}
 
-	// Add changes
-	logger.Info("Adding changes...")
-	if err := gitClient.AddAll(); err != nil {
-		logger.Error("%s", err.Error())
-		return err
+	if noVerify {
+		log.Info("Skipping pre-push checks")
+	} else {
+		log.Info("Running pre-push checks...")
+		if err := Verify(cfg, repo, log); err != nil {
+			return "", err
+		}
+	}
+
+	// Add changes and create commit
+	log.Info("Creating commit...")
+	var edit func(string) (string, error)
+	if editMessage {
+		edit = func(commitMessage string) (string, error) {
+			return editCommitMessage(cfg, repo, commitMessage)
+		}
+	}
+	count, err := repo.CommitChanges(message, edit)
+	if err != nil {
+		log.Error("%s", err.Error())
+		return "", err
+	}
+	if count == 0 {
+		log.Info("No changes to commit")
+		if !force {
+			return "no changes", nil
+		}
+	} else {
+		log.Success("Commit created (%d file(s))", count)
+	}
+
+	// Push to remote
+	log.Info("Pushing to %s/%s...", repo.Remote(), repo.Branch())
+	if err := repo.Push(); err != nil {
+		git.LogError(log, err)
+		return "", err
+	}
+	log.Success("Push successful!")
+
+	return fmt.Sprintf("%d file(s) pushed to %s/%s", count, repo.Remote(), repo.Branch()), nil
+}
+
+// editorMu empêche d'ouvrir plusieurs éditeurs à la fois quand les dépôts sont poussés en parallèle
+var editorMu sync.Mutex
+
+const editorHelp = `
+# Commit message for %s.
+# Lines starting with '#' are ignored; an empty message aborts the commit.
+`
+
+// editCommitMessage ouvre le message dans l'éditeur par défaut et retourne le texte enregistré
+func editCommitMessage(cfg *config.Config, repo *git.Repository, commitMessage string) (string, error) {
+	editorMu.Lock()
+	defer editorMu.Unlock()
+
+	file, err := os.CreateTemp("", "obs-cli-commit-*.txt")
+	if err != nil {
+		return "", err
 	}
-	logger.Success("Changes added")
+	defer os.Remove(file.Name())
 
-	// Create commit
-	logger.Info("Creating commit...")
-	if err := gitClient.Commit(); err != nil {
-		logger.Info("No changes to commit")
+	_, err = fmt.Fprintf(file, "%s\n"+editorHelp, commitMessage, repo.Name())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write commit message: %w", err)
+	}
+
+	editorCmd := exec.Command(cfg.Editor(), file.Name())
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to open editor: %w", err)
+	}
+
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit message: %w", err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	edited := strings.TrimSpace(strings.Join(lines, "\n"))
+	if edited == "" {
+		return "", fmt.Errorf("empty commit message, commit aborted")
+	}
+	return edited, nil
+}
+
+// Verify exécute les vérifications configurées dans 'git.checks' sur les fichiers qui seront
+// commités, affiche les problèmes par fichier et retourne une erreur si l'un d'eux bloque le commit
+func Verify(cfg *config.Config, repo *git.Repository, log logger.Scope) error {
+	changes, err := repo.Status()
+	if err != nil {
+		log.Error("%s", err.Error())
+		return err
+	}
+	var paths []string
+	for _, change := range changes {
+		if change.Kind() != git.ChangeDeleted {
+			paths = append(paths, change.Path)
+		}
+	}
+	if len(paths) == 0 {
 		return nil
 	}
-	logger.Success("Commit created")
 
-	// Push to GitHub
-	logger.Info("Pushing to GitHub...")
-	if err := gitClient.Push(); err != nil {
-		logger.Error("Unable to connect to GitHub")
-		logger.Error("Check your internet connection and try again")
+	var vaultPaths []string
+	for _, name := range repo.Vaults {
+		v, err := vault.Open(cfg, name)
+		if err != nil {
+			log.Error("%s", err.Error())
+			return err
+		}
+		vaultPaths = append(vaultPaths, v.Path)
+	}
+
+	pipeline, err := check.New(repo.Path(), vaultPaths, repo.Config.Checks)
+	if err != nil {
+		log.Error("%s", err.Error())
+		return err
+	}
+	findings, err := pipeline.Run(paths)
+	if err != nil {
+		log.Error("%s", err.Error())
 		return err
 	}
-	logger.Success("Push successful!")
+	printFindings(log, findings)
 
-	logger.Success("Synchronization completed!")
+	if blocking := check.Blocking(findings); blocking > 0 {
+		log.Info("Fix them, add exceptions in 'git.checks' or skip the checks with --no-verify")
+		return fmt.Errorf("pre-push checks failed: %d problem(s)", blocking)
+	}
 	return nil
 }
 
+// printFindings affiche les problèmes regroupés par fichier
+func printFindings(log logger.Scope, findings []check.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	if check.Blocking(findings) > 0 {
+		log.Error("Pre-push checks failed:")
+	} else {
+		log.Warning("Pre-push checks found warnings:")
+	}
+
+	current := ""
+	for _, finding := range findings {
+		if finding.Path != current {
+			current = finding.Path
+			log.Info("  %s", current)
+		}
+		message := fmt.Sprintf("    %s (%s)", finding.Message, finding.Check)
+		if finding.Line > 0 {
+			message = fmt.Sprintf("    line %d: %s (%s)", finding.Line, finding.Message, finding.Check)
+		}
+		if finding.Blocking {
+			log.Error("%s", message)
+		} else {
+			log.Warning("%s", message)
+		}
+	}
+}
+
 func init() {
 	pushCmd.Flags().BoolVarP(&force, "force", "f", false, "Force push even without changes")
+	pushCmd.Flags().StringArrayVar(&vaults, "vault", nil, "Vault to push (repeatable, default vault by default)")
+	pushCmd.Flags().BoolVar(&allVaults, "all", false, "Push every configured vault")
+	pushCmd.Flags().StringVarP(&message, "message", "m", "", "Commit message (replaces the generated message)")
+	pushCmd.Flags().BoolVarP(&editMessage, "edit", "e", false, "Edit the commit message in the default editor before committing")
+	pushCmd.Flags().BoolVar(&noVerify, "no-verify", false, "Skip the pre-push checks")
 }
 
 // GetCommand returns the push command for root command integration
@@ -0,0 +1,114 @@
+package restore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/coyls/obs-cli/cmd/history"
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vault string
+	at    string
+	force bool
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <note>",
+	Short: "Bring back an older version of a note",
+	Long: `The restore command replaces a note with the version saved in a commit or at a
+date (see 'obs-cli history'). The note keeps its current name even if it was
+renamed since. A deleted note is recreated.
+
+The restored version is not committed: check it, then run 'obs-cli push'.
+Uncommitted changes of the note are only overwritten with --force.
+
+Examples:
+  obs-cli restore Projects/Roadmap --at 3f2a9c1
+  obs-cli restore Projects/Roadmap --at "2024-05-01 18:00"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeRestore(args[0])
+	},
+}
+
+func executeRestore(arg string) error {
+	logger.PrintHeader("Restore Note")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	note, err := history.ResolveNote(cfg, vault, arg)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	versions, err := history.NoteHistory(note)
+	if err != nil {
+		return err
+	}
+
+	index, err := history.FindVersion(versions, at)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	version := versions[index]
+	content, err := history.Content(note.Repository, versions, index)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		logger.Info("Choose an earlier version with 'obs-cli history %s'", note.Name)
+		return err
+	}
+
+	if !force {
+		changes, err := note.Repository.Status()
+		if err != nil {
+			logger.Error("%s", err.Error())
+			return err
+		}
+		for _, change := range changes {
+			if change.Path == note.Path {
+				logger.Error("%s has uncommitted changes", note.Name)
+				logger.Info("Commit them with 'obs-cli push' or overwrite them with --force")
+				return fmt.Errorf("%s has uncommitted changes", note.Name)
+			}
+		}
+	}
+
+	if current, err := os.ReadFile(note.Abs); err == nil && string(current) == string(content) {
+		logger.Info("%s is already at version %s", note.Name, version.ShortHash())
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(note.Abs), 0755); err != nil {
+		logger.Error("Failed to create directory: %s", err.Error())
+		return err
+	}
+	if err := os.WriteFile(note.Abs, content, 0644); err != nil {
+		logger.Error("Failed to write note: %s", err.Error())
+		return err
+	}
+
+	logger.Success("Restored %s as of %s (%s)", note.Name, version.ShortHash(), version.Date.Local().Format("2006-01-02 15:04"))
+	logger.Info("Run 'obs-cli push' to commit it")
+	return nil
+}
+
+func init() {
+	restoreCmd.Flags().StringVar(&vault, "vault", "", "Vault of the note (default vault by default)")
+	restoreCmd.Flags().StringVar(&at, "at", "", "Version to restore: a commit or a date (YYYY-MM-DD [HH:MM])")
+	restoreCmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite uncommitted changes of the note")
+	restoreCmd.MarkFlagRequired("at")
+}
+
+// GetCommand returns the restore command for root command integration
+func GetCommand() *cobra.Command {
+	return restoreCmd
+}
@@ -0,0 +1,330 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/git"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vaults     []string
+	allVaults  bool
+	jsonOutput bool
+	fetch      bool
+	largeSize  float64
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show what push and pull would do for each vault",
+	Long: `The status command reports, for each vault:
+  - the commits ahead of and behind the remote branch, as of the last fetch
+    (--fetch updates the remote branch first)
+  - the uncommitted notes, grouped by added, modified, deleted and renamed
+  - the untracked attachments larger than --large-size, which would bloat the repository
+  - the unresolved conflicts
+
+With --json the report is printed as JSON, for scripts.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeStatus()
+	},
+}
+
+// VaultStatus est l'état d'un vault. Les chemins sont relatifs au dossier du vault.
+type VaultStatus struct {
+	Vault      string `json:"vault"`
+	Repository string `json:"repository"`
+	Remote     string `json:"remote"`
+	Branch     string `json:"branch"`
+	// CurrentBranch est la branche extraite, qui peut différer de Branch
+	CurrentBranch string `json:"current_branch"`
+	Ahead         int    `json:"ahead"`
+	Behind        int    `json:"behind"`
+	// RemoteError explique pourquoi Ahead et Behind sont inconnus
+	RemoteError string `json:"remote_error,omitempty"`
+	// InProgress vaut "merge" ou "rebase" si une intégration n'est pas terminée
+	InProgress string `json:"in_progress,omitempty"`
+
+	Added       []string     `json:"added"`
+	Modified    []string     `json:"modified"`
+	Deleted     []string     `json:"deleted"`
+	Renamed     []Rename     `json:"renamed"`
+	Large       []Attachment `json:"large_untracked"`
+	Conflicts   []string     `json:"conflicts"`
+	Uncommitted int          `json:"uncommitted"`
+}
+
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Attachment est un fichier non suivi, avec sa taille en octets
+type Attachment struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+func executeStatus() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if !jsonOutput {
+		logger.PrintHeader("Status Obsidian")
+	}
+
+	names, err := cfg.SelectVaults(vaults, allVaults)
+	if err != nil {
+		return report(err)
+	}
+	repos, err := git.Repositories(cfg, names)
+	if err != nil {
+		return report(err)
+	}
+
+	var statuses []VaultStatus
+	for _, repo := range repos {
+		repoStatuses, err := repositoryStatus(cfg, repo)
+		if err != nil {
+			return report(fmt.Errorf("%s: %w", repo.Name(), err))
+		}
+		statuses = append(statuses, repoStatuses...)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	}
+
+	for i, status := range statuses {
+		if i > 0 {
+			fmt.Println()
+		}
+		printStatus(status)
+	}
+	return nil
+}
+
+// report affiche une erreur, sauf en JSON où seule la valeur de retour la signale
+func report(err error) error {
+	if !jsonOutput {
+		logger.Error("%s", err.Error())
+	}
+	return err
+}
+
+// repositoryStatus retourne l'état des vaults d'un dépôt
+func repositoryStatus(cfg *config.Config, repo *git.Repository) ([]VaultStatus, error) {
+	base := VaultStatus{Repository: repo.Path(), Remote: repo.Remote(), Branch: repo.Branch()}
+
+	currentBranch, err := repo.GetCurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+	base.CurrentBranch = currentBranch
+	if repo.IsRebasing() {
+		base.InProgress = "rebase"
+	} else if repo.IsMerging() {
+		base.InProgress = "merge"
+	}
+
+	if fetch {
+		if !jsonOutput {
+			logger.Info("Fetching %s/%s for %s...", repo.Remote(), repo.Branch(), repo.Name())
+		}
+		err = repo.Fetch()
+	}
+	if err == nil {
+		base.Ahead, base.Behind, err = repo.AheadBehind()
+	}
+	if err != nil {
+		var gitErr *git.Error
+		if !errors.As(err, &gitErr) {
+			return nil, err
+		}
+		base.RemoteError = err.Error()
+	}
+
+	changes, err := repo.Status()
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := repo.GetConflicts()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []VaultStatus
+	for _, name := range repo.Vaults {
+		v, err := vault.Open(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		prefix, err := vaultPrefix(repo, v.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		status := base
+		status.Vault = name
+		status.Added, status.Modified, status.Deleted = []string{}, []string{}, []string{}
+		status.Renamed, status.Large, status.Conflicts = []Rename{}, []Attachment{}, []string{}
+
+		conflicted := make(map[string]bool)
+		for _, file := range conflicts {
+			conflicted[file] = true
+			if rel, ok := inVault(prefix, file); ok {
+				status.Conflicts = append(status.Conflicts, rel)
+			}
+		}
+
+		for _, change := range changes {
+			rel, ok := inVault(prefix, change.Path)
+			if !ok || conflicted[change.Path] {
+				continue
+			}
+			status.Uncommitted++
+
+			switch change.Kind() {
+			case git.ChangeCreated:
+				status.Added = append(status.Added, rel)
+				if change.Status == "??" {
+					if attachment, ok := largeAttachment(repo.Path(), change.Path); ok {
+						attachment.Path = rel
+						status.Large = append(status.Large, attachment)
+					}
+				}
+			case git.ChangeDeleted:
+				status.Deleted = append(status.Deleted, rel)
+			case git.ChangeRenamed:
+				from, _ := inVault(prefix, change.OldPath)
+				status.Renamed = append(status.Renamed, Rename{From: from, To: rel})
+			default:
+				status.Modified = append(status.Modified, rel)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// vaultPrefix retourne le dossier du vault relatif au dépôt, au format des chemins de git
+func vaultPrefix(repo *git.Repository, vaultPath string) (string, error) {
+	rel, err := filepath.Rel(repo.Path(), vaultPath)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel) + "/", nil
+}
+
+// inVault retourne le chemin relatif au vault d'un fichier du dépôt, s'il est dans le vault
+func inVault(prefix, file string) (string, bool) {
+	if !strings.HasPrefix(file, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(file, prefix), true
+}
+
+// largeAttachment indique si un fichier non suivi est une pièce jointe plus grande que --large-size
+func largeAttachment(repoPath, file string) (Attachment, bool) {
+	if strings.EqualFold(path.Ext(file), ".md") {
+		return Attachment{}, false
+	}
+	info, err := os.Stat(filepath.Join(repoPath, filepath.FromSlash(file)))
+	if err != nil || info.IsDir() || float64(info.Size()) < largeSize*1024*1024 {
+		return Attachment{}, false
+	}
+	return Attachment{Path: file, Size: info.Size()}, true
+}
+
+func printStatus(status VaultStatus) {
+	logger.Info("%s (%s)", status.Vault, status.Repository)
+
+	upstream := status.Remote + "/" + status.Branch
+	switch {
+	case status.CurrentBranch != status.Branch:
+		logger.Error("On branch %s instead of %s", status.CurrentBranch, status.Branch)
+	case status.RemoteError != "":
+		logger.Error("Cannot compare with %s: %s", upstream, status.RemoteError)
+	case status.Ahead == 0 && status.Behind == 0:
+		logger.Success("Up to date with %s", upstream)
+	default:
+		logger.Info("%d commit(s) ahead of %s, %d behind", status.Ahead, upstream, status.Behind)
+	}
+
+	switch status.InProgress {
+	case "merge":
+		logger.Error("A merge is in progress")
+	case "rebase":
+		logger.Error("A rebase is in progress")
+	}
+
+	if len(status.Conflicts) > 0 {
+		logger.Error("Unresolved conflicts (%d):", len(status.Conflicts))
+		for _, file := range status.Conflicts {
+			logger.Error("  %s", file)
+		}
+		logger.Info("Run 'obs-cli conflicts' to resolve them")
+	}
+
+	if status.Uncommitted == 0 {
+		logger.Info("Nothing to commit")
+	} else {
+		logger.Info("Uncommitted changes (%d):", status.Uncommitted)
+		printFiles("Added", "+", status.Added)
+		printFiles("Modified", "~", status.Modified)
+		printFiles("Deleted", "-", status.Deleted)
+		if len(status.Renamed) > 0 {
+			logger.Info("  Renamed:")
+			for _, rename := range status.Renamed {
+				logger.Info("    > %s -> %s", rename.From, rename.To)
+			}
+		}
+	}
+
+	if len(status.Large) > 0 {
+		logger.Error("Large untracked attachments:")
+		for _, attachment := range status.Large {
+			logger.Error("  %s (%.1f MB)", attachment.Path, float64(attachment.Size)/1024/1024)
+		}
+		logger.Info("Add them to .gitignore if they should not be pushed")
+	}
+}
+
+func printFiles(label, mark string, files []string) {
+	if len(files) == 0 {
+		return
+	}
+	logger.Info("  %s:", label)
+	for _, file := range files {
+		logger.Info("    %s %s", mark, file)
+	}
+}
+
+func init() {
+	statusCmd.Flags().StringArrayVar(&vaults, "vault", nil, "Vault to report (repeatable, default vault by default)")
+	statusCmd.Flags().BoolVar(&allVaults, "all", false, "Report every configured vault")
+	statusCmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the report as JSON")
+	statusCmd.Flags().BoolVar(&fetch, "fetch", false, "Fetch the remote branch before comparing")
+	statusCmd.Flags().Float64Var(&largeSize, "large-size", 5, "Size in MB above which an untracked attachment is reported")
+}
+
+// GetCommand returns the status command for root command integration
+func GetCommand() *cobra.Command {
+	return statusCmd
+}
@@ -0,0 +1,239 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/coyls/obs-cli/cmd/push"
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/conflict"
+	"github.com/coyls/obs-cli/internal/git"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+const (
+	ModeRebase = "rebase"
+	ModeMerge  = "merge"
+)
+
+var (
+	vaults    []string
+	allVaults bool
+	message   string
+	mode      string
+	retries   int
+	noVerify  bool
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Commit, pull and push changes in a single step",
+	Long: `The sync command commits local changes, fetches the remote branch, rebases
+the local commits onto it (or merges it), resolves conflicts with the strategies
+of 'git.conflicts' and pushes.
+
+If the remote branch moves between the fetch and the push, the cycle is retried.
+Conflicts that cannot be resolved automatically are left for 'obs-cli conflicts';
+run 'obs-cli sync' again once they are resolved.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeSync()
+	},
+}
+
+func executeSync() error {
+	logger.PrintHeader("Sync Obsidian")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if retries < 0 {
+		return fmt.Errorf("--retries cannot be negative")
+	}
+
+	names, err := cfg.SelectVaults(vaults, allVaults)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	repos, err := git.Repositories(cfg, names)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	results := git.RunAll(repos, func(repo *git.Repository, log logger.Scope) (string, error) {
+		return syncRepository(cfg, repo, log)
+	})
+	if err := git.PrintSummary(results); err != nil {
+		return err
+	}
+
+	logger.Success("Synchronization completed!")
+	return nil
+}
+
+// syncMode retourne le mode d'intégration : --mode, puis la configuration du vault, puis rebase
+func syncMode(repo *git.Repository) (string, error) {
+	selected := mode
+	if selected == "" {
+		selected = repo.Config.SyncMode
+	}
+	switch selected {
+	case "":
+		return ModeRebase, nil
+	case ModeRebase, ModeMerge:
+		return selected, nil
+	}
+	return "", fmt.Errorf("unknown sync mode '%s' (expected %s or %s)", selected, ModeRebase, ModeMerge)
+}
+
+// syncRepository commite, intègre les changements distants et pousse un dépôt
+func syncRepository(cfg *config.Config, repo *git.Repository, log logger.Scope) (string, error) {
+	integration, err := syncMode(repo)
+	if err != nil {
+		log.Error("%s", err.Error())
+		return "", err
+	}
+	resolver, err := conflict.NewResolver(repo.Git, repo.Config.Conflicts)
+	if err != nil {
+		log.Error("%s", err.Error())
+		return "", err
+	}
+
+	currentBranch, err := repo.GetCurrentBranch()
+	if err != nil {
+		log.Error("%s", err.Error())
+		return "", err
+	}
+	if currentBranch != repo.Branch() {
+		log.Error("You are not on the %s branch (current branch: %s)", repo.Branch(), currentBranch)
+		return "", fmt.Errorf("not on the %s branch (current branch: %s)", repo.Branch(), currentBranch)
+	}
+	if repo.IsMerging() || repo.IsRebasing() {
+		log.Error("A merge or rebase is in progress")
+		log.Info("Run 'obs-cli conflicts' to finish it")
+		return "", fmt.Errorf("a merge or rebase is in progress")
+	}
+
+	if noVerify {
+		log.Info("Skipping pre-push checks")
+	} else {
+		log.Info("Running pre-push checks...")
+		if err := push.Verify(cfg, repo, log); err != nil {
+			return "", err
+		}
+	}
+
+	log.Info("Committing local changes...")
+	count, err := repo.CommitChanges(message, nil)
+	if err != nil {
+		log.Error("%s", err.Error())
+		return "", err
+	}
+	if count > 0 {
+		log.Success("Commit created (%d file(s))", count)
+	}
+
+	resolved := 0
+	for attempt := 0; ; attempt++ {
+		log.Info("Fetching %s/%s...", repo.Remote(), repo.Branch())
+		if err := repo.Fetch(); err != nil {
+			git.LogError(log, err)
+			return "", err
+		}
+
+		n, err := integrate(repo, resolver, integration, log)
+		resolved += n
+		if err != nil {
+			return "", err
+		}
+
+		log.Info("Pushing to %s/%s...", repo.Remote(), repo.Branch())
+		err = repo.Push()
+		if errors.Is(err, git.ErrNonFastForward) && attempt < retries {
+			log.Info("The remote branch has new commits, retrying (%d/%d)...", attempt+1, retries)
+			continue
+		}
+		if err != nil {
+			git.LogError(log, err)
+			return "", err
+		}
+		break
+	}
+	log.Success("Sync successful!")
+
+	summary := fmt.Sprintf("%d file(s) committed, in sync with %s/%s", count, repo.Remote(), repo.Branch())
+	if resolved > 0 {
+		summary += fmt.Sprintf(", %d conflict(s) resolved", resolved)
+	}
+	return summary, nil
+}
+
+// integrate rebase les commits locaux sur la branche distante ou la fusionne, en résolvant les
+// conflits avec les stratégies configurées. Il retourne le nombre de conflits résolus.
+func integrate(repo *git.Repository, resolver *conflict.Resolver, integration string, log logger.Scope) (int, error) {
+	var err error
+	if integration == ModeMerge {
+		log.Info("Merging %s/%s...", repo.Remote(), repo.Branch())
+		err = repo.Merge()
+	} else {
+		log.Info("Rebasing onto %s/%s...", repo.Remote(), repo.Branch())
+		err = repo.Rebase()
+	}
+
+	resolvedCount := 0
+	// Un rebase s'arrête sur chaque commit local en conflit
+	for err != nil {
+		if !errors.Is(err, git.ErrConflict) {
+			git.LogError(log, err)
+			return resolvedCount, err
+		}
+		conflicts, conflictErr := repo.GetConflicts()
+		if conflictErr != nil || len(conflicts) == 0 {
+			git.LogError(log, err)
+			return resolvedCount, err
+		}
+
+		log.Error("Conflicts detected!")
+		resolved, remaining, resolveErr := resolver.ResolveAll(conflicts)
+		for _, file := range conflicts {
+			if strategy, ok := resolved[file]; ok {
+				log.Success("  - %s (%s)", file, strategy)
+			}
+		}
+		resolvedCount += len(resolved)
+		if resolveErr != nil {
+			log.Error("%s", resolveErr.Error())
+			return resolvedCount, resolveErr
+		}
+
+		if len(remaining) > 0 {
+			log.Info("Conflicting files:")
+			for _, file := range remaining {
+				log.Info("  - %s", file)
+			}
+			log.Info("Run 'obs-cli conflicts' to resolve them, then 'obs-cli sync' again")
+			return resolvedCount, fmt.Errorf("%d conflict(s) to resolve", len(remaining))
+		}
+
+		err = repo.Continue()
+	}
+	return resolvedCount, nil
+}
+
+func init() {
+	syncCmd.Flags().StringArrayVar(&vaults, "vault", nil, "Vault to sync (repeatable, default vault by default)")
+	syncCmd.Flags().BoolVar(&allVaults, "all", false, "Sync every configured vault")
+	syncCmd.Flags().StringVarP(&message, "message", "m", "", "Commit message (replaces the generated message)")
+	syncCmd.Flags().StringVar(&mode, "mode", "", "How to integrate remote changes: rebase or merge (default from configuration, or rebase)")
+	syncCmd.Flags().BoolVar(&noVerify, "no-verify", false, "Skip the pre-push checks")
+	syncCmd.Flags().IntVar(&retries, "retries", 3, "Number of retries when the remote branch moves before the push")
+}
+
+// GetCommand returns the sync command for root command integration
+func GetCommand() *cobra.Command {
+	return syncCmd
+}
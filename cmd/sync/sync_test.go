@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/git"
+	"github.com/coyls/obs-cli/internal/git/gittest"
+	"github.com/coyls/obs-cli/internal/logger"
+)
+
+// newRepository crée un dépôt sur un faux backend, sans vérifications avant le push
+func newRepository(t *testing.T, backend *gittest.Backend, gitConfig config.GitConfig) (*config.Config, *git.Repository) {
+	t.Helper()
+	noVerify = true
+	t.Cleanup(func() { noVerify = false })
+
+	return &config.Config{}, &git.Repository{
+		Git:    git.NewWithBackend(t.TempDir(), git.Options{}, backend),
+		Vaults: []string{"Notes"},
+		Config: gitConfig,
+	}
+}
+
+func TestSyncRetriesOnNonFastForward(t *testing.T) {
+	moved := func() error { return gittest.Error("pushing to origin", git.ErrNonFastForward) }
+
+	tests := []struct {
+		name    string
+		retries int
+		push    []error
+		wantErr error
+		// fetches est le nombre de cycles fetch, intégration et push
+		fetches int
+	}{
+		{name: "in sync", retries: 3, fetches: 1},
+		{name: "remote moved once", retries: 3, push: []error{moved()}, fetches: 2},
+		{name: "remote moved on every retry", retries: 2, push: []error{moved(), moved(), moved()}, wantErr: git.ErrNonFastForward, fetches: 3},
+		{name: "no retry", retries: 0, push: []error{moved()}, wantErr: git.ErrNonFastForward, fetches: 1},
+		{name: "other error", retries: 3, push: []error{gittest.Error("pushing to origin", git.ErrAuth)}, wantErr: git.ErrAuth, fetches: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retries = tt.retries
+			t.Cleanup(func() { retries = 3 })
+
+			backend := &gittest.Backend{
+				Changes: []git.Change{{Status: "M ", Path: "Notes/Note.md"}},
+				Errors:  map[string][]error{"Push": tt.push},
+			}
+			cfg, repo := newRepository(t, backend, config.GitConfig{})
+
+			_, err := syncRepository(cfg, repo, logger.Scope{})
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("syncRepository error = %v, want %v", err, tt.wantErr)
+			}
+			if got := backend.Count("Commit"); got != 1 {
+				t.Errorf("%d commits, want 1", got)
+			}
+			for _, call := range []string{"Fetch", "Rebase", "Push"} {
+				if got := backend.Count(call); got != tt.fetches {
+					t.Errorf("%s called %d times, want %d", call, got, tt.fetches)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncResolvesConflicts(t *testing.T) {
+	conflict := func() error { return gittest.Error("rebasing onto origin/main", git.ErrConflict) }
+
+	tests := []struct {
+		name     string
+		mode     string
+		strategy string
+		errors   map[string][]error
+		wantErr  bool
+		// continues est le nombre d'appels à Continue
+		continues int
+		content   string
+	}{
+		{
+			name:      "rebase resolved",
+			mode:      ModeRebase,
+			strategy:  "theirs",
+			errors:    map[string][]error{"Rebase": {conflict()}},
+			continues: 1,
+			content:   "remote\n",
+		},
+		{
+			name:      "merge resolved",
+			mode:      ModeMerge,
+			strategy:  "ours",
+			errors:    map[string][]error{"Merge": {conflict()}},
+			continues: 1,
+			content:   "local\n",
+		},
+		{
+			name:      "conflict on the next commit",
+			mode:      ModeRebase,
+			strategy:  "theirs",
+			errors:    map[string][]error{"Rebase": {conflict()}, "Continue": {conflict()}},
+			continues: 2,
+			content:   "remote\n",
+		},
+		{
+			name:      "manual resolution",
+			mode:      ModeRebase,
+			errors:    map[string][]error{"Rebase": {conflict()}},
+			wantErr:   true,
+			continues: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &gittest.Backend{
+				Conflicted: []string{"Note.md"},
+				Versions:   map[string]git.Versions{"Note.md": {Base: []byte("base\n"), Ours: []byte("local\n"), Theirs: []byte("remote\n")}},
+				Errors:     tt.errors,
+			}
+			cfg, repo := newRepository(t, backend, config.GitConfig{
+				SyncMode:  tt.mode,
+				Conflicts: config.ConflictConfig{Strategy: tt.strategy},
+			})
+
+			_, err := syncRepository(cfg, repo, logger.Scope{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("syncRepository error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := backend.Count("Continue"); got != tt.continues {
+				t.Errorf("Continue called %d times, want %d", got, tt.continues)
+			}
+			if tt.wantErr {
+				if backend.Count("Push") != 0 {
+					t.Error("pushed with unresolved conflicts")
+				}
+				return
+			}
+
+			data, err := os.ReadFile(filepath.Join(repo.Path(), "Note.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.content {
+				t.Errorf("Note.md = %q, want %q", data, tt.content)
+			}
+			if backend.Count("Add Note.md") == 0 || backend.Count("Push") != 1 {
+				t.Errorf("unexpected calls %v", backend.Calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package watch
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processExists envoie le signal 0, qui vérifie l'existence du processus sans l'interrompre
+func processExists(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
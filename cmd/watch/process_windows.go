@@ -0,0 +1,15 @@
+//go:build windows
+
+package watch
+
+import "os"
+
+// processExists ouvre le processus : sous Windows, FindProcess échoue s'il n'existe plus
+func processExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
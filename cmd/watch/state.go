@@ -0,0 +1,128 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const stateName = "watch.json"
+
+// State est le fichier de verrou du watcher : il contient son pid et l'état de chaque dépôt,
+// lus par 'watch status'
+type State struct {
+	PID          int               `json:"pid"`
+	Started      time.Time         `json:"started"`
+	Delay        string            `json:"delay"`
+	Push         bool              `json:"push"`
+	Repositories []RepositoryState `json:"repositories"`
+}
+
+// RepositoryState est le résultat du dernier enregistrement automatique d'un dépôt
+type RepositoryState struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Pending   bool      `json:"pending"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+	LastCount int       `json:"last_count"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// statePath retourne le chemin du fichier de verrou, dans le dossier de cache de l'utilisateur
+func statePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(dir, "obs-cli", stateName), nil
+}
+
+// readState lit le fichier de verrou. Il retourne nil s'il n'existe pas.
+func readState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid lock file %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// running indique si le processus du watcher existe encore
+func (s *State) running() bool {
+	return s.PID > 0 && processExists(s.PID)
+}
+
+// lockGrace est la durée pendant laquelle un verrou illisible est considéré comme en cours
+// d'écriture par un autre watcher plutôt qu'abandonné
+const lockGrace = 5 * time.Second
+
+// lock crée le fichier de verrou. Il échoue si un autre watcher est en cours ;
+// un verrou laissé par un watcher arrêté brutalement est remplacé.
+func lock(path string, state *State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	for {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			// L'état est écrit dans le fichier créé, jamais laissé vide après un rename
+			err = json.NewEncoder(file).Encode(state)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+			}
+			return err
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+
+		existing, readErr := readState(path)
+		if readErr == nil && existing != nil && existing.running() {
+			return fmt.Errorf("watch is already running (pid %d)", existing.PID)
+		}
+		if readErr != nil || existing == nil {
+			// Un verrou vide ou incomplet est peut-être en cours d'écriture par un autre watcher
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < lockGrace {
+				return fmt.Errorf("watch is starting in another process (lock file %s)", path)
+			}
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+}
+
+// writeState remplace le fichier de verrou de façon atomique
+func writeState(path string, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// unlock supprime le fichier de verrou s'il appartient encore à ce processus
+func unlock(path string) error {
+	state, err := readState(path)
+	if err != nil || state == nil || state.PID != os.Getpid() {
+		return err
+	}
+	return os.Remove(path)
+}
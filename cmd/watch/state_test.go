@@ -0,0 +1,54 @@
+package watch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	tests := []struct {
+		name string
+		// existing est le contenu du verrou avant l'appel, absent si vide
+		existing string
+		// age vieillit le verrou existant
+		age     time.Duration
+		wantErr bool
+	}{
+		{name: "no lock"},
+		{name: "running watcher", existing: fmt.Sprintf(`{"pid": %d}`, os.Getpid()), wantErr: true},
+		{name: "stopped watcher", existing: `{"pid": 0}`},
+		{name: "lock being written", existing: " ", wantErr: true},
+		{name: "abandoned empty lock", existing: " ", age: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), stateName)
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+				modTime := time.Now().Add(-tt.age)
+				if err := os.Chtimes(path, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := lock(path, &State{PID: os.Getpid(), Delay: "1m"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lock error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			state, err := readState(path)
+			if err != nil || state == nil || state.PID != os.Getpid() || state.Delay != "1m" {
+				t.Errorf("lock file state = %+v, %v", state, err)
+			}
+		})
+	}
+}
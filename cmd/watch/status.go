@@ -0,0 +1,70 @@
+package watch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var StatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the watcher is running and the last commit of each repository",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStatus()
+	},
+}
+
+func init() {
+	WatchCmd.AddCommand(StatusCmd)
+}
+
+func runStatus() error {
+	logger.PrintHeader("Watch Status")
+
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	state, err := readState(path)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	if state == nil {
+		logger.Info("watch is not running")
+		return nil
+	}
+	if !state.running() {
+		logger.Info("watch is not running (stale lock file left by pid %d: %s)", state.PID, path)
+		return nil
+	}
+
+	mode := "commit and push"
+	if !state.Push {
+		mode = "commit only"
+	}
+	logger.Success("watch is running (pid %d) since %s", state.PID, state.Started.Format(time.DateTime))
+	logger.Info("Delay: %s, %s", state.Delay, mode)
+
+	for _, repo := range state.Repositories {
+		fmt.Println()
+		logger.Info("%s (%s)", repo.Name, repo.Path)
+		if repo.Pending {
+			logger.Info("  Changes pending")
+		}
+		switch {
+		case repo.LastRun.IsZero():
+			logger.Info("  Nothing committed yet")
+		case repo.LastError != "":
+			logger.Error("  %s: %s", repo.LastRun.Format(time.DateTime), repo.LastError)
+		case repo.LastCount == 0:
+			logger.Info("  %s: no changes to commit", repo.LastRun.Format(time.DateTime))
+		default:
+			logger.Success("  %s: %d file(s) committed", repo.LastRun.Format(time.DateTime), repo.LastCount)
+		}
+	}
+	return nil
+}
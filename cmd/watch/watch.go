@@ -0,0 +1,309 @@
+package watch
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/coyls/obs-cli/cmd/push"
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/git"
+	"github.com/coyls/obs-cli/internal/logger"
+	"github.com/coyls/obs-cli/internal/vault"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vaults    []string
+	allVaults bool
+	delay     time.Duration
+	noPush    bool
+)
+
+var WatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Commit and push changes automatically while the vaults are edited",
+	Long: `The watch command monitors the vault directories and, once no file has
+changed for --delay, commits the changes of each repository and pushes them, as
+'obs-cli push' does. Files that fail the pre-push checks (see 'obs-cli push')
+block the commit.
+
+Only one watcher runs at a time: its pid and state are kept in a lock file in the
+user cache directory, read by 'obs-cli watch status'. On SIGINT or SIGTERM,
+pending changes are committed and pushed before exiting.
+
+To keep it running in the background, start it with nohup, a systemd user
+service or a launchd agent.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executeWatch()
+	},
+}
+
+// watcher enregistre les dépôts dont les vaults ont changé, après un délai sans modification
+type watcher struct {
+	cfg       *config.Config
+	fs        *fsnotify.Watcher
+	statePath string
+	state     *State
+
+	// dirs associe chaque dossier surveillé à son dépôt
+	dirs map[string]*git.Repository
+	// pending contient les dépôts modifiés depuis leur dernier enregistrement
+	pending map[*git.Repository]bool
+	timers  map[*git.Repository]*time.Timer
+	ready   chan *git.Repository
+	index   map[*git.Repository]int
+}
+
+func executeWatch() error {
+	logger.PrintHeader("Watch Obsidian")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if delay <= 0 {
+		return fmt.Errorf("--delay must be positive")
+	}
+
+	names, err := cfg.SelectVaults(vaults, allVaults)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	repos, err := git.Repositories(cfg, names)
+	if err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	state := &State{PID: os.Getpid(), Started: time.Now(), Delay: delay.String(), Push: !noPush}
+	for _, repo := range repos {
+		state.Repositories = append(state.Repositories, RepositoryState{Name: repo.Name(), Path: repo.Path()})
+	}
+	if err := lock(path, state); err != nil {
+		logger.Error("%s", err.Error())
+		return err
+	}
+	defer func() {
+		if err := unlock(path); err != nil {
+			logger.Error("Failed to remove lock file: %s", err.Error())
+		}
+	}()
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watcher: %w", err)
+	}
+	defer fsWatcher.Close()
+
+	w := &watcher{
+		cfg:       cfg,
+		fs:        fsWatcher,
+		statePath: path,
+		state:     state,
+		dirs:      make(map[string]*git.Repository),
+		pending:   make(map[*git.Repository]bool),
+		timers:    make(map[*git.Repository]*time.Timer),
+		ready:     make(chan *git.Repository, len(repos)),
+		index:     make(map[*git.Repository]int),
+	}
+	for i, repo := range repos {
+		w.index[repo] = i
+		for _, name := range repo.Vaults {
+			v, err := vault.Open(cfg, name)
+			if err != nil {
+				logger.Error("%s", err.Error())
+				return err
+			}
+			if err := w.addTree(v.Path, repo); err != nil {
+				logger.Error("Failed to watch %s: %s", v.Path, err.Error())
+				return err
+			}
+		}
+		logger.Info("Watching %s (%s)", repo.Name(), repo.Path())
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if noPush {
+		logger.Info("Changes will be committed %s after the last edit", delay)
+	} else {
+		logger.Info("Changes will be committed and pushed %s after the last edit", delay)
+	}
+	return w.run(signals)
+}
+
+// run traite les événements jusqu'à la réception d'un signal
+func (w *watcher) run(signals <-chan os.Signal) error {
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return nil
+			}
+			w.handle(event)
+
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Error("Watcher error: %s", err.Error())
+
+		case repo := <-w.ready:
+			if w.pending[repo] {
+				delete(w.pending, repo)
+				w.save(repo)
+			}
+
+		case sig := <-signals:
+			logger.Info("Received %s, stopping...", sig)
+			for repo, timer := range w.timers {
+				timer.Stop()
+				if w.pending[repo] {
+					delete(w.pending, repo)
+					w.save(repo)
+				}
+			}
+			logger.Success("Watch stopped")
+			return nil
+		}
+	}
+}
+
+// handle relance le délai du dépôt concerné par un événement
+func (w *watcher) handle(event fsnotify.Event) {
+	if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) {
+		return
+	}
+	if filepath.Base(event.Name) == ".git" {
+		return
+	}
+
+	repo, ok := w.dirs[filepath.Dir(event.Name)]
+	if !ok {
+		return
+	}
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addTree(event.Name, repo); err != nil {
+				logger.Error("Failed to watch %s: %s", event.Name, err.Error())
+			}
+		}
+	}
+	if event.Has(fsnotify.Remove | fsnotify.Rename) {
+		delete(w.dirs, event.Name)
+	}
+
+	w.schedule(repo)
+}
+
+// schedule enregistre le dépôt après le délai, repoussé à chaque modification
+func (w *watcher) schedule(repo *git.Repository) {
+	if !w.pending[repo] {
+		w.pending[repo] = true
+		w.setState(repo, func(s *RepositoryState) { s.Pending = true })
+	}
+	if timer, ok := w.timers[repo]; ok {
+		timer.Reset(delay)
+		return
+	}
+	w.timers[repo] = time.AfterFunc(delay, func() { w.ready <- repo })
+}
+
+// addTree surveille dir et ses sous-dossiers, sauf les dossiers .git
+func (w *watcher) addTree(dir string, repo *git.Repository) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if _, ok := w.dirs[path]; ok {
+			return nil
+		}
+		if err := w.fs.Add(path); err != nil {
+			return err
+		}
+		w.dirs[path] = repo
+		return nil
+	})
+}
+
+// save commite et pousse les changements d'un dépôt
+func (w *watcher) save(repo *git.Repository) {
+	log := logger.NewScope(repo.Name())
+	count, err := w.commitAndPush(repo, log)
+
+	w.setState(repo, func(s *RepositoryState) {
+		s.Pending = false
+		s.LastRun = time.Now()
+		s.LastCount = count
+		s.LastError = ""
+		if err != nil {
+			s.LastError = err.Error()
+		}
+	})
+}
+
+func (w *watcher) commitAndPush(repo *git.Repository, log logger.Scope) (int, error) {
+	if err := push.Verify(w.cfg, repo, log); err != nil {
+		return 0, err
+	}
+
+	count, err := repo.CommitChanges("", nil)
+	if err != nil {
+		log.Error("%s", err.Error())
+		return 0, err
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	log.Success("%s: commit created (%d file(s))", time.Now().Format(time.TimeOnly), count)
+
+	if noPush {
+		return count, nil
+	}
+	if err := repo.Push(); err != nil {
+		git.LogError(log, err)
+		return count, err
+	}
+	log.Success("Pushed to %s/%s", repo.Remote(), repo.Branch())
+	return count, nil
+}
+
+// setState met à jour l'état d'un dépôt dans le fichier de verrou
+func (w *watcher) setState(repo *git.Repository, update func(*RepositoryState)) {
+	update(&w.state.Repositories[w.index[repo]])
+	if err := writeState(w.statePath, w.state); err != nil {
+		logger.Error("Failed to update lock file: %s", err.Error())
+	}
+}
+
+func init() {
+	WatchCmd.Flags().StringArrayVar(&vaults, "vault", nil, "Vault to watch (repeatable, default vault by default)")
+	WatchCmd.Flags().BoolVar(&allVaults, "all", false, "Watch every configured vault")
+	WatchCmd.Flags().DurationVar(&delay, "delay", 30*time.Second, "Time without changes before committing")
+	WatchCmd.Flags().BoolVar(&noPush, "no-push", false, "Commit without pushing")
+}
+
+// GetCommand returns the watch command for root command integration
+func GetCommand() *cobra.Command {
+	return WatchCmd
+}
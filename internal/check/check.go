@@ -0,0 +1,232 @@
+package check
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/ignore"
+	"github.com/coyls/obs-cli/internal/link"
+)
+
+// Noms des vérifications, dans l'ordre où elles sont exécutées
+const (
+	Size      = "size"
+	Private   = "private"
+	Secrets   = "secrets"
+	Conflicts = "conflicts"
+	Links     = "links"
+)
+
+var Names = []string{Size, Private, Secrets, Conflicts, Links}
+
+// DefaultMaxSizeMB est la taille maximale d'un fichier si 'max_size_mb' n'est pas défini
+const DefaultMaxSizeMB = 20
+
+// Finding est un problème trouvé dans un fichier
+type Finding struct {
+	Check string
+	// Path est le chemin du fichier dans le dépôt
+	Path string
+	// Line est le numéro de la ligne concernée, ou zéro
+	Line    int
+	Message string
+	// Blocking est vrai pour une erreur qui empêche le commit, faux pour un avertissement
+	Blocking bool
+}
+
+// Pipeline vérifie les fichiers d'un dépôt avant qu'ils soient commités
+type Pipeline struct {
+	repoPath string
+	vaults   []string
+	enabled  map[string]bool
+	maxSize  int64
+	secrets  []secretPattern
+	exclude  *ignore.Matcher
+	// indexes contient les fichiers de chaque vault, pour résoudre les liens
+	indexes map[string]*link.Index
+}
+
+// New crée les vérifications d'un dépôt. vaults sont les dossiers des vaults qu'il contient.
+func New(repoPath string, vaults []string, cfg config.CheckConfig) (*Pipeline, error) {
+	p := &Pipeline{
+		repoPath: repoPath,
+		vaults:   vaults,
+		enabled:  make(map[string]bool),
+		maxSize:  int64(DefaultMaxSizeMB * 1024 * 1024),
+		secrets:  defaultSecrets,
+		indexes:  make(map[string]*link.Index),
+	}
+
+	for _, name := range Names {
+		p.enabled[name] = true
+	}
+	for _, name := range cfg.Skip {
+		if _, ok := p.enabled[name]; !ok {
+			return nil, fmt.Errorf("unknown check '%s' in git.checks.skip (expected one of %s)", name, strings.Join(Names, ", "))
+		}
+		// Une note privée poussée en clair ne peut plus être retirée de l'historique distant
+		if name == Private {
+			return nil, fmt.Errorf("check '%s' cannot be disabled in git.checks.skip (use --no-verify for one push)", Private)
+		}
+		p.enabled[name] = false
+	}
+
+	if cfg.MaxSizeMB < 0 {
+		return nil, fmt.Errorf("git.checks.max_size_mb cannot be negative")
+	}
+	if cfg.MaxSizeMB > 0 {
+		p.maxSize = int64(cfg.MaxSizeMB * 1024 * 1024)
+	}
+
+	for _, pattern := range cfg.SecretPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern '%s': %w", pattern, err)
+		}
+		p.secrets = append(p.secrets, secretPattern{name: "secret matching '" + pattern + "'", re: re})
+	}
+
+	if len(cfg.Exclude) > 0 {
+		matcher, err := ignore.New(repoPath, cfg.Exclude, nil)
+		if err != nil {
+			return nil, err
+		}
+		p.exclude = matcher
+	}
+	return p, nil
+}
+
+// Run vérifie les fichiers (chemins relatifs au dépôt) et retourne les problèmes trouvés,
+// triés par fichier. Les fichiers absents ou exclus sont ignorés.
+func (p *Pipeline) Run(paths []string) ([]Finding, error) {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+
+	var findings []Finding
+	for _, rel := range sorted {
+		note := strings.EqualFold(path.Ext(rel), ".md")
+		// Les notes exclues sont toujours vérifiées : exclude ne désactive pas la vérification private
+		excluded := p.exclude != nil && p.exclude.Match(rel, false)
+		if excluded && !note {
+			continue
+		}
+		abs := filepath.Join(p.repoPath, filepath.FromSlash(rel))
+		info, err := os.Lstat(abs)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		if !excluded && p.enabled[Size] && info.Size() > p.maxSize {
+			findings = append(findings, Finding{
+				Check:    Size,
+				Path:     rel,
+				Message:  fmt.Sprintf("file is %.1f MB, above the limit of %.1f MB", megabytes(info.Size()), megabytes(p.maxSize)),
+				Blocking: true,
+			})
+			continue
+		}
+
+		content, err := os.ReadFile(abs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		// Les fichiers binaires (pièces jointes) ne sont pas analysés
+		if bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+			continue
+		}
+
+		encrypted := note && crypt.IsEncrypted(content)
+		if p.enabled[Private] && note && !encrypted && crypt.IsPrivate(content) {
+			message := "private note is not encrypted (run 'obs-cli encrypt')"
+			if excluded {
+				message += "; git.checks.exclude does not apply to private notes"
+			}
+			findings = append(findings, Finding{
+				Check:    Private,
+				Path:     rel,
+				Message:  message,
+				Blocking: true,
+			})
+		}
+		if excluded {
+			continue
+		}
+		if p.enabled[Secrets] && !encrypted {
+			findings = append(findings, p.findSecrets(rel, content)...)
+		}
+		if p.enabled[Conflicts] {
+			findings = append(findings, findMarkers(rel, content)...)
+		}
+		if p.enabled[Links] && note {
+			linkFindings, err := p.findBrokenLinks(rel, abs, content)
+			if err != nil {
+				return nil, err
+			}
+			findings = append(findings, linkFindings...)
+		}
+	}
+	return findings, nil
+}
+
+// Blocking compte les problèmes qui empêchent le commit
+func Blocking(findings []Finding) int {
+	count := 0
+	for _, finding := range findings {
+		if finding.Blocking {
+			count++
+		}
+	}
+	return count
+}
+
+func megabytes(size int64) float64 {
+	return float64(size) / 1024 / 1024
+}
+
+// lines appelle fn pour chaque ligne du contenu, numérotées à partir de 1
+func lines(content []byte, fn func(number int, line string)) {
+	for i, line := range strings.Split(string(content), "\n") {
+		fn(i+1, strings.TrimSuffix(line, "\r"))
+	}
+}
+
+var markerPattern = regexp.MustCompile(`^(<<<<<<<|>>>>>>>)( |$)`)
+
+// findMarkers signale les marqueurs de conflit laissés dans un fichier, par conflit
+func findMarkers(rel string, content []byte) []Finding {
+	var findings []Finding
+	open := false
+	lines(content, func(number int, line string) {
+		match := markerPattern.FindStringSubmatch(line)
+		if match == nil {
+			return
+		}
+		if match[1] == "<<<<<<<" || !open {
+			findings = append(findings, Finding{
+				Check:    Conflicts,
+				Path:     rel,
+				Line:     number,
+				Message:  "unresolved conflict marker",
+				Blocking: true,
+			})
+		}
+		open = match[1] == "<<<<<<<"
+	})
+	return findings
+}
@@ -0,0 +1,70 @@
+package check
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/link"
+)
+
+// findBrokenLinks signale les liens [[note]] d'une note qui ne mènent à aucun fichier du vault.
+// Ce ne sont que des avertissements : la note liée peut ne pas encore exister.
+func (p *Pipeline) findBrokenLinks(rel, abs string, content []byte) ([]Finding, error) {
+	vault := p.vaultOf(abs)
+	if vault == "" {
+		return nil, nil
+	}
+	index, err := p.index(vault)
+	if err != nil {
+		return nil, err
+	}
+	source, err := filepath.Rel(vault, abs)
+	if err != nil {
+		return nil, err
+	}
+	source = filepath.ToSlash(source)
+
+	var findings []Finding
+	for _, l := range link.Parse(content) {
+		if l.Markdown || l.Target == "" {
+			continue
+		}
+		if _, ok := index.Resolve(source, l.Target); ok {
+			continue
+		}
+		findings = append(findings, Finding{
+			Check:   Links,
+			Path:    rel,
+			Line:    l.Line,
+			Message: fmt.Sprintf("broken link [[%s]]", l.Target),
+		})
+	}
+	return findings, nil
+}
+
+// vaultOf retourne le dossier du vault qui contient abs
+func (p *Pipeline) vaultOf(abs string) string {
+	for _, vault := range p.vaults {
+		rel, err := filepath.Rel(vault, abs)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return vault
+		}
+	}
+	return ""
+}
+
+// index liste les fichiers du vault, sans les dossiers cachés (.obsidian, .trash...)
+func (p *Pipeline) index(vault string) (*link.Index, error) {
+	if index, ok := p.indexes[vault]; ok {
+		return index, nil
+	}
+
+	files, err := link.Files(vault)
+	if err != nil {
+		return nil, err
+	}
+	index := link.NewIndex(files)
+	p.indexes[vault] = index
+	return index, nil
+}
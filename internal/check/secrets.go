@@ -0,0 +1,58 @@
+package check
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// secretPattern reconnaît un type de secret
+type secretPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// defaultSecrets sont les formats de clés les plus courants
+var defaultSecrets = []secretPattern{
+	{"private key", regexp.MustCompile(`-----BEGIN (RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Stripe secret key", regexp.MustCompile(`\b[sr]k_live_[0-9A-Za-z]{24,}\b`)},
+	{"API secret key", regexp.MustCompile(`\bsk-(proj-|ant-)?[A-Za-z0-9_-]{32,}\b`)},
+	{"password or token", regexp.MustCompile(`(?i)\b(api[_-]?key|secret|token|passw(or)?d)\b["']?\s*[:=]\s*["']?([A-Za-z0-9/+_.=-]{16,})`)},
+}
+
+// findSecrets signale les lignes qui contiennent un secret. Le secret n'est pas affiché.
+func (p *Pipeline) findSecrets(rel string, content []byte) []Finding {
+	var findings []Finding
+	lines(content, func(number int, line string) {
+		for _, secret := range p.secrets {
+			match := secret.re.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			// Une affectation n'est un secret que si la valeur mélange lettres et chiffres
+			if secret.name == "password or token" && !mixed(match[len(match)-1]) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Check:    Secrets,
+				Path:     rel,
+				Line:     number,
+				Message:  fmt.Sprintf("possible %s", secret.name),
+				Blocking: true,
+			})
+			return
+		}
+	})
+	return findings
+}
+
+func mixed(value string) bool {
+	return strings.ContainsAny(value, "0123456789") && strings.IndexFunc(value, func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+	}) >= 0
+}
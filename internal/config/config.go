@@ -3,13 +3,63 @@ package config
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/spf13/viper"
 )
 
+// GitConfig configure le dépôt git d'un vault. Les valeurs vides utilisent les valeurs par défaut.
+type GitConfig struct {
+	// Path est le dépôt du vault, relatif à root. Détecté à partir du dossier du vault s'il est vide.
+	Path   string `mapstructure:"path"`
+	Remote string `mapstructure:"remote"`
+	Branch string `mapstructure:"branch"`
+	// Message est un modèle text/template (voir internal/git)
+	Message   string         `mapstructure:"message"`
+	Conflicts ConflictConfig `mapstructure:"conflicts"`
+	// SyncMode est "rebase" (par défaut) ou "merge", pour intégrer les changements distants avec sync
+	SyncMode string `mapstructure:"sync_mode"`
+	// Backend est "cli" (commande git, par défaut) ou "go" (go-git, sans commande git)
+	Backend string      `mapstructure:"backend"`
+	Checks  CheckConfig `mapstructure:"checks"`
+}
+
+// CheckConfig règle les vérifications faites avant de commiter (voir internal/check)
+type CheckConfig struct {
+	// Skip liste les vérifications désactivées : size, secrets, conflicts, links (private ne peut pas l'être)
+	Skip []string `mapstructure:"skip"`
+	// MaxSizeMB est la taille maximale d'un fichier, 20 Mo par défaut
+	MaxSizeMB float64 `mapstructure:"max_size_mb"`
+	// SecretPatterns sont des expressions régulières de secrets, en plus de celles par défaut
+	SecretPatterns []string `mapstructure:"secret_patterns"`
+	// Exclude sont des motifs au format .gitignore, relatifs au dépôt, des fichiers non vérifiés.
+	// Les notes exclues restent soumises à la vérification private.
+	Exclude []string `mapstructure:"exclude"`
+}
+
+// IsZero indique si aucune vérification n'est configurée
+func (c CheckConfig) IsZero() bool {
+	return len(c.Skip) == 0 && c.MaxSizeMB == 0 && len(c.SecretPatterns) == 0 && len(c.Exclude) == 0
+}
+
+// ConflictConfig choisit comment pull résout les conflits (voir internal/conflict).
+// La première règle dont le motif correspond l'emporte sur la stratégie par défaut.
+type ConflictConfig struct {
+	Strategy string         `mapstructure:"strategy"`
+	Rules    []ConflictRule `mapstructure:"rules"`
+}
+
+// ConflictRule applique une stratégie aux fichiers correspondant à un motif au format .gitignore,
+// relatif au dépôt
+type ConflictRule struct {
+	Pattern  string `mapstructure:"pattern"`
+	Strategy string `mapstructure:"strategy"`
+}
+
 type VaultConfig struct {
-	VaultPath string `mapstructure:"vault_path"`
+	VaultPath string    `mapstructure:"vault_path"`
+	Git       GitConfig `mapstructure:"git"`
 	Commands  struct {
 		Cp struct {
 			DefaultTargetPath string `mapstructure:"default_target_path"`
@@ -20,6 +70,47 @@ type VaultConfig struct {
 	} `mapstructure:"commands"`
 }
 
+// RetentionConfig définit combien de générations de sauvegardes conserver (schéma GFS).
+// Si tout est à zéro, seule la dernière sauvegarde est conservée.
+type RetentionConfig struct {
+	Daily   int `mapstructure:"daily"`
+	Weekly  int `mapstructure:"weekly"`
+	Monthly int `mapstructure:"monthly"`
+}
+
+// EncryptionConfig active le chiffrement des sauvegardes au format age.
+// Sans destinataire, les sauvegardes sont chiffrées avec une passphrase.
+type EncryptionConfig struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	Recipients   []string `mapstructure:"recipients"`
+	IdentityFile string   `mapstructure:"identity_file"`
+}
+
+// DestinationConfig décrit un emplacement de sauvegarde : dossier local, serveur SFTP
+// ou stockage objet compatible S3
+type DestinationConfig struct {
+	Name string `mapstructure:"name"`
+	Type string `mapstructure:"type"`
+	Path string `mapstructure:"path"`
+
+	// SFTP
+	Host       string `mapstructure:"host"`
+	Port       int    `mapstructure:"port"`
+	User       string `mapstructure:"user"`
+	Password   string `mapstructure:"password"`
+	KeyFile    string `mapstructure:"key_file"`
+	KnownHosts string `mapstructure:"known_hosts"`
+
+	// S3
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	Prefix    string `mapstructure:"prefix"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	PathStyle bool   `mapstructure:"path_style"`
+}
+
 type Config struct {
 	Config struct {
 		DefaultEditor string                  `mapstructure:"default_editor"`
@@ -27,8 +118,19 @@ type Config struct {
 		Root          string                  `mapstructure:"root"`
 		Vaults        map[string]*VaultConfig `mapstructure:"vaults"`
 		Archive       struct {
-			UsbPath     string `mapstructure:"usb_path"`
-			ExtractPath string `mapstructure:"extract_path"`
+			UsbPath      string              `mapstructure:"usb_path"`
+			ExtractPath  string              `mapstructure:"extract_path"`
+			Mode         string              `mapstructure:"mode"`
+			Format       string              `mapstructure:"format"`
+			Level        int                 `mapstructure:"level"`
+			Portable     bool                `mapstructure:"portable"`
+			Retention    RetentionConfig     `mapstructure:"retention"`
+			Encryption   EncryptionConfig    `mapstructure:"encryption"`
+			Destinations []DestinationConfig `mapstructure:"destinations"`
+			// Motifs au format .gitignore, relatifs au dossier racine des vaults
+			Exclude       []string `mapstructure:"exclude"`
+			Include       []string `mapstructure:"include"`
+			SkipGitignore bool     `mapstructure:"skip_gitignore"`
 		} `mapstructure:"archive"`
 	} `mapstructure:"config"`
 }
@@ -93,6 +195,68 @@ func (c *Config) GetVaultConfig(vaultName string) (*VaultConfig, bool) {
 	return nil, false
 }
 
+// Editor retourne l'éditeur configuré, puis $EDITOR, puis nano
+func (c *Config) Editor() string {
+	if c.Config.DefaultEditor != "" {
+		return c.Config.DefaultEditor
+	}
+	if envEditor := os.Getenv("EDITOR"); envEditor != "" {
+		return envEditor
+	}
+	return "nano"
+}
+
+// VaultNames retourne les noms des vaults configurés, triés
+func (c *Config) VaultNames() []string {
+	names := make([]string, 0, len(c.Config.Vaults))
+	for name := range c.Config.Vaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectVaults retourne les vaults demandés sous leur nom configuré : tous avec all,
+// ceux de names, ou à défaut le vault par défaut
+func (c *Config) SelectVaults(names []string, all bool) ([]string, error) {
+	if all {
+		if len(names) > 0 {
+			return nil, fmt.Errorf("--vault and --all cannot be used together")
+		}
+		return c.VaultNames(), nil
+	}
+	if len(names) == 0 {
+		names = []string{c.Config.DefaultVault}
+	}
+
+	var selected []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		key, exists := c.vaultKey(name)
+		if !exists {
+			return nil, fmt.Errorf("vault '%s' not found in configuration", name)
+		}
+		if !seen[key] {
+			seen[key] = true
+			selected = append(selected, key)
+		}
+	}
+	return selected, nil
+}
+
+// vaultKey retourne le nom configuré d'un vault, sans tenir compte de la casse
+func (c *Config) vaultKey(vaultName string) (string, bool) {
+	if _, exists := c.Config.Vaults[vaultName]; exists {
+		return vaultName, true
+	}
+	for key := range c.Config.Vaults {
+		if strings.EqualFold(key, vaultName) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
 // func debugConfig(cfg *Config) {
 // 	vaultConfig, exists := cfg.GetVaultConfig("Coyls")
 // 	fmt.Printf("Config for 'Coyls': %+v (exists: %v)\n", vaultConfig, exists)
@@ -0,0 +1,225 @@
+package conflict
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/git"
+	"github.com/coyls/obs-cli/internal/ignore"
+)
+
+const (
+	// StrategyManual laisse le conflit à résoudre avec 'obs-cli conflicts'
+	StrategyManual = "manual"
+	// StrategyKeepBoth garde la version distante et copie la version locale à côté
+	StrategyKeepBoth = "keep-both"
+	// StrategyUnion garde les lignes des deux versions, pour les notes où l'on ne fait qu'ajouter
+	StrategyUnion = "union"
+	// StrategyMerge fusionne le corps ligne à ligne et le frontmatter propriété par propriété
+	StrategyMerge = "merge"
+	// StrategyOurs garde la version locale
+	StrategyOurs = "ours"
+	// StrategyTheirs garde la version distante
+	StrategyTheirs = "theirs"
+)
+
+// Strategies liste les stratégies acceptées dans la configuration
+var Strategies = []string{StrategyManual, StrategyKeepBoth, StrategyUnion, StrategyMerge, StrategyOurs, StrategyTheirs}
+
+// ValidStrategy indique si name est une stratégie connue
+func ValidStrategy(name string) bool {
+	for _, strategy := range Strategies {
+		if name == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+type rule struct {
+	matcher  *ignore.Matcher
+	strategy string
+}
+
+// Resolver résout les fichiers en conflit d'un dépôt selon la configuration du vault
+type Resolver struct {
+	repo     *git.Git
+	strategy string
+	rules    []rule
+	host     string
+}
+
+// NewResolver prépare les règles de résolution des conflits de repo. Sans configuration, la stratégie est manuelle.
+func NewResolver(repo *git.Git, cfg config.ConflictConfig) (*Resolver, error) {
+	r := &Resolver{repo: repo, strategy: cfg.Strategy}
+	if r.strategy == "" {
+		r.strategy = StrategyManual
+	}
+	if !ValidStrategy(r.strategy) {
+		return nil, fmt.Errorf("unknown conflict strategy '%s' (expected %s)", r.strategy, strings.Join(Strategies, ", "))
+	}
+
+	for _, c := range cfg.Rules {
+		if !ValidStrategy(c.Strategy) {
+			return nil, fmt.Errorf("unknown conflict strategy '%s' for '%s' (expected %s)", c.Strategy, c.Pattern, strings.Join(Strategies, ", "))
+		}
+		// Un matcher sans fichier de règles ne sert qu'à la syntaxe .gitignore du motif
+		matcher, err := ignore.New(repo.Path(), []string{c.Pattern}, nil)
+		if err != nil {
+			return nil, err
+		}
+		r.rules = append(r.rules, rule{matcher: matcher, strategy: c.Strategy})
+	}
+
+	host, _ := os.Hostname()
+	r.host = hostPattern.ReplaceAllString(host, "-")
+	if r.host == "" {
+		r.host = "local"
+	}
+	return r, nil
+}
+
+var hostPattern = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Strategy retourne la stratégie configurée pour un chemin relatif au dépôt
+func (r *Resolver) Strategy(rel string) string {
+	for _, c := range r.rules {
+		if c.matcher.Match(rel, false) {
+			return c.strategy
+		}
+	}
+	return r.strategy
+}
+
+// Resolve applique une stratégie à un fichier en conflit et le marque comme résolu.
+// resolved est faux si la stratégie ne s'applique pas ou si la fusion reste en conflit :
+// le fichier est alors laissé tel quel.
+func (r *Resolver) Resolve(rel, strategy string) (resolved bool, err error) {
+	if strategy == StrategyManual {
+		return false, nil
+	}
+
+	versions, err := r.repo.ConflictVersions(rel)
+	if err != nil {
+		return false, err
+	}
+	if versions.Ours == nil && versions.Theirs == nil {
+		return false, fmt.Errorf("no conflicting version of %s in the index", rel)
+	}
+	file := filepath.Join(r.repo.Path(), filepath.FromSlash(rel))
+
+	// Fichier supprimé d'un côté et modifié de l'autre : la version modifiée est conservée,
+	// sauf si ours ou theirs désigne explicitement la suppression
+	if versions.Ours == nil || versions.Theirs == nil {
+		keep := versions.Ours
+		if keep == nil {
+			keep = versions.Theirs
+		}
+		switch strategy {
+		case StrategyOurs:
+			keep = versions.Ours
+		case StrategyTheirs:
+			keep = versions.Theirs
+		}
+		if keep == nil {
+			return true, r.repo.Remove(rel)
+		}
+		return true, r.write(file, rel, keep)
+	}
+
+	switch strategy {
+	case StrategyOurs:
+		return true, r.write(file, rel, versions.Ours)
+	case StrategyTheirs:
+		return true, r.write(file, rel, versions.Theirs)
+	case StrategyKeepBoth:
+		return true, r.keepBoth(file, rel, versions)
+	}
+
+	// union et merge ne s'appliquent qu'aux notes
+	if !isNote(rel) {
+		return false, nil
+	}
+
+	var merged []byte
+	var conflicts bool
+	if strategy == StrategyUnion {
+		merged, conflicts, err = git.MergeFile(versions.Base, versions.Ours, versions.Theirs, true)
+	} else {
+		merged, conflicts, err = mergeNote(versions.Base, versions.Ours, versions.Theirs)
+	}
+	if err != nil || conflicts {
+		return false, err
+	}
+	return true, r.write(file, rel, merged)
+}
+
+// keepBoth garde la version distante sous le nom d'origine et la version locale dans
+// une copie nommée d'après la machine, comme le font les outils de synchronisation
+func (r *Resolver) keepBoth(file, rel string, versions git.Versions) error {
+	ext := path.Ext(rel)
+	stem := strings.TrimSuffix(rel, ext)
+
+	copyRel := stem + ".conflict-" + r.host + ext
+	for i := 2; exists(filepath.Join(r.repo.Path(), filepath.FromSlash(copyRel))); i++ {
+		copyRel = stem + ".conflict-" + r.host + "-" + strconv.Itoa(i) + ext
+	}
+
+	copyFile := filepath.Join(r.repo.Path(), filepath.FromSlash(copyRel))
+	if err := r.write(copyFile, copyRel, versions.Ours); err != nil {
+		return err
+	}
+	return r.write(file, rel, versions.Theirs)
+}
+
+// write remplace le contenu du fichier et l'ajoute à l'index
+func (r *Resolver) write(file, rel string, content []byte) error {
+	if err := crypt.WriteFileAtomic(file, content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return r.repo.Add(rel)
+}
+
+// ResolveAll applique la stratégie configurée à chaque fichier. Il retourne la stratégie
+// utilisée pour les fichiers résolus et la liste des fichiers restant en conflit.
+func (r *Resolver) ResolveAll(paths []string) (resolved map[string]string, remaining []string, err error) {
+	resolved = make(map[string]string)
+	for _, rel := range paths {
+		strategy := r.Strategy(rel)
+		ok, err := r.Resolve(rel, strategy)
+		if err != nil {
+			return resolved, nil, err
+		}
+		if ok {
+			resolved[rel] = strategy
+		} else {
+			remaining = append(remaining, rel)
+		}
+	}
+	return resolved, remaining, nil
+}
+
+// HasMarkers indique si un contenu contient encore des marqueurs de conflit
+func HasMarkers(content []byte) bool {
+	return markerPattern.Match(content)
+}
+
+var markerPattern = regexp.MustCompile(`(?m)^(<<<<<<<|>>>>>>>)( |$)`)
+
+func isNote(rel string) bool {
+	return strings.EqualFold(path.Ext(rel), ".md")
+}
+
+func exists(file string) bool {
+	_, err := os.Lstat(file)
+	return !errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,81 @@
+package conflict
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/git"
+	"github.com/coyls/obs-cli/internal/git/gittest"
+)
+
+func TestResolveNonASCII(t *testing.T) {
+	name := "Journée.md"
+	tests := []struct {
+		name     string
+		strategy string
+		versions map[string]git.Versions
+		wantErr  bool
+		// files est le contenu attendu des fichiers du dépôt après la résolution
+		files map[string]string
+	}{
+		{
+			name:     "keep both",
+			strategy: StrategyKeepBoth,
+			versions: map[string]git.Versions{name: {Base: []byte("base\n"), Ours: []byte("local\n"), Theirs: []byte("remote\n")}},
+			files:    map[string]string{name: "remote\n", "Journée.conflict-test.md": "local\n"},
+		},
+		{
+			name:     "theirs",
+			strategy: StrategyTheirs,
+			versions: map[string]git.Versions{name: {Base: []byte("base\n"), Ours: []byte("local\n"), Theirs: []byte("remote\n")}},
+			files:    map[string]string{name: "remote\n"},
+		},
+		{
+			name:     "no version in the index",
+			strategy: StrategyKeepBoth,
+			wantErr:  true,
+			files:    map[string]string{name: "<<<<<<< local\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, name), []byte("<<<<<<< local\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			backend := &gittest.Backend{Conflicted: []string{name}, Versions: tt.versions}
+			r, err := NewResolver(git.NewWithBackend(dir, git.Options{}, backend), config.ConflictConfig{Strategy: tt.strategy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.host = "test"
+
+			resolved, remaining, err := r.ResolveAll([]string{name})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveAll error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (resolved[name] != tt.strategy || len(remaining) > 0) {
+				t.Errorf("resolved %v, remaining %v", resolved, remaining)
+			}
+			if backend.Count("Remove") > 0 {
+				t.Errorf("conflicted file removed: %v", backend.Calls)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != len(tt.files) {
+				t.Errorf("%d files in the repository, want %d", len(entries), len(tt.files))
+			}
+			for file, content := range tt.files {
+				data, err := os.ReadFile(filepath.Join(dir, file))
+				if err != nil {
+					t.Errorf("%s: %v", file, err)
+				} else if string(data) != content {
+					t.Errorf("%s = %q, want %q", file, data, content)
+				}
+			}
+		})
+	}
+}
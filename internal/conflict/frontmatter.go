@@ -0,0 +1,194 @@
+package conflict
+
+import (
+	"bytes"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/git"
+)
+
+// mergeNote fusionne trois versions d'une note. Le corps est fusionné ligne à ligne ; le
+// frontmatter propriété par propriété, pour que deux modifications de propriétés différentes
+// (ou l'ajout de tags des deux côtés) ne produisent pas de conflit.
+func mergeNote(base, ours, theirs []byte) ([]byte, bool, error) {
+	baseFront, baseBody := crypt.SplitFrontmatter(base)
+	oursFront, oursBody := crypt.SplitFrontmatter(ours)
+	theirsFront, theirsBody := crypt.SplitFrontmatter(theirs)
+
+	body, conflicts, err := git.MergeFile(baseBody, oursBody, theirsBody, false)
+	if err != nil || conflicts {
+		return nil, conflicts, err
+	}
+
+	front, ok := mergeFrontmatter(baseFront, oursFront, theirsFront)
+	if !ok {
+		// Frontmatter illisible ou propriété modifiée des deux côtés : fusion ligne à ligne
+		var conflicts bool
+		front, conflicts, err = git.MergeFile(baseFront, oursFront, theirsFront, false)
+		if err != nil || conflicts {
+			return nil, conflicts, err
+		}
+	}
+
+	return append(front, body...), false, nil
+}
+
+// mergeFrontmatter fusionne trois frontmatters (délimiteurs inclus). ok est faux si une
+// propriété a été modifiée différemment des deux côtés.
+func mergeFrontmatter(base, ours, theirs []byte) ([]byte, bool) {
+	switch {
+	case bytes.Equal(ours, theirs), bytes.Equal(theirs, base):
+		return ours, true
+	case bytes.Equal(ours, base):
+		return theirs, true
+	}
+
+	baseMap, ok1 := parseFrontmatter(base)
+	oursMap, ok2 := parseFrontmatter(ours)
+	theirsMap, ok3 := parseFrontmatter(theirs)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, false
+	}
+
+	// Les propriétés gardent l'ordre de la version locale, suivies des nouvelles propriétés distantes
+	var keys []string
+	seen := make(map[string]bool)
+	for _, m := range []*mapping{oursMap, theirsMap} {
+		for _, key := range m.keys {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	result := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, key := range keys {
+		value, ok := mergeValue(baseMap.values[key], oursMap.values[key], theirsMap.values[key])
+		if !ok {
+			return nil, false
+		}
+		if value == nil {
+			continue
+		}
+		result.Content = append(result.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	if len(result.Content) > 0 {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(result); err != nil {
+			return nil, false
+		}
+		enc.Close()
+	}
+	buf.WriteString("---\n")
+	return buf.Bytes(), true
+}
+
+// mergeValue fusionne une propriété. nil signifie que la propriété est absente ou supprimée.
+func mergeValue(base, ours, theirs *yaml.Node) (*yaml.Node, bool) {
+	switch {
+	case equalNodes(ours, theirs), equalNodes(theirs, base):
+		return ours, true
+	case equalNodes(ours, base):
+		return theirs, true
+	}
+
+	// Listes (tags, aliases...) modifiées des deux côtés : union des éléments, sans ceux
+	// qu'un des côtés a retirés
+	if ours == nil || theirs == nil || ours.Kind != yaml.SequenceNode || theirs.Kind != yaml.SequenceNode {
+		return nil, false
+	}
+	if base != nil && base.Kind != yaml.SequenceNode {
+		return nil, false
+	}
+
+	inBase := itemSet(base)
+	inOurs := itemSet(ours)
+	inTheirs := itemSet(theirs)
+
+	merged := *ours
+	merged.Content = nil
+	added := make(map[string]bool)
+	for _, item := range append(append([]*yaml.Node{}, ours.Content...), theirs.Content...) {
+		key := encodeNode(item)
+		removed := inBase[key] && (!inOurs[key] || !inTheirs[key])
+		if removed || added[key] {
+			continue
+		}
+		added[key] = true
+		merged.Content = append(merged.Content, item)
+	}
+	return &merged, true
+}
+
+// mapping est un frontmatter dont l'ordre des propriétés est conservé
+type mapping struct {
+	keys   []string
+	values map[string]*yaml.Node
+}
+
+func parseFrontmatter(front []byte) (*mapping, bool) {
+	m := &mapping{values: make(map[string]*yaml.Node)}
+	if front == nil {
+		return m, true
+	}
+
+	inner := bytes.TrimSpace(front)
+	inner = bytes.TrimSuffix(bytes.TrimPrefix(inner, []byte("---")), []byte("---"))
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(inner, &doc); err != nil {
+		return nil, false
+	}
+	if len(doc.Content) == 0 {
+		return m, true
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i].Value
+		if _, dup := m.values[key]; !dup {
+			m.keys = append(m.keys, key)
+		}
+		m.values[key] = root.Content[i+1]
+	}
+	return m, true
+}
+
+func itemSet(seq *yaml.Node) map[string]bool {
+	set := make(map[string]bool)
+	if seq != nil {
+		for _, item := range seq.Content {
+			set[encodeNode(item)] = true
+		}
+	}
+	return set
+}
+
+func equalNodes(a, b *yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return encodeNode(a) == encodeNode(b)
+}
+
+// encodeNode retourne la forme canonique d'une valeur, sans style ni commentaire
+func encodeNode(n *yaml.Node) string {
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return n.Value
+	}
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return n.Value
+	}
+	return string(out)
+}
@@ -0,0 +1,234 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	BeginMarker = "-----BEGIN OBS-CLI ENCRYPTED NOTE-----"
+	EndMarker   = "-----END OBS-CLI ENCRYPTED NOTE-----"
+
+	PrivateTag = "private"
+
+	lineWidth = 64
+)
+
+var (
+	privateTagRegex = regexp.MustCompile(`(?:^|\s)#` + PrivateTag + `(?:/[\w/-]*)?(?:[^\w/-]|$)`)
+	fencedCodeRegex = regexp.MustCompile("(?ms)^(```|~~~).*?^(```|~~~)")
+	inlineCodeRegex = regexp.MustCompile("`[^`\n]*`")
+)
+
+// SplitFrontmatter sépare le frontmatter YAML (délimiteurs inclus) du corps de la note
+func SplitFrontmatter(content []byte) (front, body []byte) {
+	if !bytes.HasPrefix(content, []byte("---\n")) && !bytes.HasPrefix(content, []byte("---\r\n")) {
+		return nil, content
+	}
+
+	rest := content[bytes.IndexByte(content, '\n')+1:]
+	offset := len(content) - len(rest)
+	for len(rest) > 0 {
+		lineEnd := bytes.IndexByte(rest, '\n')
+		line := rest
+		if lineEnd >= 0 {
+			line = rest[:lineEnd]
+		}
+		if string(bytes.TrimRight(line, "\r")) == "---" {
+			end := offset + len(line)
+			if lineEnd >= 0 {
+				end++
+			}
+			return content[:end], content[end:]
+		}
+		if lineEnd < 0 {
+			break
+		}
+		offset += lineEnd + 1
+		rest = rest[lineEnd+1:]
+	}
+
+	return nil, content
+}
+
+// IsEncrypted indique si le corps de la note a été chiffré par obs-cli
+func IsEncrypted(content []byte) bool {
+	_, body := SplitFrontmatter(content)
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte(BeginMarker))
+}
+
+// IsPrivate indique si la note porte le tag #private (frontmatter ou corps) ou `private: true`
+func IsPrivate(content []byte) bool {
+	front, body := SplitFrontmatter(content)
+
+	if front != nil {
+		var meta map[string]any
+		inner := bytes.TrimSuffix(bytes.TrimSpace(front), []byte("---"))
+		inner = bytes.TrimPrefix(inner, []byte("---"))
+		if err := yaml.Unmarshal(inner, &meta); err == nil {
+			if isTrue(meta[PrivateTag]) || hasPrivateTag(meta["tags"]) || hasPrivateTag(meta["tag"]) {
+				return true
+			}
+		}
+	}
+
+	text := fencedCodeRegex.ReplaceAll(body, nil)
+	text = inlineCodeRegex.ReplaceAll(text, nil)
+	return privateTagRegex.Match(text)
+}
+
+func isTrue(v any) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case string:
+		return strings.EqualFold(val, "true") || strings.EqualFold(val, "yes")
+	}
+	return false
+}
+
+func hasPrivateTag(v any) bool {
+	match := func(tag string) bool {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		return tag == PrivateTag || strings.HasPrefix(tag, PrivateTag+"/")
+	}
+
+	switch val := v.(type) {
+	case string:
+		for _, tag := range strings.FieldsFunc(val, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if match(tag) {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range val {
+			if tag, ok := item.(string); ok && match(tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EncryptNote chiffre le corps de la note en laissant le frontmatter lisible
+func EncryptNote(content []byte, c *Cipher) ([]byte, error) {
+	if IsEncrypted(content) {
+		return nil, fmt.Errorf("note is already encrypted")
+	}
+
+	front, body := SplitFrontmatter(content)
+	sealed, err := c.Encrypt(body)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+
+	var buf bytes.Buffer
+	buf.Write(front)
+	buf.WriteString(BeginMarker + "\n")
+	for len(encoded) > lineWidth {
+		buf.WriteString(encoded[:lineWidth] + "\n")
+		encoded = encoded[lineWidth:]
+	}
+	if encoded != "" {
+		buf.WriteString(encoded + "\n")
+	}
+	buf.WriteString(EndMarker + "\n")
+
+	return buf.Bytes(), nil
+}
+
+// DecryptNote restaure le corps original d'une note chiffrée par EncryptNote
+func DecryptNote(content []byte, c *Cipher) ([]byte, error) {
+	front, body := SplitFrontmatter(content)
+
+	block := strings.TrimSpace(string(body))
+	if !strings.HasPrefix(block, BeginMarker) || !strings.HasSuffix(block, EndMarker) {
+		return nil, fmt.Errorf("note is not encrypted")
+	}
+	block = strings.TrimSuffix(strings.TrimPrefix(block, BeginMarker), EndMarker)
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(block), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted block: %w", err)
+	}
+
+	plaintext, err := c.Decrypt(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]byte{}, front...), plaintext...), nil
+}
+
+// WalkNotes appelle fn pour chaque note Markdown du dossier, en ignorant
+// les dossiers cachés (.obsidian, .git, .trash...)
+func WalkNotes(dir string, fn func(path string, content []byte) error) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return fn(path, content)
+	})
+}
+
+// FindPlaintextPrivate retourne les notes privées qui ne sont pas encore chiffrées
+func FindPlaintextPrivate(dir string) ([]string, error) {
+	var notes []string
+	err := WalkNotes(dir, func(path string, content []byte) error {
+		if !IsEncrypted(content) && IsPrivate(content) {
+			notes = append(notes, path)
+		}
+		return nil
+	})
+	return notes, err
+}
+
+// WriteFileAtomic écrit le fichier via un fichier temporaire pour ne jamais laisser une note à moitié écrite
+func WriteFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,111 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// StreamMagic est l'en-tête des fichiers au format age (https://age-encryption.org)
+const StreamMagic = "age-encryption.org/v1\n"
+
+// PassphraseFunc fournit la passphrase uniquement si elle est nécessaire
+type PassphraseFunc func() (string, error)
+
+// IsEncryptedStream indique si les premiers octets d'un fichier correspondent au format age
+func IsEncryptedStream(header []byte) bool {
+	return bytes.HasPrefix(header, []byte(StreamMagic))
+}
+
+// NewEncryptWriter chiffre le flux au format age pour les destinataires donnés (clés publiques age1...).
+// Sans destinataire, le flux est chiffré avec la passphrase.
+func NewEncryptWriter(w io.Writer, recipients []string, passphrase PassphraseFunc) (io.WriteCloser, error) {
+	var list []age.Recipient
+
+	if len(recipients) > 0 {
+		parsed, err := age.ParseRecipients(strings.NewReader(strings.Join(recipients, "\n")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption recipient: %w", err)
+		}
+		list = parsed
+	} else {
+		pass, err := passphrase()
+		if err != nil {
+			return nil, err
+		}
+		recipient, err := age.NewScryptRecipient(pass)
+		if err != nil {
+			return nil, err
+		}
+		list = []age.Recipient{recipient}
+	}
+
+	return age.Encrypt(w, list...)
+}
+
+// NewDecryptReader déchiffre un flux age avec les identités du fichier donné,
+// ou avec la passphrase si le flux a été chiffré par passphrase
+func NewDecryptReader(r io.Reader, identityFile string, passphrase PassphraseFunc) (io.Reader, error) {
+	identities := []age.Identity{&lazyScryptIdentity{passphrase: passphrase}}
+
+	if identityFile != "" {
+		file, err := os.Open(identityFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open identity file: %w", err)
+		}
+		defer file.Close()
+
+		parsed, err := age.ParseIdentities(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity file %s: %w", identityFile, err)
+		}
+		identities = append(parsed, identities...)
+	}
+
+	reader, err := age.Decrypt(r, identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, fmt.Errorf("%w: no matching identity", ErrDecrypt)
+		}
+		return nil, err
+	}
+	return reader, nil
+}
+
+// lazyScryptIdentity ne demande la passphrase que si le fichier a été chiffré par passphrase
+type lazyScryptIdentity struct {
+	passphrase PassphraseFunc
+}
+
+func (l *lazyScryptIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type != "scrypt" {
+			continue
+		}
+
+		pass, err := l.passphrase()
+		if err != nil {
+			return nil, err
+		}
+		identity, err := age.NewScryptIdentity(pass)
+		if err != nil {
+			return nil, err
+		}
+		key, err := identity.Unwrap(stanzas)
+		if err != nil {
+			var noMatch *age.NoIdentityMatchError
+			if errors.As(err, &noMatch) || errors.Is(err, age.ErrIncorrectIdentity) {
+				return nil, ErrDecrypt
+			}
+			return nil, err
+		}
+		return key, nil
+	}
+	return nil, age.ErrIncorrectIdentity
+}
@@ -0,0 +1,136 @@
+package destination
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/config"
+)
+
+const (
+	TypeLocal = "local"
+	TypeSFTP  = "sftp"
+	TypeS3    = "s3"
+)
+
+// ErrNotFound est retournée quand un objet n'existe pas sur la destination
+var ErrNotFound = errors.New("object not found")
+
+// Object décrit un objet stocké sur une destination
+type Object struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Destination est un emplacement de sauvegarde. Les noms d'objets sont des chemins
+// relatifs séparés par des "/".
+type Destination interface {
+	// Name retourne le nom de la destination dans la configuration
+	Name() string
+	// Location décrit l'emplacement pour les messages (chemin, URL...)
+	Location() string
+
+	Put(name string, r io.Reader) error
+	Get(name string) (io.ReadCloser, error)
+	Exists(name string) (bool, error)
+	Stat(name string) (Object, error)
+	List(prefix string) ([]string, error)
+	Delete(name string) error
+
+	// Available retourne l'espace libre en octets, ou -1 s'il est inconnu
+	Available() (int64, error)
+	Close() error
+}
+
+// New crée la destination décrite par la configuration
+func New(cfg config.DestinationConfig) (Destination, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("destination name is required")
+	}
+
+	switch strings.ToLower(cfg.Type) {
+	case "", TypeLocal:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("destination '%s': path is required", cfg.Name)
+		}
+		return NewLocal(cfg.Name, cfg.Path), nil
+	case TypeSFTP:
+		return NewSFTP(cfg)
+	case TypeS3:
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("destination '%s': unknown type '%s' (expected %s, %s or %s)", cfg.Name, cfg.Type, TypeLocal, TypeSFTP, TypeS3)
+	}
+}
+
+// prefixed restreint une destination à un sous-dossier
+type prefixed struct {
+	Destination
+	prefix string
+}
+
+// WithPrefix retourne une destination dont tous les objets sont stockés sous prefix
+func WithPrefix(d Destination, prefix string) Destination {
+	return &prefixed{Destination: d, prefix: strings.TrimSuffix(prefix, "/") + "/"}
+}
+
+func (p *prefixed) Location() string {
+	return strings.TrimSuffix(p.Destination.Location(), "/") + "/" + strings.TrimSuffix(p.prefix, "/")
+}
+
+func (p *prefixed) Put(name string, r io.Reader) error {
+	return p.Destination.Put(p.prefix+name, r)
+}
+
+func (p *prefixed) Get(name string) (io.ReadCloser, error) {
+	return p.Destination.Get(p.prefix + name)
+}
+
+func (p *prefixed) Exists(name string) (bool, error) {
+	return p.Destination.Exists(p.prefix + name)
+}
+
+func (p *prefixed) Stat(name string) (Object, error) {
+	obj, err := p.Destination.Stat(p.prefix + name)
+	obj.Name = strings.TrimPrefix(obj.Name, p.prefix)
+	return obj, err
+}
+
+func (p *prefixed) List(prefix string) ([]string, error) {
+	names, err := p.Destination.List(p.prefix + prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		names[i] = strings.TrimPrefix(name, p.prefix)
+	}
+	return names, nil
+}
+
+func (p *prefixed) Delete(name string) error {
+	return p.Destination.Delete(p.prefix + name)
+}
+
+// sizeOf retourne la taille d'un flux s'il est possible de la connaître sans le lire
+func sizeOf(r io.Reader) (int64, bool) {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return 0, false
+	}
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, false
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return 0, false
+	}
+	return end - current, true
+}
@@ -0,0 +1,174 @@
+package destination
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Local stocke les objets dans un dossier local (clé USB, disque externe...)
+type Local struct {
+	name string
+	root string
+}
+
+func NewLocal(name, root string) *Local {
+	return &Local{name: name, root: root}
+}
+
+func (l *Local) Name() string {
+	return l.name
+}
+
+func (l *Local) Location() string {
+	return l.root
+}
+
+func (l *Local) path(name string) string {
+	return filepath.Join(l.root, filepath.FromSlash(name))
+}
+
+// Put écrit dans un fichier temporaire puis le renomme : un objet interrompu n'est jamais visible
+func (l *Local) Put(name string, r io.Reader) error {
+	target := l.path(name)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// Move renomme le fichier path, situé sur le même disque que la destination, en objet name
+func (l *Local) Move(name, path string) error {
+	target := l.path(name)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = file.Sync()
+	file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(path, target)
+}
+
+func (l *Local) Get(name string) (io.ReadCloser, error) {
+	file, err := os.Open(l.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	return file, err
+}
+
+func (l *Local) Exists(name string) (bool, error) {
+	_, err := os.Stat(l.path(name))
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (l *Local) Stat(name string) (Object, error) {
+	info, err := os.Stat(l.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Object{}, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Name: name, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// List retourne les noms des objets dont le nom commence par prefix
+func (l *Local) List(prefix string) ([]string, error) {
+	// Ne parcourir que le dossier contenant le préfixe
+	start := l.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		start = l.path(prefix[:i])
+	}
+
+	var names []string
+	err := filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			// Ignorer les dossiers qui ne peuvent pas contenir d'objet correspondant
+			dir := rel + "/"
+			if rel != "." && !strings.HasPrefix(dir, prefix) && !strings.HasPrefix(prefix, dir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		if strings.HasPrefix(rel, prefix) {
+			names = append(names, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", l.root, err)
+	}
+	return names, nil
+}
+
+func (l *Local) Delete(name string) error {
+	err := os.Remove(l.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) Available() (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(l.root, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+func (l *Local) Close() error {
+	return nil
+}
@@ -0,0 +1,424 @@
+package destination
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/config"
+)
+
+const (
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	emptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+var (
+	// Au-delà de cette taille, les objets sont envoyés en plusieurs parties
+	multipartThreshold int64 = 64 << 20
+	multipartSize            = 32 << 20
+)
+
+// S3 stocke les objets dans un bucket compatible S3 (AWS, MinIO, Garage...)
+type S3 struct {
+	name      string
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	prefix    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	client    *http.Client
+}
+
+func NewS3(cfg config.DestinationConfig) (*S3, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("destination '%s': bucket is required", cfg.Name)
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("destination '%s': invalid endpoint '%s'", cfg.Name, endpoint)
+	}
+
+	accessKey := cfg.AccessKey
+	if accessKey == "" {
+		accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	secretKey := cfg.SecretKey
+	if secretKey == "" {
+		secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("destination '%s': access_key and secret_key are required (or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)", cfg.Name)
+	}
+
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &S3{
+		name:      cfg.Name,
+		endpoint:  u,
+		region:    region,
+		bucket:    cfg.Bucket,
+		prefix:    prefix,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		pathStyle: cfg.PathStyle,
+		client:    &http.Client{},
+	}, nil
+}
+
+func (s *S3) Name() string {
+	return s.name
+}
+
+func (s *S3) Location() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.prefix)
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// request signe (AWS Signature V4) et envoie une requête sur une clé du bucket
+func (s *S3) request(method, key string, query url.Values, body io.Reader, size int64, payloadHash string) (*http.Response, error) {
+	host := s.endpoint.Host
+	uri := "/" + key
+	if s.pathStyle {
+		uri = "/" + s.bucket + uri
+	} else {
+		host = s.bucket + "." + host
+	}
+	uri = encodePath(uri)
+	rawQuery := encodeQuery(query)
+
+	target := s.endpoint.Scheme + "://" + host + uri
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	s.sign(req, uri, rawQuery, payloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var e s3Error
+		data, _ := io.ReadAll(resp.Body)
+		if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+			return nil, fmt.Errorf("%s %s: %s: %s", method, key, e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, key, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// sign ajoute à req les en-têtes de la signature AWS Signature V4 calculée à l'instant now. uri
+// et rawQuery sont le chemin et la requête encodés tels qu'ils sont envoyés.
+func (s *S3) sign(req *http.Request, uri, rawQuery, payloadHash string, now time.Time) {
+	host := req.URL.Host
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("Host", host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uri,
+		rawQuery,
+		"host:" + host + "\n" + "x-amz-content-sha256:" + payloadHash + "\n" + "x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key1 := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key2 := hmacSHA256(key1, s.region)
+	key3 := hmacSHA256(key2, "s3")
+	signingKey := hmacSHA256(key3, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// encodeURI encode selon les règles AWS : tout sauf les caractères non réservés
+func encodeURI(s string, keepSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func encodePath(p string) string {
+	return encodeURI(p, true)
+}
+
+func encodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, encodeURI(k, false)+"="+encodeURI(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func (s *S3) Put(name string, r io.Reader) error {
+	key := s.prefix + name
+
+	size, ok := sizeOf(r)
+	if !ok {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		r, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	if size > multipartThreshold {
+		return s.putMultipart(key, r)
+	}
+
+	resp, err := s.request(http.MethodPut, key, nil, r, size, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+type initiateMultipartResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+func (s *S3) putMultipart(key string, r io.Reader) error {
+	resp, err := s.request(http.MethodPost, key, url.Values{"uploads": {""}}, nil, 0, emptyPayload)
+	if err != nil {
+		return err
+	}
+	var initiated initiateMultipartResult
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("invalid multipart upload response: %w", err)
+	}
+
+	abort := func(cause error) error {
+		if resp, err := s.request(http.MethodDelete, key, url.Values{"uploadId": {initiated.UploadID}}, nil, 0, emptyPayload); err == nil {
+			resp.Body.Close()
+		}
+		return cause
+	}
+
+	var parts []completedPart
+	buf := make([]byte, multipartSize)
+	for number := 1; ; number++ {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			query := url.Values{
+				"partNumber": {strconv.Itoa(number)},
+				"uploadId":   {initiated.UploadID},
+			}
+			resp, err := s.request(http.MethodPut, key, query, bytes.NewReader(buf[:n]), int64(n), unsignedPayload)
+			if err != nil {
+				return abort(err)
+			}
+			resp.Body.Close()
+			parts = append(parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return abort(readErr)
+		}
+	}
+
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return abort(err)
+	}
+	resp, err = s.request(http.MethodPost, key, url.Values{"uploadId": {initiated.UploadID}}, bytes.NewReader(body), int64(len(body)), sha256Hex(body))
+	if err != nil {
+		return abort(err)
+	}
+	return resp.Body.Close()
+}
+
+func (s *S3) Get(name string) (io.ReadCloser, error) {
+	resp, err := s.request(http.MethodGet, s.prefix+name, nil, nil, 0, emptyPayload)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Stat(name string) (Object, error) {
+	resp, err := s.request(http.MethodHead, s.prefix+name, nil, nil, 0, emptyPayload)
+	if err != nil {
+		return Object{}, err
+	}
+	resp.Body.Close()
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Object{Name: name, Size: resp.ContentLength, ModTime: modTime}, nil
+}
+
+func (s *S3) Exists(name string) (bool, error) {
+	_, err := s.Stat(name)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3) List(prefix string) ([]string, error) {
+	var names []string
+	token := ""
+
+	for {
+		query := url.Values{
+			"list-type": {"2"},
+			"prefix":    {s.prefix + prefix},
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		// Requête sur le bucket lui-même (clé vide)
+		resp, err := s.request(http.MethodGet, "", query, nil, 0, emptyPayload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", s.Location(), err)
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid list response: %w", err)
+		}
+
+		for _, c := range result.Contents {
+			names = append(names, strings.TrimPrefix(c.Key, s.prefix))
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return names, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3) Delete(name string) error {
+	resp, err := s.request(http.MethodDelete, s.prefix+name, nil, nil, 0, emptyPayload)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Available retourne -1 : un stockage objet n'a pas de limite d'espace connue
+func (s *S3) Available() (int64, error) {
+	return -1, nil
+}
+
+func (s *S3) Close() error {
+	return nil
+}
@@ -0,0 +1,265 @@
+package destination
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/coyls/obs-cli/internal/config"
+)
+
+// SFTPPasswordEnv permet de fournir le mot de passe SFTP sans l'écrire dans la configuration
+const SFTPPasswordEnv = "OBS_CLI_SFTP_PASSWORD"
+
+// SFTP stocke les objets sur un serveur SSH. La connexion est ouverte à la création.
+type SFTP struct {
+	name   string
+	host   string
+	user   string
+	root   string
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+func NewSFTP(cfg config.DestinationConfig) (*SFTP, error) {
+	if cfg.Host == "" || cfg.Path == "" {
+		return nil, fmt.Errorf("destination '%s': host and path are required", cfg.Name)
+	}
+
+	user := cfg.User
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+
+	hostKeyCallback, err := hostKeyCallback(cfg.KnownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("destination '%s': %w", cfg.Name, err)
+	}
+
+	auth, err := sshAuthMethods(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("destination '%s': %w", cfg.Name, err)
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	conn, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("destination '%s': failed to connect to %s: %w", cfg.Name, addr, err)
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("destination '%s': failed to start SFTP session: %w", cfg.Name, err)
+	}
+
+	return &SFTP{
+		name:   cfg.Name,
+		host:   cfg.Host,
+		user:   user,
+		root:   cfg.Path,
+		conn:   conn,
+		client: client,
+	}, nil
+}
+
+func hostKeyCallback(knownHostsFile string) (ssh.HostKeyCallback, error) {
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	callback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts %s: %w", knownHostsFile, err)
+	}
+	return callback, nil
+}
+
+// sshAuthMethods utilise, dans l'ordre : la clé privée configurée, l'agent SSH et le mot de passe
+func sshAuthMethods(cfg config.DestinationConfig) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+
+	if cfg.KeyFile != "" {
+		key, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key file %s: %w", cfg.KeyFile, err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	password := cfg.Password
+	if password == "" {
+		password = os.Getenv(SFTPPasswordEnv)
+	}
+	if password != "" {
+		methods = append(methods, ssh.Password(password))
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no SSH authentication method available (key_file, ssh-agent or %s)", SFTPPasswordEnv)
+	}
+	return methods, nil
+}
+
+func (s *SFTP) Name() string {
+	return s.name
+}
+
+func (s *SFTP) Location() string {
+	return fmt.Sprintf("sftp://%s@%s%s", s.user, s.host, s.root)
+}
+
+func (s *SFTP) path(name string) string {
+	return path.Join(s.root, name)
+}
+
+// Put écrit dans un fichier temporaire puis le renomme : un objet interrompu n'est jamais visible
+func (s *SFTP) Put(name string, r io.Reader) error {
+	target := s.path(name)
+	if err := s.client.MkdirAll(path.Dir(target)); err != nil {
+		return err
+	}
+
+	tmp := path.Join(path.Dir(target), ".tmp-"+path.Base(target))
+	file, err := s.client.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.ReadFrom(r); err != nil {
+		file.Close()
+		s.client.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		s.client.Remove(tmp)
+		return err
+	}
+
+	if err := s.client.PosixRename(tmp, target); err != nil {
+		// Serveurs sans l'extension posix-rename : le renommage simple échoue si la cible existe
+		s.client.Remove(target)
+		if err := s.client.Rename(tmp, target); err != nil {
+			s.client.Remove(tmp)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SFTP) Get(name string) (io.ReadCloser, error) {
+	file, err := s.client.Open(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	return file, err
+}
+
+func (s *SFTP) Exists(name string) (bool, error) {
+	_, err := s.client.Stat(s.path(name))
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s *SFTP) Stat(name string) (Object, error) {
+	info, err := s.client.Stat(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Object{}, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Name: name, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (s *SFTP) List(prefix string) ([]string, error) {
+	var names []string
+
+	walker := s.client.Walk(s.root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list %s: %w", s.Location(), err)
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), s.root), "/")
+		if walker.Stat().IsDir() {
+			dir := rel + "/"
+			if rel != "" && !strings.HasPrefix(dir, prefix) && !strings.HasPrefix(prefix, dir) {
+				walker.SkipDir()
+			}
+			continue
+		}
+		if strings.HasPrefix(path.Base(rel), ".tmp-") {
+			continue
+		}
+		if strings.HasPrefix(rel, prefix) {
+			names = append(names, rel)
+		}
+	}
+	return names, nil
+}
+
+func (s *SFTP) Delete(name string) error {
+	err := s.client.Remove(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *SFTP) Available() (int64, error) {
+	stat, err := s.client.StatVFS(s.root)
+	if err != nil {
+		// Extension statvfs non supportée par le serveur
+		return -1, nil
+	}
+	return int64(stat.Bavail * stat.Frsize), nil
+}
+
+func (s *SFTP) Close() error {
+	s.client.Close()
+	return s.conn.Close()
+}
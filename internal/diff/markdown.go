@@ -0,0 +1,318 @@
+package diff
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/coyls/obs-cli/internal/crypt"
+)
+
+// Types de modification d'une propriété du frontmatter
+const (
+	PropertyAdded   = "added"
+	PropertyRemoved = "removed"
+	PropertyChanged = "changed"
+)
+
+// Property est une propriété du frontmatter ajoutée, supprimée ou modifiée
+type Property struct {
+	Key  string
+	Kind string
+	Old  string
+	New  string
+}
+
+// Line est une ligne d'un bloc de différences. Une ligne modifiée (Op vaut Insert et Words
+// n'est pas vide) regroupe l'ancienne et la nouvelle version, mot à mot.
+type Line struct {
+	Op    Op
+	Text  string
+	Words []Edit
+}
+
+// Hunk est un bloc de lignes modifiées et leur contexte. Line est le numéro de la première
+// ligne dans la nouvelle version, Heading le titre Markdown de la section qui la contient.
+type Hunk struct {
+	Line    int
+	Heading string
+	Lines   []Line
+}
+
+// Note est la différence entre deux versions d'une note
+type Note struct {
+	Properties []Property
+	Hunks      []Hunk
+}
+
+// Empty indique si les deux versions sont identiques
+func (n Note) Empty() bool {
+	return len(n.Properties) == 0 && len(n.Hunks) == 0
+}
+
+// Notes compare deux versions d'une note : le frontmatter propriété par propriété et le corps
+// ligne à ligne, avec context lignes inchangées autour des modifications
+func Notes(old, new []byte, context int) Note {
+	var note Note
+	oldFront, oldBody := crypt.SplitFrontmatter(old)
+	newFront, newBody := crypt.SplitFrontmatter(new)
+
+	properties, ok := diffFrontmatter(oldFront, newFront)
+	if ok {
+		note.Properties = properties
+	} else {
+		// Frontmatter illisible : il est comparé ligne à ligne avec le corps
+		oldBody, newBody = old, new
+	}
+
+	note.Hunks = hunks(splitLines(oldBody), splitLines(newBody), context)
+	return note
+}
+
+func splitLines(content []byte) []string {
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffFrontmatter compare deux frontmatters (délimiteurs inclus). ok est faux si l'un n'est pas lisible.
+func diffFrontmatter(old, new []byte) ([]Property, bool) {
+	if bytes.Equal(old, new) {
+		return nil, true
+	}
+	oldKeys, oldValues, ok1 := parseProperties(old)
+	newKeys, newValues, ok2 := parseProperties(new)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+
+	var properties []Property
+	for _, key := range newKeys {
+		oldValue, exists := oldValues[key]
+		switch {
+		case !exists:
+			properties = append(properties, Property{Key: key, Kind: PropertyAdded, New: newValues[key]})
+		case oldValue != newValues[key]:
+			properties = append(properties, Property{Key: key, Kind: PropertyChanged, Old: oldValue, New: newValues[key]})
+		}
+	}
+	for _, key := range oldKeys {
+		if _, exists := newValues[key]; !exists {
+			properties = append(properties, Property{Key: key, Kind: PropertyRemoved, Old: oldValues[key]})
+		}
+	}
+	return properties, true
+}
+
+// parseProperties retourne les propriétés d'un frontmatter dans leur ordre, avec leur valeur affichable
+func parseProperties(front []byte) ([]string, map[string]string, bool) {
+	values := make(map[string]string)
+	if len(front) == 0 {
+		return nil, values, true
+	}
+
+	content := bytes.TrimPrefix(bytes.TrimSpace(front), []byte("---"))
+	content = bytes.TrimSuffix(content, []byte("---"))
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, nil, false
+	}
+	if len(doc.Content) == 0 {
+		return nil, values, true
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, nil, false
+	}
+
+	var keys []string
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i].Value
+		keys = append(keys, key)
+		values[key] = formatValue(mapping.Content[i+1])
+	}
+	return keys, values, true
+}
+
+// formatValue affiche une valeur sur une ligne : les listes de scalaires sont écrites [a, b]
+func formatValue(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return marshal(node)
+			}
+			items = append(items, item.Value)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return marshal(node)
+}
+
+func marshal(node *yaml.Node) string {
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(string(out)), " ")
+}
+
+// hunks regroupe les modifications séparées par moins de 2*context lignes inchangées
+func hunks(a, b []string, context int) []Hunk {
+	edits := Diff(a, b)
+
+	// Numéro de ligne de chaque modification dans la nouvelle version
+	lineNumbers := make([]int, len(edits))
+	line := 1
+	for i, edit := range edits {
+		lineNumbers[i] = line
+		if edit.Op != Delete {
+			line++
+		}
+	}
+
+	var result []Hunk
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			i++
+			continue
+		}
+
+		start := i
+		for start > 0 && i-start < context && edits[start-1].Op == Equal {
+			start--
+		}
+		end := i
+		for end < len(edits) {
+			if edits[end].Op != Equal {
+				end++
+				continue
+			}
+			equal := end
+			for equal < len(edits) && edits[equal].Op == Equal {
+				equal++
+			}
+			if equal == len(edits) || equal-end > 2*context {
+				end += min(context, equal-end)
+				break
+			}
+			end = equal
+		}
+
+		result = append(result, Hunk{
+			Line:    lineNumbers[start],
+			Heading: heading(b, lineNumbers[start]-1),
+			Lines:   pairLines(edits[start:end]),
+		})
+		i = end
+	}
+	return result
+}
+
+// heading retourne le titre Markdown le plus proche avant la ligne index, hors blocs de code
+func heading(lines []string, index int) string {
+	current := ""
+	inCode := false
+	for i := 0; i < index && i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if !inCode && strings.HasPrefix(trimmed, "#") {
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			if level <= 6 && len(trimmed) > level && trimmed[level] == ' ' {
+				current = trimmed
+			}
+		}
+	}
+	return current
+}
+
+// pairLines associe les lignes supprimées aux lignes ajoutées qui les suivent quand elles se
+// ressemblent, pour les afficher mot à mot
+func pairLines(edits []Edit) []Line {
+	var lines []Line
+	for i := 0; i < len(edits); {
+		if edits[i].Op != Delete {
+			lines = append(lines, Line{Op: edits[i].Op, Text: edits[i].Text})
+			i++
+			continue
+		}
+
+		deleted := i
+		for i < len(edits) && edits[i].Op == Delete {
+			i++
+		}
+		inserted := i
+		for i < len(edits) && edits[i].Op == Insert {
+			i++
+		}
+		removed, added := edits[deleted:inserted], edits[inserted:i]
+
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			if j < len(removed) && j < len(added) {
+				if words := Diff(tokenize(removed[j].Text), tokenize(added[j].Text)); similar(words) {
+					lines = append(lines, Line{Op: Insert, Text: added[j].Text, Words: words})
+					continue
+				}
+			}
+			if j < len(removed) {
+				lines = append(lines, Line{Op: Delete, Text: removed[j].Text})
+			}
+			if j < len(added) {
+				lines = append(lines, Line{Op: Insert, Text: added[j].Text})
+			}
+		}
+	}
+	return lines
+}
+
+// similar indique si au moins la moitié des mots sont conservés
+func similar(words []Edit) bool {
+	equal, total := 0, 0
+	for _, word := range words {
+		if strings.TrimSpace(word.Text) == "" {
+			continue
+		}
+		total++
+		if word.Op == Equal {
+			equal++
+		}
+	}
+	return total > 0 && equal*2 >= total
+}
+
+// tokenize découpe une ligne en mots, espaces et ponctuation ; leur concaténation redonne la ligne
+func tokenize(line string) []string {
+	var tokens []string
+	var current []rune
+	kind := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return 0
+		case unicode.IsSpace(r):
+			return 1
+		}
+		return 2
+	}
+
+	for _, r := range line {
+		if len(current) > 0 && (kind(r) != kind(current[0]) || kind(r) == 2) {
+			tokens = append(tokens, string(current))
+			current = current[:0]
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		tokens = append(tokens, string(current))
+	}
+	return tokens
+}
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"fmt"
+)
+
+const (
+	// BackendCLI exécute la commande git installée
+	BackendCLI = "cli"
+	// BackendGo utilise go-git, sans commande git. Les branches divergentes ne peuvent pas
+	// y être fusionnées : pull, sync et conflicts retournent alors ErrUnsupported.
+	BackendGo = "go"
+)
+
+// Backend est une implémentation des opérations git d'un dépôt. Les erreurs sont des
+// *Error dont le type (ErrAuth, ErrNetwork...) se teste avec errors.Is.
+type Backend interface {
+	Status() ([]Change, error)
+	AddAll() error
+	Add(paths ...string) error
+	Remove(paths ...string) error
+	Commit(message string) error
+	CurrentBranch() (string, error)
+
+	Fetch(remote, branch string) error
+	Pull(remote, branch string) error
+	Merge(remote, branch string) error
+	Rebase(remote, branch string) error
+	Push(remote, branch string) error
+	AheadBehind(remote, branch string) (ahead, behind int, err error)
+
+	// Log retourne les commits qui ont modifié path, en suivant ses renommages avec follow
+	Log(path string, follow bool, limit int) ([]Commit, error)
+	Show(hash, path string) ([]byte, error)
+
+	Conflicts() ([]string, error)
+	ConflictVersions(path string) (Versions, error)
+	IsMerging() bool
+	IsRebasing() bool
+	Continue() error
+}
+
+// NewBackend crée l'implémentation name (BackendCLI par défaut) pour le dépôt repoPath
+func NewBackend(name, repoPath string) (Backend, error) {
+	switch name {
+	case "", BackendCLI:
+		return newCLIBackend(repoPath), nil
+	case BackendGo:
+		return newGoBackend(repoPath)
+	}
+	return nil, fmt.Errorf("unknown git backend '%s' (expected %s or %s)", name, BackendCLI, BackendGo)
+}
@@ -0,0 +1,278 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cliBackend exécute la commande git et analyse sa sortie d'erreur
+type cliBackend struct {
+	repoPath string
+}
+
+func newCLIBackend(repoPath string) *cliBackend {
+	return &cliBackend{repoPath: repoPath}
+}
+
+// run exécute git dans le dépôt et retourne sa sortie standard. En cas d'échec, les sorties
+// sont analysées pour retourner une *Error typée.
+func (c *cliBackend) run(op string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = c.repoPath
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		// git écrit certains messages (conflits notamment) sur la sortie standard
+		messages := stderr.String() + "\n" + string(output)
+		return output, &Error{Op: op, Kind: classify(messages), Detail: detail(messages), Err: err}
+	}
+	return output, nil
+}
+
+func (c *cliBackend) Status() ([]Change, error) {
+	output, err := c.run("checking for changes", "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	entries := bytes.Split(bytes.TrimSuffix(output, []byte{0}), []byte{0})
+	for i := 0; i < len(entries); i++ {
+		entry := string(entries[i])
+		if len(entry) < 4 {
+			continue
+		}
+		change := Change{Status: entry[:2], Path: entry[3:]}
+		// Avec -z, l'ancien chemin d'un renommage suit dans l'entrée suivante
+		if (change.Status[0] == 'R' || change.Status[0] == 'C') && i+1 < len(entries) {
+			i++
+			change.OldPath = string(entries[i])
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (c *cliBackend) AddAll() error {
+	_, err := c.run("adding changes", "add", ".")
+	return err
+}
+
+func (c *cliBackend) Add(paths ...string) error {
+	_, err := c.run("adding changes", append([]string{"add", "--"}, paths...)...)
+	return err
+}
+
+func (c *cliBackend) Remove(paths ...string) error {
+	_, err := c.run("removing files", append([]string{"rm", "--quiet", "--"}, paths...)...)
+	return err
+}
+
+func (c *cliBackend) Commit(message string) error {
+	_, err := c.run("creating commit", "commit", "--quiet", "-m", message)
+	return err
+}
+
+func (c *cliBackend) CurrentBranch() (string, error) {
+	output, err := c.run("getting current branch", "branch", "--show-current")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (c *cliBackend) Fetch(remote, branch string) error {
+	_, err := c.run("fetching", "fetch", remote, branch)
+	return err
+}
+
+func (c *cliBackend) Pull(remote, branch string) error {
+	_, err := c.run("pulling", "pull", "--no-rebase", remote, branch)
+	return err
+}
+
+func (c *cliBackend) Merge(remote, branch string) error {
+	_, err := c.run("merging", "merge", "--no-edit", remote+"/"+branch)
+	return err
+}
+
+func (c *cliBackend) Rebase(remote, branch string) error {
+	_, err := c.run("rebasing", "rebase", remote+"/"+branch)
+	return err
+}
+
+func (c *cliBackend) Push(remote, branch string) error {
+	_, err := c.run("pushing to "+remote, "push", "--quiet", remote, branch)
+	return err
+}
+
+func (c *cliBackend) AheadBehind(remote, branch string) (int, int, error) {
+	output, err := c.run("comparing with "+remote+"/"+branch, "rev-list", "--left-right", "--count", "HEAD..."+remote+"/"+branch)
+	if err != nil {
+		var gitErr *Error
+		// Une branche distante jamais récupérée n'est pas une révision connue
+		if errors.As(err, &gitErr) && gitErr.Kind == nil && strings.Contains(gitErr.Detail, "unknown revision") {
+			gitErr.Kind = ErrNoRemote
+		}
+		return 0, 0, err
+	}
+
+	var ahead, behind int
+	if _, err := fmt.Sscanf(string(output), "%d %d", &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("unexpected output of git rev-list: %q", output)
+	}
+	return ahead, behind, nil
+}
+
+// logFormat sépare les commits par \x1e et leurs champs par \x1f
+const logFormat = "--format=%x1e%H%x1f%an%x1f%aI%x1f%s"
+
+func (c *cliBackend) Log(path string, follow bool, limit int) ([]Commit, error) {
+	args := []string{"log", "-z", "--name-status", logFormat}
+	if follow {
+		args = append(args, "--follow")
+	}
+	if limit > 0 {
+		args = append(args, "-n", strconv.Itoa(limit))
+	}
+	if path == "" {
+		path = "."
+	}
+	output, err := c.run("reading history of "+path, append(args, "--", path)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(output), "\x1e")[1:] {
+		// "<hash>\x1f<auteur>\x1f<date>\x1f<sujet>\x00\n<statut>\x00<chemin>\x00..."
+		header, files, _ := strings.Cut(record, "\x00")
+		fields := strings.SplitN(header, "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected date in git log: %q", fields[2])
+		}
+		commit := Commit{Hash: fields[0], Author: fields[1], Date: date, Subject: fields[3]}
+
+		entries := strings.Split(strings.TrimPrefix(files, "\n"), "\x00")
+		for i := 0; i+1 < len(entries) && entries[i] != ""; i += 2 {
+			change := Change{Status: entries[i][:1], Path: entries[i+1]}
+			// Un renommage ou une copie est suivi de l'ancien puis du nouveau chemin
+			if (change.Status == "R" || change.Status == "C") && i+2 < len(entries) {
+				change.OldPath, change.Path = entries[i+1], entries[i+2]
+				i++
+			}
+			commit.Changes = append(commit.Changes, change)
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+func (c *cliBackend) Show(hash, path string) ([]byte, error) {
+	return c.run("reading "+path+" at "+hash, "show", hash+":"+path)
+}
+
+func (c *cliBackend) Conflicts() ([]string, error) {
+	// Avec -z, les chemins ne sont pas entre guillemets ni échappés (core.quotePath)
+	output, err := c.run("getting conflicts", "diff", "--name-only", "-z", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []string{}
+	for _, entry := range bytes.Split(bytes.TrimSuffix(output, []byte{0}), []byte{0}) {
+		if len(entry) > 0 {
+			conflicts = append(conflicts, string(entry))
+		}
+	}
+	return conflicts, nil
+}
+
+func (c *cliBackend) ConflictVersions(path string) (Versions, error) {
+	var versions Versions
+
+	output, err := c.run("reading conflict of "+path, "ls-files", "-u", "-z", "--", path)
+	if err != nil {
+		return versions, err
+	}
+
+	// Pendant un rebase, l'étape 2 est la branche distante et l'étape 3 le commit local rejoué
+	ours, theirs := "2", "3"
+	if c.IsRebasing() {
+		ours, theirs = "3", "2"
+	}
+
+	for _, entry := range strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00") {
+		// "<mode> <objet> <étape>\t<chemin>"
+		info, _, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+		if !ok || len(fields) != 3 {
+			continue
+		}
+
+		content, err := c.run("reading conflict of "+path, "cat-file", "blob", fields[1])
+		if err != nil {
+			return versions, err
+		}
+		if content == nil {
+			content = []byte{}
+		}
+
+		switch fields[2] {
+		case "1":
+			versions.Base = content
+		case ours:
+			versions.Ours = content
+		case theirs:
+			versions.Theirs = content
+		}
+	}
+	return versions, nil
+}
+
+func (c *cliBackend) IsMerging() bool {
+	_, err := c.run("checking merge", "rev-parse", "-q", "--verify", "MERGE_HEAD")
+	return err == nil
+}
+
+func (c *cliBackend) IsRebasing() bool {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		output, err := c.run("checking rebase", "rev-parse", "--git-path", dir)
+		if err != nil {
+			continue
+		}
+		path := strings.TrimSpace(string(output))
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.repoPath, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cliBackend) Continue() error {
+	if c.IsRebasing() {
+		_, err := c.run("rebasing", "-c", "core.editor=true", "rebase", "--continue")
+		return err
+	}
+	if c.IsMerging() {
+		_, err := c.run("creating merge commit", "commit", "--quiet", "--no-edit")
+		return err
+	}
+	return nil
+}
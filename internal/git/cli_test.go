@@ -0,0 +1,79 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// conflictedRepository crée un dépôt dont la fusion de la branche other est en conflit sur name
+func conflictedRepository(t *testing.T, name string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for key, value := range map[string]string{
+		"GIT_AUTHOR_NAME": "test", "GIT_AUTHOR_EMAIL": "test@example.com",
+		"GIT_COMMITTER_NAME": "test", "GIT_COMMITTER_EMAIL": "test@example.com",
+		"GIT_CONFIG_GLOBAL": os.DevNull, "GIT_CONFIG_NOSYSTEM": "1",
+	} {
+		t.Setenv(key, value)
+	}
+
+	dir := t.TempDir()
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	git("init", "-q", "-b", "main")
+	write("base\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "base")
+	git("checkout", "-q", "-b", "other")
+	write("other\n")
+	git("commit", "-q", "-am", "other")
+	git("checkout", "-q", "main")
+	write("main\n")
+	git("commit", "-q", "-am", "main")
+
+	cmd := exec.Command("git", "merge", "-q", "other")
+	cmd.Dir = dir
+	if err := cmd.Run(); err == nil {
+		t.Fatal("merge succeeded without conflict")
+	}
+	return dir
+}
+
+func TestCLIConflictsNonASCII(t *testing.T) {
+	name := "Journée à l'été.md"
+	dir := conflictedRepository(t, name)
+	backend := newCLIBackend(dir)
+
+	conflicts, err := backend.Conflicts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(conflicts, []string{name}) {
+		t.Fatalf("conflicts = %q, want %q", conflicts, name)
+	}
+
+	versions, err := backend.ConflictVersions(conflicts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Versions{Base: []byte("base\n"), Ours: []byte("main\n"), Theirs: []byte("other\n")}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("versions = %q, want %q", versions, want)
+	}
+}
@@ -0,0 +1,125 @@
+package git
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/logger"
+)
+
+// Types d'erreur reconnus, à tester avec errors.Is
+var (
+	ErrAuth           = errors.New("authentication failed")
+	ErrNetwork        = errors.New("remote repository unreachable")
+	ErrNoRemote       = errors.New("remote repository or branch not found")
+	ErrNonFastForward = errors.New("the remote branch has new commits")
+	ErrConflict       = errors.New("conflicts must be resolved")
+	ErrUnsupported    = errors.New("not supported by the go backend")
+)
+
+// Error est l'échec d'une opération git
+type Error struct {
+	// Op décrit l'opération, par exemple "pushing to origin"
+	Op string
+	// Kind est l'un des types d'erreur ci-dessus, ou nil si la cause n'a pas été reconnue
+	Kind error
+	// Detail est le message de git le plus utile
+	Detail string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	msg := "error while " + e.Op
+	if e.Kind != nil {
+		msg += ": " + e.Kind.Error()
+	}
+	switch {
+	case e.Detail != "":
+		msg += " (" + e.Detail + ")"
+	case e.Kind == nil && e.Err != nil:
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// patterns associe des extraits des messages de git (en minuscules) à un type d'erreur
+var patterns = []struct {
+	kind   error
+	substr []string
+}{
+	{ErrConflict, []string{"conflict (", "automatic merge failed", "could not apply", "you have unmerged paths", "needs merge"}},
+	{ErrNonFastForward, []string{"non-fast-forward", "fetch first", "not possible to fast-forward", "diverging branches"}},
+	{ErrAuth, []string{"authentication failed", "permission denied", "could not read username", "could not read password", "terminal prompts disabled", "invalid username or password", "access denied", "unable to authenticate", "authentication required", "authorization failed", "returned error: 401", "returned error: 403"}},
+	{ErrNetwork, []string{"could not resolve host", "connection refused", "connection timed out", "operation timed out", "network is unreachable", "no route to host", "no such host", "i/o timeout", "could not connect", "failed to connect"}},
+	{ErrNoRemote, []string{"does not appear to be a git repository", "no such remote", "repository not found", "couldn't find remote ref", "remote not found", "reference not found", "remote repository is empty"}},
+}
+
+// classify retourne le type d'une erreur à partir du message de git
+func classify(message string) error {
+	message = strings.ToLower(message)
+	for _, p := range patterns {
+		for _, substr := range p.substr {
+			if strings.Contains(message, substr) {
+				return p.kind
+			}
+		}
+	}
+	return nil
+}
+
+// detail extrait le message le plus utile de la sortie de git : la première ligne
+// "fatal:", "error:" ou "! [rejected]", sinon la dernière ligne qui n'est pas une aide
+func detail(output string) string {
+	var last string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "hint:") {
+			continue
+		}
+		for _, prefix := range []string{"fatal:", "error:", "! ", "CONFLICT"} {
+			if strings.HasPrefix(line, prefix) {
+				return line
+			}
+		}
+		last = line
+	}
+	return last
+}
+
+// Advice retourne un conseil pour l'utilisateur selon le type d'erreur, ou une chaîne vide
+func Advice(err error) string {
+	switch {
+	case errors.Is(err, ErrAuth):
+		return "Check your credentials (SSH key, token or credential helper)"
+	case errors.Is(err, ErrNetwork):
+		return "Check your internet connection and try again"
+	case errors.Is(err, ErrNoRemote):
+		return "Check the remote and the branch in the 'git' section of the vault configuration"
+	case errors.Is(err, ErrNonFastForward):
+		return "Run 'obs-cli sync' to integrate the remote changes first"
+	case errors.Is(err, ErrConflict):
+		return "Run 'obs-cli conflicts' to resolve them"
+	case errors.Is(err, ErrUnsupported):
+		return "Use the git command line for this repository ('backend: cli')"
+	}
+	return ""
+}
+
+// LogError affiche une erreur suivie du conseil correspondant
+func LogError(log logger.Scope, err error) {
+	log.Error("%s", err.Error())
+	if advice := Advice(err); advice != "" {
+		log.Info("%s", advice)
+	}
+}
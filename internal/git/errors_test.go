@@ -0,0 +1,154 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		message string
+		want    error
+	}{
+		{"CONFLICT (content): Merge conflict in Note.md\nAutomatic merge failed; fix conflicts and then commit the result.", ErrConflict},
+		{"error: could not apply 1a2b3c4... Update note", ErrConflict},
+		{"error: you have unmerged paths.", ErrConflict},
+		{" ! [rejected]        main -> main (fetch first)", ErrNonFastForward},
+		{"! [rejected] main -> main (non-fast-forward)", ErrNonFastForward},
+		{"fatal: Not possible to fast-forward, aborting.", ErrNonFastForward},
+		{"fatal: Authentication failed for 'https://github.com/user/vault.git/'", ErrAuth},
+		{"git@github.com: Permission denied (publickey).", ErrAuth},
+		{"fatal: could not read Username for 'https://github.com': terminal prompts disabled", ErrAuth},
+		{"The requested URL returned error: 403", ErrAuth},
+		{"ssh: Could not resolve hostname github.com: Name or service not known", ErrNetwork},
+		{"fatal: unable to access 'https://github.com/': Could not resolve host: github.com", ErrNetwork},
+		{"ssh: connect to host github.com port 22: Connection timed out", ErrNetwork},
+		{"fatal: 'origin' does not appear to be a git repository", ErrNoRemote},
+		{"fatal: couldn't find remote ref main", ErrNoRemote},
+		{"remote: Repository not found.", ErrNoRemote},
+		{"fatal: not a git repository (or any of the parent directories): .git", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := classify(tt.message); got != tt.want {
+			t.Errorf("classify(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestDetail(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"To github.com:user/vault.git\n ! [rejected]        main -> main (fetch first)\nerror: failed to push some refs\nhint: Updates were rejected", "! [rejected]        main -> main (fetch first)"},
+		{"hint: see 'git help'\nfatal: no such remote 'backup'\n", "fatal: no such remote 'backup'"},
+		{"Auto-merging Note.md\nCONFLICT (content): Merge conflict in Note.md\n", "CONFLICT (content): Merge conflict in Note.md"},
+		{"Already up to date.\nhint: nothing\n", "Already up to date."},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := detail(tt.output); got != tt.want {
+			t.Errorf("detail(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("exit status 128")
+	tests := []struct {
+		name    string
+		err     *Error
+		message string
+		is      []error
+	}{
+		{
+			name:    "typed with detail",
+			err:     &Error{Op: "pushing to origin", Kind: ErrAuth, Detail: "fatal: Authentication failed", Err: cause},
+			message: "error while pushing to origin: authentication failed (fatal: Authentication failed)",
+			is:      []error{ErrAuth, cause},
+		},
+		{
+			name:    "typed without detail",
+			err:     &Error{Op: "continuing", Kind: ErrUnsupported},
+			message: "error while continuing: not supported by the go backend",
+			is:      []error{ErrUnsupported},
+		},
+		{
+			name:    "unknown cause",
+			err:     &Error{Op: "committing", Err: cause},
+			message: "error while committing: exit status 128",
+			is:      []error{cause},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.message {
+				t.Errorf("Error() = %q, want %q", got, tt.message)
+			}
+			wrapped := fmt.Errorf("vault: %w", tt.err)
+			for _, target := range tt.is {
+				if !errors.Is(wrapped, target) {
+					t.Errorf("errors.Is(%v) = false", target)
+				}
+			}
+			for _, kind := range []error{ErrAuth, ErrNetwork, ErrNoRemote, ErrNonFastForward, ErrConflict, ErrUnsupported} {
+				if kind != tt.err.Kind && errors.Is(wrapped, kind) {
+					t.Errorf("errors.Is(%v) = true", kind)
+				}
+			}
+			var gitErr *Error
+			if !errors.As(wrapped, &gitErr) || gitErr != tt.err {
+				t.Error("errors.As did not find the *Error")
+			}
+		})
+	}
+}
+
+func TestGoError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"authentication required", transport.ErrAuthenticationRequired, ErrAuth},
+		{"authorization failed", fmt.Errorf("push: %w", transport.ErrAuthorizationFailed), ErrAuth},
+		{"invalid auth method", transport.ErrInvalidAuthMethod, ErrAuth},
+		{"repository not found", transport.ErrRepositoryNotFound, ErrNoRemote},
+		{"empty remote", transport.ErrEmptyRemoteRepository, ErrNoRemote},
+		{"remote not found", gogit.ErrRemoteNotFound, ErrNoRemote},
+		{"reference not found", plumbing.ErrReferenceNotFound, ErrNoRemote},
+		{"non fast-forward", gogit.ErrNonFastForwardUpdate, ErrNonFastForward},
+		{"network", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrNetwork},
+		{"classified message", errors.New("ssh: handshake failed: unable to authenticate"), ErrAuth},
+		{"unknown", errors.New("object not found"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := goError("pushing to origin", tt.err)
+			var gitErr *Error
+			if !errors.As(err, &gitErr) {
+				t.Fatalf("goError returned %T, want *Error", err)
+			}
+			if gitErr.Kind != tt.want {
+				t.Errorf("kind = %v, want %v", gitErr.Kind, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("the go-git error is not wrapped")
+			}
+			if gitErr.Op != "pushing to origin" || gitErr.Detail != tt.err.Error() {
+				t.Errorf("unexpected error %+v", gitErr)
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package git
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"os/exec"
-	"strings"
-	"time"
+	"path/filepath"
+	"strconv"
 )
 
 const (
@@ -13,93 +16,220 @@ const (
 	DefaultTimeFormat = "02-01-2006_15:04:05"
 )
 
+// Options choisit le dépôt distant, la branche et l'implémentation. Les valeurs vides
+// utilisent DefaultRemote, DefaultBranch et BackendCLI.
+type Options struct {
+	Remote  string
+	Branch  string
+	Backend string
+}
+
+// Git applique les opérations d'un Backend au dépôt distant et à la branche configurés
 type Git struct {
+	backend  Backend
 	repoPath string
+	remote   string
+	branch   string
+}
+
+func New(repoPath string, opts Options) (*Git, error) {
+	backend, err := NewBackend(opts.Backend, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithBackend(repoPath, opts, backend), nil
 }
 
-func New(repoPath string) *Git {
-	return &Git{
+// NewWithBackend crée un Git sur une implémentation donnée, par exemple un faux backend de test
+func NewWithBackend(repoPath string, opts Options, backend Backend) *Git {
+	g := &Git{
+		backend:  backend,
 		repoPath: repoPath,
+		remote:   opts.Remote,
+		branch:   opts.Branch,
+	}
+	if g.remote == "" {
+		g.remote = DefaultRemote
+	}
+	if g.branch == "" {
+		g.branch = DefaultBranch
 	}
+	return g
 }
 
-func (g *Git) HasChanges() (bool, error) {
-	cmd := exec.Command("git", "status", "--porcelain")
-	cmd.Dir = g.repoPath
-	output, err := cmd.Output()
+// Path retourne le dossier du dépôt
+func (g *Git) Path() string {
+	return g.repoPath
+}
+
+// FindRoot retourne la racine du dépôt git contenant dir
+func FindRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
 	if err != nil {
-		return false, fmt.Errorf("error while checking for changes: %w", err)
+		return "", err
 	}
-	return len(output) > 0, nil
+	for current := dir; ; {
+		// .git est un dossier, ou un fichier pour un worktree ou un sous-module
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", fmt.Errorf("%s is not in a git repository", dir)
+		}
+		current = parent
+	}
+}
+
+// Remote retourne le nom du dépôt distant utilisé par Push, Fetch et Pull
+func (g *Git) Remote() string {
+	return g.remote
+}
+
+// Branch retourne la branche attendue
+func (g *Git) Branch() string {
+	return g.branch
+}
+
+func (g *Git) HasChanges() (bool, error) {
+	changes, err := g.backend.Status()
+	return len(changes) > 0, err
+}
+
+// Change est une entrée de 'git status --porcelain'
+type Change struct {
+	// Status est le code à deux lettres de git (index puis copie de travail)
+	Status string
+	Path   string
+	// OldPath est l'ancien chemin d'un fichier renommé ou copié
+	OldPath string
+}
+
+// Status retourne les fichiers modifiés, ajoutés ou supprimés du dépôt
+func (g *Git) Status() ([]Change, error) {
+	return g.backend.Status()
 }
 
 func (g *Git) AddAll() error {
-	cmd := exec.Command("git", "add", ".")
-	cmd.Dir = g.repoPath
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error while adding changes: %w", err)
-	}
-	return nil
+	return g.backend.AddAll()
 }
 
-func (g *Git) Commit() error {
-	now := time.Now().Format(DefaultTimeFormat)
-	cmd := exec.Command("git", "commit", "--quiet", "-m", now)
-	cmd.Dir = g.repoPath
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error while creating commit: %w", err)
-	}
-	return nil
+// Add ajoute des fichiers à l'index, ce qui marque leurs conflits comme résolus
+func (g *Git) Add(paths ...string) error {
+	return g.backend.Add(paths...)
+}
+
+// Remove supprime des fichiers de l'index et de la copie de travail
+func (g *Git) Remove(paths ...string) error {
+	return g.backend.Remove(paths...)
+}
+
+func (g *Git) Commit(message string) error {
+	return g.backend.Commit(message)
 }
 
 func (g *Git) Push() error {
-	cmd := exec.Command("git", "push", "--quiet", DefaultRemote, DefaultBranch)
-	cmd.Dir = g.repoPath
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error while pushing to GitHub: %w", err)
-	}
-	return nil
+	return g.backend.Push(g.remote, g.branch)
 }
 
 func (g *Git) GetCurrentBranch() (string, error) {
-	cmd := exec.Command("git", "branch", "--show-current")
-	cmd.Dir = g.repoPath
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("error while getting current branch: %w", err)
-	}
-	return strings.TrimSpace(string(output)), nil
+	return g.backend.CurrentBranch()
+}
+
+// AheadBehind compte les commits locaux absents de la branche distante récupérée par Fetch,
+// et inversement
+func (g *Git) AheadBehind() (ahead, behind int, err error) {
+	return g.backend.AheadBehind(g.remote, g.branch)
 }
 
 func (g *Git) Fetch() error {
-	cmd := exec.Command("git", "fetch", DefaultRemote, DefaultBranch)
-	cmd.Dir = g.repoPath
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error while fetching: %w", err)
-	}
-	return nil
+	return g.backend.Fetch(g.remote, g.branch)
 }
 
+// Pull fusionne la branche distante. En cas de conflit (ErrConflict), la fusion reste en cours.
 func (g *Git) Pull() error {
-	cmd := exec.Command("git", "pull", DefaultRemote, DefaultBranch)
-	cmd.Dir = g.repoPath
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error while pulling: %w", err)
-	}
-	return nil
+	return g.backend.Pull(g.remote, g.branch)
+}
+
+// Merge fusionne la branche distante récupérée par Fetch. En cas de conflit, la fusion reste en cours.
+func (g *Git) Merge() error {
+	return g.backend.Merge(g.remote, g.branch)
+}
+
+// Rebase rejoue les commits locaux sur la branche distante récupérée par Fetch.
+// En cas de conflit, le rebase reste en cours.
+func (g *Git) Rebase() error {
+	return g.backend.Rebase(g.remote, g.branch)
 }
 
 func (g *Git) GetConflicts() ([]string, error) {
-	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
-	cmd.Dir = g.repoPath
-	output, err := cmd.Output()
+	return g.backend.Conflicts()
+}
+
+// Versions contient les trois versions d'un fichier en conflit. Ours est toujours la version
+// locale, y compris pendant un rebase. Une version absente (fichier créé ou supprimé d'un côté) est nil.
+type Versions struct {
+	Base   []byte
+	Ours   []byte
+	Theirs []byte
+}
+
+// ConflictVersions lit dans l'index les versions d'origine, locale et distante d'un fichier en conflit
+func (g *Git) ConflictVersions(path string) (Versions, error) {
+	return g.backend.ConflictVersions(path)
+}
+
+// IsMerging indique si une fusion est en cours
+func (g *Git) IsMerging() bool {
+	return g.backend.IsMerging()
+}
+
+// IsRebasing indique si un rebase est en cours
+func (g *Git) IsRebasing() bool {
+	return g.backend.IsRebasing()
+}
+
+// Continue poursuit le rebase ou termine la fusion en cours une fois les conflits résolus.
+// Un rebase peut s'arrêter à nouveau sur le conflit d'un commit suivant.
+func (g *Git) Continue() error {
+	return g.backend.Continue()
+}
+
+// MergeFile fusionne trois versions d'un texte avec 'git merge-file'. Avec union, les lignes
+// des deux côtés sont conservées au lieu de produire un conflit. conflicts indique si le
+// résultat contient des marqueurs de conflit.
+func MergeFile(base, ours, theirs []byte, union bool) (merged []byte, conflicts bool, err error) {
+	dir, err := os.MkdirTemp("", "obs-cli-merge-*")
 	if err != nil {
-		return nil, fmt.Errorf("error while getting conflicts: %w", err)
+		return nil, false, err
+	}
+	defer os.RemoveAll(dir)
+
+	files := make([]string, 3)
+	for i, content := range [][]byte{ours, base, theirs} {
+		files[i] = filepath.Join(dir, strconv.Itoa(i))
+		if err := os.WriteFile(files[i], content, 0600); err != nil {
+			return nil, false, err
+		}
 	}
 
-	if len(output) == 0 {
-		return []string{}, nil
+	args := []string{"merge-file", "-p", "-L", "local", "-L", "base", "-L", "remote"}
+	if union {
+		args = append(args, "--union")
 	}
+	cmd := exec.Command("git", append(args, files...)...)
+	output, err := cmd.Output()
 
-	return strings.Split(strings.TrimSpace(string(output)), "\n"), nil
+	// Le code de sortie est le nombre de conflits, ou négatif en cas d'erreur
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+		return output, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error while merging: %w", err)
+	}
+	return output, false, nil
 }
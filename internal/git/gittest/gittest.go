@@ -0,0 +1,204 @@
+// Package gittest fournit un faux git.Backend pour tester les commandes sans dépôt ni réseau
+package gittest
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/coyls/obs-cli/internal/git"
+)
+
+// Backend est un dépôt en mémoire. Ses champs peuvent être modifiés avant l'utilisation ;
+// ensuite, seuls Calls, Count et Messages doivent être lus.
+type Backend struct {
+	mu sync.Mutex
+
+	// Changes est retourné par Status, puis vidé par Commit
+	Changes []git.Change
+	// Branch est la branche courante, DefaultBranch si vide
+	Branch string
+	// Conflicted sont les fichiers en conflit retournés par Conflicts, vidés par Continue
+	Conflicted []string
+	// Versions sont les versions des fichiers en conflit
+	Versions map[string]git.Versions
+	Merging  bool
+	Rebasing bool
+	// Errors associe le nom d'une méthode aux erreurs retournées par ses appels successifs ;
+	// une fois la liste épuisée, ou pour une erreur nil, l'appel réussit
+	Errors map[string][]error
+
+	// Calls liste les appels dans l'ordre, par exemple "Push origin main"
+	Calls []string
+	// Messages sont les messages des commits créés
+	Messages []string
+}
+
+var _ git.Backend = (*Backend)(nil)
+
+// Error retourne une *git.Error du type kind, comme celles des vrais backends
+func Error(op string, kind error) error {
+	return &git.Error{Op: op, Kind: kind}
+}
+
+// Count retourne le nombre d'appels de la méthode name
+func (b *Backend) Count(name string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	count := 0
+	for _, call := range b.Calls {
+		if call == name || strings.HasPrefix(call, name+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+// call enregistre un appel et retourne l'erreur prévue. mu doit être verrouillé.
+func (b *Backend) call(name string, args ...string) error {
+	b.Calls = append(b.Calls, strings.Join(append([]string{name}, args...), " "))
+	errs := b.Errors[name]
+	if len(errs) == 0 {
+		return nil
+	}
+	b.Errors[name] = errs[1:]
+	return errs[0]
+}
+
+func (b *Backend) Status() ([]git.Change, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.call("Status"); err != nil {
+		return nil, err
+	}
+	return append([]git.Change(nil), b.Changes...), nil
+}
+
+func (b *Backend) AddAll() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.call("AddAll")
+}
+
+func (b *Backend) Add(paths ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.call("Add", paths...)
+}
+
+func (b *Backend) Remove(paths ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.call("Remove", paths...)
+}
+
+func (b *Backend) Commit(message string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.call("Commit"); err != nil {
+		return err
+	}
+	b.Messages = append(b.Messages, message)
+	b.Changes = nil
+	return nil
+}
+
+func (b *Backend) CurrentBranch() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.call("CurrentBranch"); err != nil {
+		return "", err
+	}
+	if b.Branch == "" {
+		return git.DefaultBranch, nil
+	}
+	return b.Branch, nil
+}
+
+func (b *Backend) Fetch(remote, branch string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.call("Fetch", remote, branch)
+}
+
+func (b *Backend) Pull(remote, branch string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.call("Pull", remote, branch)
+}
+
+func (b *Backend) Merge(remote, branch string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.call("Merge", remote, branch)
+}
+
+func (b *Backend) Rebase(remote, branch string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.call("Rebase", remote, branch)
+}
+
+func (b *Backend) Push(remote, branch string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.call("Push", remote, branch)
+}
+
+func (b *Backend) AheadBehind(remote, branch string) (int, int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return 0, 0, b.call("AheadBehind", remote, branch)
+}
+
+func (b *Backend) Log(path string, follow bool, limit int) ([]git.Commit, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return nil, b.call("Log", path)
+}
+
+func (b *Backend) Show(hash, path string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return nil, b.call("Show", hash, path)
+}
+
+func (b *Backend) Conflicts() ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.call("Conflicts"); err != nil {
+		return nil, err
+	}
+	return append([]string(nil), b.Conflicted...), nil
+}
+
+func (b *Backend) ConflictVersions(path string) (git.Versions, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.call("ConflictVersions", path); err != nil {
+		return git.Versions{}, err
+	}
+	return b.Versions[path], nil
+}
+
+func (b *Backend) IsMerging() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Merging
+}
+
+func (b *Backend) IsRebasing() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Rebasing
+}
+
+// Continue termine la fusion ou le rebase en cours, sauf si une erreur est prévue
+func (b *Backend) Continue() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.call("Continue"); err != nil {
+		return err
+	}
+	b.Merging, b.Rebasing, b.Conflicted = false, false, nil
+	return nil
+}
@@ -0,0 +1,492 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// goBackend utilise go-git. Il ne sait faire que des avances rapides : des branches
+// divergentes retournent ErrUnsupported.
+type goBackend struct {
+	repoPath string
+	repo     *gogit.Repository
+}
+
+func newGoBackend(repoPath string) (*goBackend, error) {
+	repo, err := gogit.PlainOpenWithOptions(repoPath, &gogit.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, goError("opening "+repoPath, err)
+	}
+	return &goBackend{repoPath: repoPath, repo: repo}, nil
+}
+
+// goError type une erreur de go-git comme celles de la commande git
+func goError(op string, err error) error {
+	var kind error
+	var netErr net.Error
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed),
+		errors.Is(err, transport.ErrInvalidAuthMethod):
+		kind = ErrAuth
+	case errors.Is(err, transport.ErrRepositoryNotFound),
+		errors.Is(err, transport.ErrEmptyRemoteRepository),
+		errors.Is(err, gogit.ErrRemoteNotFound),
+		errors.Is(err, plumbing.ErrReferenceNotFound):
+		kind = ErrNoRemote
+	case errors.Is(err, gogit.ErrNonFastForwardUpdate):
+		kind = ErrNonFastForward
+	case errors.As(err, &netErr):
+		kind = ErrNetwork
+	default:
+		kind = classify(err.Error())
+	}
+	return &Error{Op: op, Kind: kind, Detail: err.Error(), Err: err}
+}
+
+func (g *goBackend) worktree(op string) (*gogit.Worktree, error) {
+	wt, err := g.repo.Worktree()
+	if err != nil {
+		return nil, goError(op, err)
+	}
+	return wt, nil
+}
+
+func (g *goBackend) Status() ([]Change, error) {
+	wt, err := g.worktree("checking for changes")
+	if err != nil {
+		return nil, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, goError("checking for changes", err)
+	}
+
+	var changes []Change
+	for path, s := range status {
+		if s.Staging == gogit.Unmodified && s.Worktree == gogit.Unmodified {
+			continue
+		}
+		change := Change{Status: string([]byte{byte(s.Staging), byte(s.Worktree)}), Path: path}
+		if s.Staging == gogit.Renamed || s.Staging == gogit.Copied {
+			change.OldPath = s.Extra
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func (g *goBackend) AddAll() error {
+	wt, err := g.worktree("adding changes")
+	if err != nil {
+		return err
+	}
+	if err := wt.AddWithOptions(&gogit.AddOptions{All: true}); err != nil {
+		return goError("adding changes", err)
+	}
+	return nil
+}
+
+func (g *goBackend) Add(paths ...string) error {
+	wt, err := g.worktree("adding changes")
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := wt.Add(path); err != nil {
+			return goError("adding changes", err)
+		}
+	}
+	return nil
+}
+
+func (g *goBackend) Remove(paths ...string) error {
+	wt, err := g.worktree("removing files")
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := wt.Remove(path); err != nil {
+			return goError("removing files", err)
+		}
+	}
+	return nil
+}
+
+func (g *goBackend) Commit(message string) error {
+	wt, err := g.worktree("creating commit")
+	if err != nil {
+		return err
+	}
+	if _, err := wt.Commit(message, &gogit.CommitOptions{}); err != nil {
+		return goError("creating commit", err)
+	}
+	return nil
+}
+
+func (g *goBackend) CurrentBranch() (string, error) {
+	head, err := g.repo.Head()
+	if err != nil {
+		return "", goError("getting current branch", err)
+	}
+	if !head.Name().IsBranch() {
+		return "", nil
+	}
+	return head.Name().Short(), nil
+}
+
+func (g *goBackend) Fetch(remote, branch string) error {
+	refSpec := gitconfig.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", branch, remote, branch))
+	err := g.repo.Fetch(&gogit.FetchOptions{RemoteName: remote, RefSpecs: []gitconfig.RefSpec{refSpec}})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return goError("fetching", err)
+	}
+	return nil
+}
+
+func (g *goBackend) Pull(remote, branch string) error {
+	if err := g.Fetch(remote, branch); err != nil {
+		return err
+	}
+	return g.fastForward("pulling", remote, branch)
+}
+
+func (g *goBackend) Merge(remote, branch string) error {
+	return g.fastForward("merging", remote, branch)
+}
+
+func (g *goBackend) Rebase(remote, branch string) error {
+	return g.fastForward("rebasing", remote, branch)
+}
+
+// fastForward avance la branche locale jusqu'à la branche distante récupérée par Fetch.
+// Si la branche locale est en avance, il n'y a rien à faire.
+func (g *goBackend) fastForward(op, remote, branch string) error {
+	remoteRef, err := g.repo.Reference(plumbing.NewRemoteReferenceName(remote, branch), true)
+	if err != nil {
+		return goError(op, err)
+	}
+	head, err := g.repo.Head()
+	if err != nil {
+		return goError(op, err)
+	}
+	if remoteRef.Hash() == head.Hash() {
+		return nil
+	}
+
+	local, err := g.repo.CommitObject(head.Hash())
+	if err != nil {
+		return goError(op, err)
+	}
+	upstream, err := g.repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return goError(op, err)
+	}
+
+	if ahead, err := upstream.IsAncestor(local); err != nil {
+		return goError(op, err)
+	} else if ahead {
+		return nil
+	}
+	if behind, err := local.IsAncestor(upstream); err != nil {
+		return goError(op, err)
+	} else if !behind {
+		return &Error{Op: op, Kind: ErrUnsupported, Detail: "the local and remote branches have diverged"}
+	}
+
+	wt, err := g.worktree(op)
+	if err != nil {
+		return err
+	}
+	if err := wt.Reset(&gogit.ResetOptions{Commit: remoteRef.Hash(), Mode: gogit.MergeReset}); err != nil {
+		return goError(op, err)
+	}
+	return nil
+}
+
+func (g *goBackend) Push(remote, branch string) error {
+	refSpec := gitconfig.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, branch))
+	err := g.repo.Push(&gogit.PushOptions{RemoteName: remote, RefSpecs: []gitconfig.RefSpec{refSpec}})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return goError("pushing to "+remote, err)
+	}
+	return nil
+}
+
+func (g *goBackend) AheadBehind(remote, branch string) (int, int, error) {
+	op := "comparing with " + remote + "/" + branch
+	remoteRef, err := g.repo.Reference(plumbing.NewRemoteReferenceName(remote, branch), true)
+	if err != nil {
+		return 0, 0, goError(op, err)
+	}
+	head, err := g.repo.Head()
+	if err != nil {
+		return 0, 0, goError(op, err)
+	}
+	if remoteRef.Hash() == head.Hash() {
+		return 0, 0, nil
+	}
+
+	local, err := g.ancestors(head.Hash())
+	if err != nil {
+		return 0, 0, goError(op, err)
+	}
+	upstream, err := g.ancestors(remoteRef.Hash())
+	if err != nil {
+		return 0, 0, goError(op, err)
+	}
+
+	var ahead, behind int
+	for hash := range local {
+		if !upstream[hash] {
+			ahead++
+		}
+	}
+	for hash := range upstream {
+		if !local[hash] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// ancestors retourne un commit et tous ses ancêtres
+func (g *goBackend) ancestors(from plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	iter, err := g.repo.Log(&gogit.LogOptions{From: from})
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[plumbing.Hash]bool)
+	err = iter.ForEach(func(c *object.Commit) error {
+		hashes[c.Hash] = true
+		return nil
+	})
+	return hashes, err
+}
+
+// Log parcourt les commits depuis HEAD et compare chacun à son premier parent, avec détection
+// des renommages. Comme pour la commande git, les fusions sont ignorées.
+func (g *goBackend) Log(path string, follow bool, limit int) ([]Commit, error) {
+	op := "reading history of " + path
+	head, err := g.repo.Head()
+	if err != nil {
+		return nil, goError(op, err)
+	}
+	iter, err := g.repo.Log(&gogit.LogOptions{From: head.Hash(), Order: gogit.LogOrderCommitterTime})
+	if err != nil {
+		return nil, goError(op, err)
+	}
+
+	var commits []Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		if limit > 0 && len(commits) >= limit {
+			return storer.ErrStop
+		}
+		if c.NumParents() > 1 {
+			return nil
+		}
+		changes, err := commitChanges(c)
+		if err != nil {
+			return err
+		}
+
+		commit := Commit{Hash: c.Hash.String(), Author: c.Author.Name, Date: c.Author.When, Subject: subject(c.Message)}
+		for _, change := range changes {
+			if follow {
+				if change.Path != path {
+					continue
+				}
+				if change.OldPath != "" {
+					path = change.OldPath
+				}
+			} else if !inDir(path, change.Path) && (change.OldPath == "" || !inDir(path, change.OldPath)) {
+				continue
+			}
+			commit.Changes = append(commit.Changes, change)
+		}
+		if len(commit.Changes) > 0 {
+			commits = append(commits, commit)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, goError(op, err)
+	}
+	return commits, nil
+}
+
+// commitChanges retourne les fichiers modifiés par un commit par rapport à son premier parent
+func commitChanges(c *object.Commit) ([]Change, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	diff, err := object.DiffTreeWithOptions(context.Background(), parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for _, d := range diff {
+		switch {
+		case d.From.Name == "":
+			changes = append(changes, Change{Status: "A", Path: d.To.Name})
+		case d.To.Name == "":
+			changes = append(changes, Change{Status: "D", Path: d.From.Name})
+		case d.From.Name != d.To.Name:
+			changes = append(changes, Change{Status: "R", Path: d.To.Name, OldPath: d.From.Name})
+		default:
+			changes = append(changes, Change{Status: "M", Path: d.To.Name})
+		}
+	}
+	return changes, nil
+}
+
+// subject retourne la première ligne d'un message de commit
+func subject(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return line
+}
+
+func (g *goBackend) Show(hash, path string) ([]byte, error) {
+	op := "reading " + path + " at " + hash
+	c, err := g.repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return nil, goError(op, err)
+	}
+	file, err := c.File(path)
+	if err != nil {
+		return nil, goError(op, err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, goError(op, err)
+	}
+	return []byte(content), nil
+}
+
+// Conflicts lit les entrées en conflit de l'index, laissées par une fusion de la commande git
+func (g *goBackend) Conflicts() ([]string, error) {
+	idx, err := g.repo.Storer.Index()
+	if err != nil {
+		return nil, goError("getting conflicts", err)
+	}
+
+	conflicts := []string{}
+	seen := make(map[string]bool)
+	for _, entry := range idx.Entries {
+		if entry.Stage != 0 && !seen[entry.Name] {
+			seen[entry.Name] = true
+			conflicts = append(conflicts, entry.Name)
+		}
+	}
+	return conflicts, nil
+}
+
+func (g *goBackend) ConflictVersions(path string) (Versions, error) {
+	var versions Versions
+	op := "reading conflict of " + path
+
+	idx, err := g.repo.Storer.Index()
+	if err != nil {
+		return versions, goError(op, err)
+	}
+
+	ours, theirs := 2, 3
+	if g.IsRebasing() {
+		ours, theirs = 3, 2
+	}
+
+	for _, entry := range idx.Entries {
+		if entry.Name != filepath.ToSlash(path) || entry.Stage == 0 {
+			continue
+		}
+		blob, err := g.repo.BlobObject(entry.Hash)
+		if err != nil {
+			return versions, goError(op, err)
+		}
+		r, err := blob.Reader()
+		if err != nil {
+			return versions, goError(op, err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return versions, goError(op, err)
+		}
+
+		switch int(entry.Stage) {
+		case 1:
+			versions.Base = content
+		case ours:
+			versions.Ours = content
+		case theirs:
+			versions.Theirs = content
+		}
+	}
+	return versions, nil
+}
+
+// gitDir retourne le chemin d'un fichier du dossier .git
+func (g *goBackend) gitDir(name string) string {
+	dir := filepath.Join(g.repoPath, ".git")
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		// Worktree : .git contient "gitdir: <chemin>"
+		if data, err := os.ReadFile(dir); err == nil {
+			var target string
+			if _, err := fmt.Sscanf(string(data), "gitdir: %s", &target); err == nil {
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(g.repoPath, target)
+				}
+				dir = target
+			}
+		}
+	}
+	return filepath.Join(dir, name)
+}
+
+func (g *goBackend) IsMerging() bool {
+	_, err := os.Stat(g.gitDir("MERGE_HEAD"))
+	return err == nil
+}
+
+func (g *goBackend) IsRebasing() bool {
+	for _, name := range []string{"rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(g.gitDir(name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *goBackend) Continue() error {
+	if g.IsMerging() || g.IsRebasing() {
+		return &Error{Op: "continuing", Kind: ErrUnsupported, Detail: "finish the merge or rebase with git"}
+	}
+	return nil
+}
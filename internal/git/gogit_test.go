@@ -0,0 +1,39 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoBackendContinue(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   string
+		wantErr error
+	}{
+		{name: "nothing in progress"},
+		{name: "merge", state: "MERGE_HEAD", wantErr: ErrUnsupported},
+		{name: "rebase", state: "rebase-merge", wantErr: ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.state != "" {
+				if err := os.Mkdir(filepath.Join(dir, ".git", tt.state), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := NewWithBackend(dir, Options{}, &goBackend{repoPath: dir}).Continue()
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("Continue error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"strings"
+	"time"
+)
+
+// Commit est une entrée de l'historique. Changes contient les fichiers modifiés par le commit
+// qui correspondent au chemin demandé ; pour l'historique d'une note, sa seule entrée donne
+// le chemin de la note dans ce commit.
+type Commit struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+	Changes []Change
+}
+
+// ShortHash retourne les sept premiers caractères du hash, comme git
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// History retourne les commits qui ont modifié un fichier, du plus récent au plus ancien,
+// en suivant ses renommages
+func (g *Git) History(path string) ([]Commit, error) {
+	return g.backend.Log(path, true, 0)
+}
+
+// Log retourne au plus limit commits (tous si limit vaut zéro) qui ont modifié un dossier
+// du dépôt, du plus récent au plus ancien. Les fusions ne sont pas listées.
+func (g *Git) Log(dir string, limit int) ([]Commit, error) {
+	return g.backend.Log(dir, false, limit)
+}
+
+// FileAt retourne le contenu d'un fichier dans un commit
+func (g *Git) FileAt(hash, path string) ([]byte, error) {
+	return g.backend.Show(hash, path)
+}
+
+// inDir indique si un chemin du dépôt est dir ou se trouve dans dir ("" ou "." pour tout le dépôt)
+func inDir(dir, path string) bool {
+	dir = strings.TrimSuffix(dir, "/")
+	if dir == "" || dir == "." {
+		return true
+	}
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
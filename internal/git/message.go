@@ -0,0 +1,93 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultMessage est le modèle de message de commit utilisé si aucun n'est configuré
+const DefaultMessage = "{{.Summary}}"
+
+// MessageData est passé au modèle de message de commit. Exemple de modèle :
+//
+//	{{.Date}} - {{.Count}} file(s) from {{.Host}}: {{join .Notes ", "}}
+type MessageData struct {
+	// Date est l'heure du commit au format DefaultTimeFormat
+	Date   string
+	Time   time.Time
+	Host   string
+	Vault  string
+	Remote string
+	Branch string
+	// Count est le nombre de fichiers modifiés
+	Count int
+	// Files sont les chemins modifiés, relatifs au dépôt
+	Files []string
+	// Notes sont les noms (sans extension) des notes Markdown modifiées
+	Notes []string
+	// Summary résume les changements par type et par dossier (voir Summarize)
+	Summary string
+}
+
+// NewMessageData prépare les valeurs du modèle à partir des changements du dépôt
+func (g *Git) NewMessageData(vault string, changes []Change) MessageData {
+	now := time.Now()
+	host, _ := os.Hostname()
+
+	data := MessageData{
+		Date:    now.Format(DefaultTimeFormat),
+		Time:    now,
+		Host:    host,
+		Vault:   vault,
+		Remote:  g.remote,
+		Branch:  g.branch,
+		Count:   len(changes),
+		Summary: Summarize(changes),
+	}
+
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		data.Files = append(data.Files, change.Path)
+		if !strings.EqualFold(path.Ext(change.Path), ".md") {
+			continue
+		}
+		note := strings.TrimSuffix(path.Base(change.Path), path.Ext(change.Path))
+		if !seen[note] {
+			seen[note] = true
+			data.Notes = append(data.Notes, note)
+		}
+	}
+	return data
+}
+
+// RenderMessage applique un modèle text/template aux valeurs data. Un modèle vide utilise DefaultMessage.
+func RenderMessage(tmpl string, data MessageData) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = DefaultMessage
+	}
+
+	t, err := template.New("message").Funcs(template.FuncMap{
+		"join": func(items []string, sep string) string {
+			return strings.Join(items, sep)
+		},
+	}).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit message template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid commit message template: %w", err)
+	}
+
+	message := strings.TrimSpace(buf.String())
+	if message == "" {
+		return "", fmt.Errorf("commit message template produced an empty message")
+	}
+	return message, nil
+}
@@ -0,0 +1,210 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/logger"
+)
+
+// Repository est un dépôt git et les vaults qu'il contient
+type Repository struct {
+	*Git
+	Vaults []string
+	Config config.GitConfig
+}
+
+// Name désigne le dépôt par ses vaults
+func (r *Repository) Name() string {
+	return strings.Join(r.Vaults, ", ")
+}
+
+// Contains indique si dir est dans le dossier du dépôt
+func (r *Repository) Contains(dir string) bool {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(r.Path(), dir)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Repositories retourne les dépôts des vaults demandés. Les vaults partageant un dépôt
+// sont regroupés ; pour chaque réglage, le premier vault qui le définit l'emporte.
+func Repositories(cfg *config.Config, vaults []string) ([]*Repository, error) {
+	var repos []*Repository
+	byPath := make(map[string]*Repository)
+
+	for _, name := range vaults {
+		vaultConfig, exists := cfg.GetVaultConfig(name)
+		if !exists {
+			return nil, fmt.Errorf("vault '%s' not found in configuration", name)
+		}
+
+		path, err := repositoryPath(cfg.Config.Root, vaultConfig)
+		if err != nil {
+			return nil, fmt.Errorf("vault '%s': %w", name, err)
+		}
+
+		repo, ok := byPath[path]
+		if !ok {
+			repo = &Repository{}
+			byPath[path] = repo
+			repos = append(repos, repo)
+		}
+		repo.Vaults = append(repo.Vaults, name)
+		repo.Config = mergeConfig(repo.Config, vaultConfig.Git)
+	}
+
+	for path, repo := range byPath {
+		g, err := New(path, Options{Remote: repo.Config.Remote, Branch: repo.Config.Branch, Backend: repo.Config.Backend})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repo.Name(), err)
+		}
+		repo.Git = g
+	}
+	return repos, nil
+}
+
+// repositoryPath retourne le dépôt configuré du vault, ou celui qui contient son dossier
+func repositoryPath(root string, vaultConfig *config.VaultConfig) (string, error) {
+	path := vaultConfig.Git.Path
+	if path == "" {
+		var err error
+		if path, err = FindRoot(filepath.Join(root, vaultConfig.VaultPath)); err != nil {
+			return "", err
+		}
+	} else {
+		path = filepath.Join(root, path)
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path, nil
+}
+
+func mergeConfig(current, vault config.GitConfig) config.GitConfig {
+	if current.Remote == "" {
+		current.Remote = vault.Remote
+	}
+	if current.Branch == "" {
+		current.Branch = vault.Branch
+	}
+	if current.Message == "" {
+		current.Message = vault.Message
+	}
+	if current.Backend == "" {
+		current.Backend = vault.Backend
+	}
+	if current.SyncMode == "" {
+		current.SyncMode = vault.SyncMode
+	}
+	if current.Checks.IsZero() {
+		current.Checks = vault.Checks
+	}
+	if current.Conflicts.Strategy == "" && len(current.Conflicts.Rules) == 0 {
+		current.Conflicts = vault.Conflicts
+	}
+	return current
+}
+
+// CommitChanges ajoute et commite tous les changements du dépôt. Sans message, celui-ci est
+// généré à partir du modèle du vault ; edit, s'il n'est pas nil, permet de le modifier.
+// Il retourne le nombre de fichiers commités, zéro s'il n'y avait rien à commiter.
+func (r *Repository) CommitChanges(message string, edit func(string) (string, error)) (int, error) {
+	if err := r.AddAll(); err != nil {
+		return 0, err
+	}
+
+	changes, err := r.Status()
+	if err != nil {
+		return 0, err
+	}
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	message = strings.TrimSpace(message)
+	if message == "" {
+		if message, err = RenderMessage(r.Config.Message, r.NewMessageData(r.Name(), changes)); err != nil {
+			return 0, err
+		}
+	}
+	if edit != nil {
+		if message, err = edit(message); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := r.Commit(message); err != nil {
+		return 0, err
+	}
+	return len(changes), nil
+}
+
+// Result est le résultat d'une opération sur un dépôt
+type Result struct {
+	Repository *Repository
+	// Summary décrit brièvement ce qui a été fait
+	Summary string
+	Err     error
+}
+
+// RunAll exécute fn sur chaque dépôt en parallèle. Les résultats suivent l'ordre de repos.
+func RunAll(repos []*Repository, fn func(repo *Repository, log logger.Scope) (string, error)) []Result {
+	results := make([]Result, len(repos))
+
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		log := logger.Scope{}
+		if len(repos) > 1 {
+			log = logger.NewScope(repo.Name())
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			summary, err := fn(repo, log)
+			results[i] = Result{Repository: repo, Summary: summary, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// PrintSummary affiche le résultat de chaque dépôt et retourne une erreur si l'un a échoué
+func PrintSummary(results []Result) error {
+	failed := 0
+	if len(results) > 1 {
+		fmt.Println()
+		logger.Info("Summary:")
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+		if len(results) == 1 {
+			continue
+		}
+		if result.Err != nil {
+			logger.Error("  %s: %s", result.Repository.Name(), result.Err.Error())
+		} else {
+			logger.Success("  %s: %s", result.Repository.Name(), result.Summary)
+		}
+	}
+
+	if failed == 1 && len(results) == 1 {
+		return results[0].Err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed", failed, len(results))
+	}
+	return nil
+}
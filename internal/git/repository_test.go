@@ -0,0 +1,210 @@
+package git_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/git"
+	"github.com/coyls/obs-cli/internal/git/gittest"
+	"github.com/coyls/obs-cli/internal/logger"
+)
+
+func newRepository(t *testing.T, backend *gittest.Backend, message string) *git.Repository {
+	t.Helper()
+	return &git.Repository{
+		Git:    git.NewWithBackend(t.TempDir(), git.Options{}, backend),
+		Vaults: []string{"Notes"},
+		Config: config.GitConfig{Message: message},
+	}
+}
+
+func TestCommitChanges(t *testing.T) {
+	changes := []git.Change{{Status: "A ", Path: "Notes/New.md"}, {Status: "M ", Path: "Notes/Old.md"}}
+
+	tests := []struct {
+		name     string
+		changes  []git.Change
+		errors   map[string][]error
+		message  string
+		template string
+		edit     func(string) (string, error)
+		want     int
+		wantErr  error
+		// wantMessage est le message du commit, s'il est créé
+		wantMessage string
+	}{
+		{
+			name:    "nothing to commit",
+			changes: nil,
+			want:    0,
+		},
+		{
+			name:        "given message",
+			changes:     changes,
+			message:     "  Daily notes\n",
+			want:        2,
+			wantMessage: "Daily notes",
+		},
+		{
+			name:        "generated message",
+			changes:     changes,
+			template:    "{{.Vault}}: {{.Count}} file(s), {{join .Notes \", \"}}",
+			want:        2,
+			wantMessage: "Notes: 2 file(s), New, Old",
+		},
+		{
+			name:        "edited message",
+			changes:     changes,
+			message:     "draft",
+			edit:        func(m string) (string, error) { return m + " (edited)", nil },
+			want:        2,
+			wantMessage: "draft (edited)",
+		},
+		{
+			name:    "edit cancelled",
+			changes: changes,
+			message: "draft",
+			edit:    func(string) (string, error) { return "", errors.New("empty commit message") },
+			wantErr: errors.New("empty commit message"),
+		},
+		{
+			name:    "add failure",
+			changes: changes,
+			errors:  map[string][]error{"AddAll": {gittest.Error("adding files", git.ErrConflict)}},
+			wantErr: git.ErrConflict,
+		},
+		{
+			name:    "status failure",
+			changes: changes,
+			errors:  map[string][]error{"Status": {gittest.Error("checking for changes", git.ErrUnsupported)}},
+			wantErr: git.ErrUnsupported,
+		},
+		{
+			name:    "commit failure",
+			changes: changes,
+			message: "Daily notes",
+			errors:  map[string][]error{"Commit": {gittest.Error("committing", git.ErrAuth)}},
+			wantErr: git.ErrAuth,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &gittest.Backend{Changes: tt.changes, Errors: tt.errors}
+			repo := newRepository(t, backend, tt.template)
+
+			count, err := repo.CommitChanges(tt.message, tt.edit)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("CommitChanges: %v", err)
+			case tt.wantErr != nil && err == nil:
+				t.Fatalf("CommitChanges succeeded, want %v", tt.wantErr)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr) && err.Error() != tt.wantErr.Error():
+				t.Fatalf("CommitChanges error = %v, want %v", err, tt.wantErr)
+			}
+			if count != tt.want {
+				t.Errorf("count = %d, want %d", count, tt.want)
+			}
+
+			var messages []string
+			if tt.wantMessage != "" {
+				messages = []string{tt.wantMessage}
+			}
+			if strings.Join(backend.Messages, "|") != strings.Join(messages, "|") {
+				t.Errorf("commits %q, want %q", backend.Messages, messages)
+			}
+		})
+	}
+}
+
+func TestRunAll(t *testing.T) {
+	var repos []*git.Repository
+	for i := 0; i < 5; i++ {
+		repo := newRepository(t, &gittest.Backend{}, "")
+		repo.Vaults = []string{fmt.Sprintf("vault-%d", i)}
+		repos = append(repos, repo)
+	}
+	failure := gittest.Error("pushing to origin", git.ErrNetwork)
+
+	results := git.RunAll(repos, func(repo *git.Repository, log logger.Scope) (string, error) {
+		if err := repo.Push(); err != nil {
+			return "", err
+		}
+		if repo.Name() == "vault-3" {
+			return "", failure
+		}
+		return "pushed " + repo.Name(), nil
+	})
+
+	if len(results) != len(repos) {
+		t.Fatalf("%d results, want %d", len(results), len(repos))
+	}
+	for i, result := range results {
+		if result.Repository != repos[i] {
+			t.Errorf("result %d belongs to %s", i, result.Repository.Name())
+		}
+		if i == 3 {
+			if !errors.Is(result.Err, git.ErrNetwork) {
+				t.Errorf("result %d error = %v, want %v", i, result.Err, git.ErrNetwork)
+			}
+			continue
+		}
+		if result.Err != nil || result.Summary != "pushed "+repos[i].Name() {
+			t.Errorf("result %d = %q, %v", i, result.Summary, result.Err)
+		}
+	}
+
+	if err := git.PrintSummary(results); err == nil || err.Error() != "1 of 5 repositories failed" {
+		t.Errorf("PrintSummary error = %v", err)
+	}
+	if err := git.PrintSummary(results[3:4]); !errors.Is(err, git.ErrNetwork) {
+		t.Errorf("PrintSummary of a single repository error = %v, want %v", err, git.ErrNetwork)
+	}
+}
+
+func TestContinue(t *testing.T) {
+	tests := []struct {
+		name     string
+		backend  *gittest.Backend
+		wantErr  error
+		resolved bool
+	}{
+		{
+			name:     "rebase",
+			backend:  &gittest.Backend{Rebasing: true, Conflicted: []string{"Note.md"}},
+			resolved: true,
+		},
+		{
+			name:     "merge",
+			backend:  &gittest.Backend{Merging: true},
+			resolved: true,
+		},
+		{
+			name: "next commit in conflict",
+			backend: &gittest.Backend{
+				Rebasing: true,
+				Errors:   map[string][]error{"Continue": {gittest.Error("continuing the rebase", git.ErrConflict)}},
+			},
+			wantErr: git.ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := git.NewWithBackend(t.TempDir(), git.Options{}, tt.backend)
+			err := g.Continue()
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("Continue error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.backend.Count("Continue") != 1 {
+				t.Errorf("Continue called %d times", tt.backend.Count("Continue"))
+			}
+			if done := !g.IsMerging() && !g.IsRebasing(); done != tt.resolved {
+				t.Errorf("merge or rebase finished = %v, want %v", done, tt.resolved)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Types de changement, dans l'ordre du résumé
+const (
+	ChangeCreated  = "created"
+	ChangeModified = "modified"
+	ChangeDeleted  = "deleted"
+	ChangeRenamed  = "renamed"
+)
+
+var changeKinds = []string{ChangeCreated, ChangeModified, ChangeDeleted, ChangeRenamed}
+
+// changeMarks préfixent chaque note dans le corps du résumé
+var changeMarks = map[string]string{
+	ChangeCreated:  "+",
+	ChangeModified: "~",
+	ChangeDeleted:  "-",
+	ChangeRenamed:  ">",
+}
+
+// Kind retourne le type du changement. Le statut de l'index l'emporte sur celui de la copie de travail.
+func (c Change) Kind() string {
+	code := c.Status[0]
+	if code == ' ' {
+		code = c.Status[1]
+	}
+
+	switch code {
+	case 'A', 'C', '?':
+		return ChangeCreated
+	case 'D':
+		return ChangeDeleted
+	case 'R':
+		return ChangeRenamed
+	default:
+		return ChangeModified
+	}
+}
+
+// title retourne le nom d'une note sans l'extension .md, ou le nom d'une pièce jointe
+func title(p string) string {
+	base := path.Base(p)
+	if strings.EqualFold(path.Ext(base), ".md") {
+		return strings.TrimSuffix(base, path.Ext(base))
+	}
+	return base
+}
+
+// folder retourne le dossier d'un chemin, "/" pour la racine du dépôt
+func folder(p string) string {
+	dir := path.Dir(p)
+	if dir == "." {
+		return "/"
+	}
+	return dir + "/"
+}
+
+// Summarize résume les changements pour un message de commit : une ligne de titre
+// comptant les changements par type, puis les notes par dossier
+//
+//	Update notes: 1 created, 2 modified
+//
+//	Projects/
+//	  + Idea
+//	  ~ Roadmap
+func Summarize(changes []Change) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	counts := make(map[string]int)
+	folders := make(map[string][]string)
+	for _, change := range changes {
+		kind := change.Kind()
+		counts[kind]++
+
+		line := changeMarks[kind] + " " + title(change.Path)
+		if kind == ChangeRenamed && change.OldPath != "" {
+			old := title(change.OldPath)
+			if path.Dir(change.OldPath) != path.Dir(change.Path) {
+				old = strings.TrimSuffix(change.OldPath, path.Ext(change.OldPath))
+			}
+			line = changeMarks[kind] + " " + old + " -> " + title(change.Path)
+		}
+		dir := folder(change.Path)
+		folders[dir] = append(folders[dir], line)
+	}
+
+	var parts []string
+	for _, kind := range changeKinds {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("Update notes: " + strings.Join(parts, ", ") + "\n")
+
+	dirs := make([]string, 0, len(folders))
+	for dir := range folders {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		b.WriteString("\n" + dir + "\n")
+		lines := folders[dir]
+		sort.SliceStable(lines, func(i, j int) bool { return lines[i][2:] < lines[j][2:] })
+		for _, line := range lines {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
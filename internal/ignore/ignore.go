@@ -0,0 +1,341 @@
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/coyls/obs-cli/internal/config"
+)
+
+const (
+	// FileName est le fichier de règles propre à obs-cli, lu dans chaque dossier
+	FileName = ".obsignore"
+	// GitignoreName est lu dans chaque dossier, sauf si 'skip_gitignore' est activé
+	GitignoreName = ".gitignore"
+)
+
+// DefaultExcludes sont exclus de toute sauvegarde. Une règle 'include' permet de les réintégrer.
+var DefaultExcludes = []string{
+	".git/",
+	".trash/",
+	"node_modules/",
+	"**/.obsidian/workspace.json",
+	"**/.obsidian/workspace-mobile.json",
+	".DS_Store",
+}
+
+// rule est un motif au format .gitignore, relatif au dossier base
+type rule struct {
+	base    string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+func (r rule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+	return r.re.MatchString(rel)
+}
+
+// Matcher décide si un chemin relatif à root est exclu. Les règles de la configuration
+// sont appliquées en premier, puis celles des fichiers d'exclusion du dossier le plus
+// haut au plus profond, et enfin les règles 'include' : la dernière règle qui correspond l'emporte.
+type Matcher struct {
+	root     string
+	excludes []rule
+	includes []rule
+	files    []string
+
+	mu   sync.Mutex
+	dirs map[string][]rule
+}
+
+// New crée un matcher pour root. files sont les noms des fichiers de règles lus dans chaque dossier.
+func New(root string, excludes, includes []string, files ...string) (*Matcher, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Matcher{
+		root:     root,
+		excludes: parseRules("", excludes),
+		includes: parseRules("", includes),
+		files:    files,
+		dirs:     make(map[string][]rule),
+	}
+
+	// Les inclusions sont des négations d'exclusions
+	for i := range m.includes {
+		m.includes[i].negate = !m.includes[i].negate
+	}
+	return m, nil
+}
+
+// FromConfig crée le matcher des règles d'archive de la configuration, appliqué au dossier racine des vaults
+func FromConfig(cfg *config.Config) (*Matcher, error) {
+	archive := cfg.Config.Archive
+
+	excludes := append(append([]string{}, DefaultExcludes...), archive.Exclude...)
+	files := []string{FileName}
+	if !archive.SkipGitignore {
+		files = append(files, GitignoreName)
+	}
+	return New(cfg.Config.Root, excludes, archive.Include, files...)
+}
+
+// Root retourne le dossier auquel les chemins sont relatifs
+func (m *Matcher) Root() string {
+	return m.root
+}
+
+// Match indique si rel (chemin relatif à root, séparé par des "/") est exclu,
+// directement ou parce qu'un de ses dossiers parents l'est
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	excluded, _ := m.match(rel, isDir)
+	return excluded
+}
+
+func (m *Matcher) match(rel string, isDir bool) (bool, error) {
+	rel = strings.Trim(path.Clean(rel), "/")
+	if rel == "." || rel == "" {
+		return false, nil
+	}
+
+	parts := strings.Split(rel, "/")
+	for i := 1; i <= len(parts); i++ {
+		current := strings.Join(parts[:i], "/")
+		dir := i < len(parts) || isDir
+
+		excluded, err := m.matchEntry(current, dir)
+		if err != nil || excluded {
+			return excluded, err
+		}
+	}
+	return false, nil
+}
+
+// matchEntry applique les règles à une entrée sans tenir compte de ses parents
+func (m *Matcher) matchEntry(rel string, isDir bool) (bool, error) {
+	excluded := false
+	apply := func(rules []rule) {
+		for _, r := range rules {
+			if r.match(rel, isDir) {
+				excluded = !r.negate
+			}
+		}
+	}
+
+	apply(m.excludes)
+
+	// Fichiers de règles de chaque dossier parent, du plus haut au plus profond
+	parts := strings.Split(rel, "/")
+	for i := 0; i < len(parts); i++ {
+		rules, err := m.dirRules(strings.Join(parts[:i], "/"))
+		if err != nil {
+			return false, err
+		}
+		apply(rules)
+	}
+
+	apply(m.includes)
+	return excluded, nil
+}
+
+// dirRules retourne les règles des fichiers d'exclusion d'un dossier (mises en cache)
+func (m *Matcher) dirRules(dir string) ([]rule, error) {
+	if len(m.files) == 0 {
+		return nil, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rules, ok := m.dirs[dir]; ok {
+		return rules, nil
+	}
+
+	var rules []rule
+	for _, name := range m.files {
+		lines, err := readLines(filepath.Join(m.root, filepath.FromSlash(dir), name))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, parseRules(dir, lines)...)
+	}
+
+	m.dirs[dir] = rules
+	return rules, nil
+}
+
+func readLines(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// Walk parcourt root comme filepath.Walk sans descendre dans les entrées exclues
+func (m *Matcher) Walk(fn filepath.WalkFunc) error {
+	return filepath.Walk(m.root, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return fn(p, info, err)
+		}
+
+		rel, err := filepath.Rel(m.root, p)
+		if err != nil {
+			return err
+		}
+		if rel != "." {
+			excluded, err := m.match(filepath.ToSlash(rel), info.IsDir())
+			if err != nil {
+				return err
+			}
+			if excluded {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		return fn(p, info, nil)
+	})
+}
+
+// Walk parcourt root en appliquant m s'il n'est pas nil
+func Walk(root string, m *Matcher, fn filepath.WalkFunc) error {
+	if m == nil {
+		return filepath.Walk(root, fn)
+	}
+	return m.Walk(fn)
+}
+
+func parseRules(base string, lines []string) []rule {
+	var rules []rule
+	for _, line := range lines {
+		if r, ok := parseRule(base, line); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// parseRule compile une ligne au format .gitignore
+func parseRule(base, line string) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+
+	// Les espaces de fin sont ignorés sauf s'ils sont échappés
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	// Un motif sans "/" (hors fin) s'applique à n'importe quelle profondeur
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return rule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// globToRegexp convertit un motif (*, ?, **, [...]) en expression régulière
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				atEnd := i+2 == len(glob)
+				if atStart && atEnd {
+					b.WriteString(".*")
+					i++
+					continue
+				}
+				if atStart && glob[i+2] == '/' {
+					// "**/" correspond à zéro ou plusieurs dossiers
+					b.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				}
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
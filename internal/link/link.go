@@ -0,0 +1,132 @@
+package link
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Link est un lien d'une note : [[wikilink]], ![[embed]], [lien](markdown) ou ![image](markdown)
+type Link struct {
+	Embed    bool
+	Markdown bool
+	// Target est la note ou le fichier visé, décodé, sans titre de section ni alias ; vide pour
+	// un lien vers une section de la note elle-même
+	Target string
+	// Fragment est le titre de section ou le bloc (^id) visé, sans '#'
+	Fragment string
+	// Alias est le texte affiché : après '|' pour un wikilink, entre crochets pour un lien Markdown
+	Alias string
+	// Line est le numéro de la ligne du lien, à partir de 1
+	Line int
+	// Start et End délimitent le lien dans le contenu de la note ; TargetStart et TargetEnd,
+	// le texte de la cible tel qu'il est écrit
+	Start, End, TargetStart, TargetEnd int
+	// Angle indique une cible Markdown écrite entre < >, qui n'est pas encodée
+	Angle bool
+}
+
+var (
+	wikilinkPattern = regexp.MustCompile(`(!?)\[\[([^\[\]\n]+?)\]\]`)
+	markdownPattern = regexp.MustCompile(`(!?)\[((?:[^\[\]\n]|\[[^\[\]\n]*\])*)\]\((<[^<>\n]*>|[^()\s]*)(?:\s+"[^"\n]*")?\)`)
+	schemePattern   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+	inlineCode      = regexp.MustCompile("`+[^`\n]*`+")
+)
+
+// Parse retourne les liens d'une note, frontmatter compris, hors blocs et extraits de code.
+// Les liens Markdown vers une URL (http:, mailto:...) sont ignorés.
+func Parse(content []byte) []Link {
+	var links []Link
+	inCode := false
+	offset := 0
+
+	for number, line := range strings.SplitAfter(string(content), "\n") {
+		lineStart := offset
+		offset += len(line)
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+
+		// Le code en ligne est masqué sans changer la position des liens
+		masked := inlineCode.ReplaceAllStringFunc(line, func(code string) string {
+			return strings.Repeat(" ", len(code))
+		})
+
+		for _, m := range wikilinkPattern.FindAllStringSubmatchIndex(masked, -1) {
+			links = append(links, parseWikilink(line, m, lineStart, number+1))
+		}
+		for _, m := range markdownPattern.FindAllStringSubmatchIndex(masked, -1) {
+			if link, ok := parseMarkdown(line, m, lineStart, number+1); ok {
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+// parseWikilink découpe [[cible#section|alias]]. m contient les positions des groupes dans la ligne.
+func parseWikilink(line string, m []int, lineStart, number int) Link {
+	inner := line[m[4]:m[5]]
+	link := Link{
+		Embed: m[3] > m[2],
+		Line:  number,
+		Start: lineStart + m[0],
+		End:   lineStart + m[1],
+	}
+
+	target, alias, hasAlias := strings.Cut(inner, "|")
+	if hasAlias {
+		link.Alias = alias
+		// Dans un tableau, le '|' de l'alias est échappé
+		target = strings.TrimSuffix(target, `\`)
+	}
+	target, fragment, _ := strings.Cut(target, "#")
+	link.Fragment = fragment
+
+	link.TargetStart = lineStart + m[4]
+	link.TargetEnd = link.TargetStart + len(target)
+	link.Target = strings.TrimSpace(target)
+	return link
+}
+
+// parseMarkdown découpe [texte](cible#section "titre")
+func parseMarkdown(line string, m []int, lineStart, number int) (Link, bool) {
+	dest := line[m[6]:m[7]]
+	link := Link{
+		Embed:    m[3] > m[2],
+		Markdown: true,
+		Alias:    line[m[4]:m[5]],
+		Line:     number,
+		Start:    lineStart + m[0],
+		End:      lineStart + m[1],
+	}
+
+	destStart := m[6]
+	if strings.HasPrefix(dest, "<") {
+		link.Angle = true
+		dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+		destStart++
+	}
+	if dest == "" || schemePattern.MatchString(dest) {
+		return Link{}, false
+	}
+
+	target, fragment, _ := strings.Cut(dest, "#")
+	link.Fragment = fragment
+	link.TargetStart = lineStart + destStart
+	link.TargetEnd = link.TargetStart + len(target)
+
+	link.Target = target
+	if !link.Angle {
+		if decoded, err := url.PathUnescape(target); err == nil {
+			link.Target = decoded
+		}
+	}
+	return link, true
+}
@@ -0,0 +1,242 @@
+package link
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Index résout les liens comme Obsidian, parmi les fichiers d'un vault (chemins relatifs au
+// vault, séparés par des "/"). Les noms sont comparés sans tenir compte de la casse.
+type Index struct {
+	// files contient chaque chemin, avec sa casse
+	files map[string]bool
+	// paths associe chaque chemin en minuscules au chemin du fichier
+	paths map[string]string
+	// names associe chaque nom de fichier en minuscules aux chemins qui le portent
+	names map[string][]string
+}
+
+func NewIndex(files []string) *Index {
+	i := &Index{files: make(map[string]bool), paths: make(map[string]string), names: make(map[string][]string)}
+	for _, file := range files {
+		i.Add(file)
+	}
+	return i
+}
+
+// Files liste les fichiers du vault dir, relatifs au vault, sans les dossiers cachés
+// (.obsidian, .trash, .git...)
+func Files(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if file != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
+// Add ajoute un fichier à l'index. Parmi des chemins qui ne diffèrent que par la casse, seul
+// le premier ajouté est trouvé sans la casse exacte.
+func (i *Index) Add(file string) {
+	i.files[file] = true
+	lower := strings.ToLower(file)
+	if _, exists := i.paths[lower]; exists {
+		return
+	}
+	i.paths[lower] = file
+	name := path.Base(lower)
+	i.names[name] = append(i.names[name], file)
+}
+
+// Files retourne les fichiers de l'index, triés
+func (i *Index) Files() []string {
+	files := make([]string, 0, len(i.files))
+	for file := range i.files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Resolve retourne le fichier visé par target dans la note source. Dans l'ordre : un chemin
+// relatif explicite (./ ou ../), un chemin depuis la racine du vault, un chemin relatif au
+// dossier de la note, puis le fichier dont le chemin se termine par target ; s'il y en a
+// plusieurs, celui du dossier de la note, puis le plus proche de la racine. L'extension
+// .md peut être omise. Une cible vide désigne la note elle-même.
+func (i *Index) Resolve(source, target string) (string, bool) {
+	if target == "" {
+		return source, true
+	}
+	dir := path.Dir(source)
+
+	if strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../") {
+		return i.exact(path.Join(dir, target))
+	}
+	if file, ok := i.exact(strings.TrimPrefix(target, "/")); ok {
+		return file, true
+	}
+	if dir != "." {
+		if file, ok := i.exact(path.Join(dir, target)); ok {
+			return file, true
+		}
+	}
+	return i.bySuffix(dir, target)
+}
+
+// candidates retourne target avec et sans l'extension .md
+func candidates(target string) []string {
+	target = strings.ToLower(target)
+	if strings.HasSuffix(target, ".md") {
+		return []string{target}
+	}
+	return []string{target + ".md", target}
+}
+
+func (i *Index) exact(target string) (string, bool) {
+	if strings.HasPrefix(target, "../") {
+		return "", false
+	}
+	// Le fichier de même casse passe avant ceux qui ne diffèrent que par la casse
+	cased := []string{target}
+	if !strings.HasSuffix(strings.ToLower(target), ".md") {
+		cased = []string{target + ".md", target}
+	}
+	for _, candidate := range cased {
+		if i.files[candidate] {
+			return candidate, true
+		}
+	}
+	for _, candidate := range candidates(target) {
+		if file, ok := i.paths[candidate]; ok {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+func (i *Index) bySuffix(dir, target string) (string, bool) {
+	for _, candidate := range candidates(strings.TrimPrefix(target, "/")) {
+		var matches []string
+		for _, file := range i.names[path.Base(candidate)] {
+			lower := strings.ToLower(file)
+			if lower == candidate || strings.HasSuffix(lower, "/"+candidate) {
+				matches = append(matches, file)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		sort.Slice(matches, func(a, b int) bool {
+			sameA, sameB := path.Dir(matches[a]) == dir, path.Dir(matches[b]) == dir
+			if sameA != sameB {
+				return sameA
+			}
+			depthA, depthB := strings.Count(matches[a], "/"), strings.Count(matches[b], "/")
+			if depthA != depthB {
+				return depthA < depthB
+			}
+			return matches[a] < matches[b]
+		})
+		return matches[0], true
+	}
+	return "", false
+}
+
+// Style est la façon dont un lien désigne sa cible
+type Style int
+
+const (
+	// Shortest est le nom du fichier seul, complété par son chemin s'il est ambigu
+	Shortest Style = iota
+	// Relative est un chemin relatif au dossier de la note
+	Relative
+	// Absolute est le chemin depuis la racine du vault
+	Absolute
+)
+
+// StyleOf retourne le style d'un lien de la note source
+func (i *Index) StyleOf(source string, link Link) Style {
+	target := link.Target
+	switch {
+	case strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../"):
+		return Relative
+	case !strings.Contains(target, "/"):
+		return Shortest
+	}
+	if dir := path.Dir(source); dir != "." && !strings.HasPrefix(target, "/") {
+		if _, ok := i.exact(path.Join(dir, target)); ok {
+			return Relative
+		}
+	}
+	return Absolute
+}
+
+// Format retourne le texte de cible d'un lien de la note source vers le fichier dest, dans le
+// style et avec l'écriture du lien d'origine (extension, encodage des liens Markdown)
+func (i *Index) Format(source, dest string, style Style, original Link) string {
+	name := dest
+	keepExt := strings.EqualFold(path.Ext(original.Target), ".md") || !strings.EqualFold(path.Ext(dest), ".md")
+	if !keepExt {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+
+	var target string
+	switch style {
+	case Relative:
+		target = relativePath(path.Dir(source), name)
+		if strings.HasPrefix(original.Target, "./") && !strings.HasPrefix(target, "../") {
+			target = "./" + target
+		}
+	case Shortest:
+		target = path.Base(name)
+		if file, ok := i.Resolve(source, target); !ok || file != dest {
+			target = name
+		}
+	default:
+		target = name
+		if strings.HasPrefix(original.Target, "/") {
+			target = "/" + target
+		}
+	}
+
+	if original.Markdown && !original.Angle {
+		target = strings.ReplaceAll(target, " ", "%20")
+	}
+	return target
+}
+
+// relativePath retourne le chemin de target depuis le dossier dir
+func relativePath(dir, target string) string {
+	if dir == "." {
+		return target
+	}
+	from := strings.Split(dir, "/")
+	to := strings.Split(target, "/")
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+	parts := make([]string, 0, len(from)-common+len(to)-common)
+	for range from[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, to[common:]...)
+	return strings.Join(parts, "/")
+}
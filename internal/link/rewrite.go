@@ -0,0 +1,99 @@
+package link
+
+import (
+	"sort"
+)
+
+// Replacement est un lien réécrit dans une note
+type Replacement struct {
+	Line     int
+	Old, New string
+}
+
+// FileRewrite est une note dont des liens changent après un déplacement
+type FileRewrite struct {
+	// Path est le chemin de la note avant le déplacement, NewPath après
+	Path, NewPath string
+	Content       []byte
+	Replacements  []Replacement
+}
+
+// Rewrite réécrit les liens des notes pour qu'ils mènent aux mêmes fichiers après les
+// déplacements moves (ancien chemin vers nouveau chemin). notes contient le contenu des notes
+// par chemin, files tous les fichiers du vault avant le déplacement. Un lien n'est réécrit que
+// s'il ne mène plus au même fichier, en gardant son style (nom seul, chemin relatif ou depuis
+// la racine). Les liens cassés ne sont pas modifiés.
+func Rewrite(notes map[string][]byte, files []string, moves map[string]string) []FileRewrite {
+	moved := func(file string) string {
+		if dest, ok := moves[file]; ok {
+			return dest
+		}
+		return file
+	}
+
+	before := NewIndex(files)
+	afterFiles := make([]string, 0, len(files))
+	for _, file := range files {
+		afterFiles = append(afterFiles, moved(file))
+	}
+	after := NewIndex(afterFiles)
+
+	paths := make([]string, 0, len(notes))
+	for path := range notes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var rewrites []FileRewrite
+	for _, path := range paths {
+		content := notes[path]
+		newPath := moved(path)
+
+		type edit struct {
+			start, end int
+			text       string
+		}
+		var edits []edit
+		var replacements []Replacement
+
+		for _, l := range Parse(content) {
+			if l.Target == "" {
+				continue
+			}
+			target, ok := before.Resolve(path, l.Target)
+			if !ok {
+				continue
+			}
+			expected := moved(target)
+			if resolved, ok := after.Resolve(newPath, l.Target); ok && resolved == expected {
+				continue
+			}
+
+			text := after.Format(newPath, expected, before.StyleOf(path, l), l)
+			edits = append(edits, edit{start: l.TargetStart, end: l.TargetEnd, text: text})
+			replacements = append(replacements, Replacement{
+				Line: l.Line,
+				Old:  string(content[l.TargetStart:l.TargetEnd]),
+				New:  text,
+			})
+		}
+		if len(edits) == 0 {
+			continue
+		}
+
+		// Les liens sont remplacés du dernier au premier pour garder les positions valides
+		sort.Slice(edits, func(a, b int) bool { return edits[a].start > edits[b].start })
+		updated := append([]byte(nil), content...)
+		for _, e := range edits {
+			updated = append(updated[:e.start], append([]byte(e.text), updated[e.end:]...)...)
+		}
+
+		rewrites = append(rewrites, FileRewrite{
+			Path:         path,
+			NewPath:      newPath,
+			Content:      updated,
+			Replacements: replacements,
+		})
+	}
+	return rewrites
+}
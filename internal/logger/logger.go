@@ -29,6 +29,36 @@ func Success(format string, args ...any) {
 	fmt.Printf("%s[SUCCESS] %s%s\n", ColorGreen, fmt.Sprintf(format, args...), ColorReset)
 }
 
+func Warning(format string, args ...any) {
+	fmt.Printf("%s[WARNING] %s%s\n", ColorYellow, fmt.Sprintf(format, args...), ColorReset)
+}
+
 func Error(format string, args ...any) {
 	fmt.Printf("%s[ERROR] %s%s\n", ColorRed, fmt.Sprintf(format, args...), ColorReset)
 }
+
+// Scope préfixe les messages du nom d'un vault, pour les opérations exécutées en parallèle.
+// Le Scope vide n'ajoute pas de préfixe.
+type Scope struct {
+	prefix string
+}
+
+func NewScope(name string) Scope {
+	return Scope{prefix: "[" + name + "] "}
+}
+
+func (s Scope) Info(format string, args ...any) {
+	Info("%s%s", s.prefix, fmt.Sprintf(format, args...))
+}
+
+func (s Scope) Success(format string, args ...any) {
+	Success("%s%s", s.prefix, fmt.Sprintf(format, args...))
+}
+
+func (s Scope) Warning(format string, args ...any) {
+	Warning("%s%s", s.prefix, fmt.Sprintf(format, args...))
+}
+
+func (s Scope) Error(format string, args ...any) {
+	Error("%s%s", s.prefix, fmt.Sprintf(format, args...))
+}
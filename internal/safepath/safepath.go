@@ -0,0 +1,166 @@
+// Package safepath garde les fichiers restaurés (archive, snapshot) dans leur dossier de
+// destination, y compris à travers les liens symboliques qu'il contient déjà
+package safepath
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxLinkHops limite le nombre de liens symboliques suivis pour résoudre une cible
+const maxLinkHops = 40
+
+// Parents vérifie qu'aucun dossier existant entre root et name (relatif, séparé par des "/")
+// n'est un lien symbolique : MkdirAll et OpenFile le suivraient hors de root. Avec self, name
+// est aussi vérifié.
+func Parents(root, name string, self bool) bool {
+	parts := strings.Split(name, "/")
+	if !self {
+		parts = parts[:len(parts)-1]
+	}
+	current := root
+	for _, part := range parts {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return true
+		}
+		if err != nil || info.Mode()&os.ModeSymlink != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Link résout la cible d'un lien symbolique name sur le disque, en suivant les liens déjà
+// présents sous root, et vérifie qu'elle reste dans root
+func Link(root, name, target string) bool {
+	if target == "" || path.IsAbs(target) || filepath.IsAbs(target) {
+		return false
+	}
+
+	var resolved []string
+	if dir := path.Dir(name); dir != "." {
+		resolved = strings.Split(dir, "/")
+	}
+	pending := strings.Split(filepath.ToSlash(target), "/")
+	missing, hops := false, 0
+	for len(pending) > 0 {
+		part := pending[0]
+		pending = pending[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			// Un dossier absent pourrait devenir un lien plus tard : ne pas remonter au-dessus
+			if missing || len(resolved) == 0 {
+				return false
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		resolved = append(resolved, part)
+		if missing {
+			continue
+		}
+		current := filepath.Join(root, filepath.FromSlash(strings.Join(resolved, "/")))
+		info, err := os.Lstat(current)
+		if err != nil {
+			missing = true
+			continue
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		next, err := os.Readlink(current)
+		if err != nil || next == "" || path.IsAbs(filepath.ToSlash(next)) || filepath.IsAbs(next) {
+			return false
+		}
+		if hops++; hops > maxLinkHops {
+			return false
+		}
+		resolved = resolved[:len(resolved)-1]
+		pending = append(strings.Split(filepath.ToSlash(next), "/"), pending...)
+	}
+	return true
+}
+
+// RemoveLink supprime file s'il s'agit d'un lien symbolique, pour ne jamais écrire à travers lui
+func RemoveLink(file string) error {
+	if info, err := os.Lstat(file); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(file); err != nil {
+			return fmt.Errorf("failed to replace link %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// Symlink est un lien symbolique à créer sous un dossier de destination
+type Symlink struct {
+	// Path est le chemin du lien, relatif à la destination et séparé par des "/"
+	Path   string
+	Target string
+}
+
+// Rejected est un lien qui n'a pas été créé, ou a été retiré, et la raison
+type Rejected struct {
+	Symlink
+	Reason string
+}
+
+// CreateLinks crée les liens sous root, à appeler une fois tous les fichiers écrits pour
+// qu'aucun ne les traverse. Un lien qui sortirait de root ou remplacerait un dossier est
+// rejeté ; les liens qu'un lien créé après eux a fait sortir de root sont retirés.
+func CreateLinks(root string, links []Symlink) ([]Rejected, error) {
+	var rejected []Rejected
+	var created []Symlink
+	for _, l := range links {
+		file := filepath.Join(root, filepath.FromSlash(l.Path))
+		if !Parents(root, l.Path, false) || !Link(root, l.Path, l.Target) {
+			rejected = append(rejected, Rejected{l, "link pointing outside the destination"})
+			continue
+		}
+
+		// Un lien ne remplace qu'un fichier ou un autre lien, jamais un dossier
+		if info, err := os.Lstat(file); err == nil {
+			if info.IsDir() {
+				rejected = append(rejected, Rejected{l, "link replacing a directory"})
+				continue
+			}
+			if err := os.Remove(file); err != nil {
+				return rejected, fmt.Errorf("failed to replace %s: %w", file, err)
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return rejected, fmt.Errorf("failed to create directory for %s: %w", file, err)
+		}
+		if err := os.Symlink(l.Target, file); err != nil {
+			return rejected, fmt.Errorf("failed to create link %s: %w", file, err)
+		}
+		created = append(created, l)
+	}
+
+	// Retirer un lien peut en invalider d'autres : vérifier jusqu'à ce que tous restent dedans
+	for changed := true; changed; {
+		changed = false
+		kept := created[:0]
+		for _, l := range created {
+			if Link(root, l.Path, l.Target) {
+				kept = append(kept, l)
+				continue
+			}
+			if err := os.Remove(filepath.Join(root, filepath.FromSlash(l.Path))); err != nil {
+				return rejected, fmt.Errorf("failed to remove link %s: %w", l.Path, err)
+			}
+			rejected = append(rejected, Rejected{l, "link pointing outside the destination"})
+			changed = true
+		}
+		created = kept
+	}
+	return rejected, nil
+}
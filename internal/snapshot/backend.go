@@ -0,0 +1,13 @@
+package snapshot
+
+import "io"
+
+// Backend stocke des objets nommés par des chemins relatifs séparés par des "/".
+// Toute destination de sauvegarde (internal/destination) satisfait cette interface.
+type Backend interface {
+	Put(name string, r io.Reader) error
+	Get(name string) (io.ReadCloser, error)
+	Exists(name string) (bool, error)
+	List(prefix string) ([]string, error)
+	Delete(name string) error
+}
@@ -0,0 +1,131 @@
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+const keyName = "key.age"
+
+// initKey génère la clé du dépôt et l'écrit chiffrée avec encrypt
+func (r *Repository) initKey(encrypt func(w io.Writer) (io.WriteCloser, error)) error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w, err := encrypt(&buf)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(key); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if err := r.backend.Put(keyName, &buf); err != nil {
+		return err
+	}
+	return r.setKey(key)
+}
+
+// loadKey lit et déchiffre la clé du dépôt
+func (r *Repository) loadKey(decrypt func(r io.Reader) (io.Reader, error)) error {
+	rc, err := r.backend.Get(keyName)
+	if err != nil {
+		return fmt.Errorf("repository key is missing: %w", err)
+	}
+	defer rc.Close()
+
+	plain, err := decrypt(rc)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt repository key: %w", err)
+	}
+
+	key, err := io.ReadAll(plain)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt repository key: %w", err)
+	}
+	return r.setKey(key)
+}
+
+func (r *Repository) setKey(key []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	r.aead = aead
+	return nil
+}
+
+// putObject compresse, chiffre si besoin, puis écrit l'objet. Retourne la taille écrite.
+func (r *Repository) putObject(name string, data []byte) (int, error) {
+	var buf bytes.Buffer
+	gw, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := gw.Write(data); err != nil {
+		return 0, err
+	}
+	if err := gw.Close(); err != nil {
+		return 0, err
+	}
+
+	out := buf.Bytes()
+	if r.aead != nil {
+		nonce := make([]byte, r.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return 0, err
+		}
+		out = r.aead.Seal(nonce, nonce, out, []byte(name))
+	}
+
+	if err := r.backend.Put(name, bytes.NewReader(out)); err != nil {
+		return 0, err
+	}
+	return len(out), nil
+}
+
+// getObject lit l'objet, le déchiffre si besoin et le décompresse
+func (r *Repository) getObject(name string) ([]byte, error) {
+	rc, err := r.backend.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.aead != nil {
+		if len(data) < r.aead.NonceSize() {
+			return nil, fmt.Errorf("object is corrupted")
+		}
+		nonce, ciphertext := data[:r.aead.NonceSize()], data[r.aead.NonceSize():]
+		if data, err = r.aead.Open(nil, nonce, ciphertext, []byte(name)); err != nil {
+			return nil, fmt.Errorf("object is corrupted or was tampered with")
+		}
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
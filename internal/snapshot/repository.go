@@ -0,0 +1,587 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/ignore"
+	"github.com/coyls/obs-cli/internal/safepath"
+)
+
+const (
+	// ChunkSize est la taille maximale d'un chunk avant compression
+	ChunkSize = 4 << 20
+
+	// IDFormat est le format des identifiants de snapshot (même format que les archives .tar.gz)
+	IDFormat = "2006-01-02_15-04-05"
+
+	repoVersion  = 1
+	configName   = "config.json"
+	chunksDir    = "chunks/"
+	snapshotsDir = "snapshots/"
+	manifestExt  = ".json.gz"
+)
+
+type repoConfig struct {
+	Version   int  `json:"version"`
+	ChunkSize int  `json:"chunk_size"`
+	Encrypted bool `json:"encrypted,omitempty"`
+}
+
+// Options configure le chiffrement du dépôt. Les chunks et manifestes sont chiffrés
+// avec une clé aléatoire, elle-même chiffrée par Encrypt lors de l'initialisation.
+type Options struct {
+	// Encrypt chiffre la clé d'un nouveau dépôt. Si nil, le dépôt n'est pas chiffré.
+	Encrypt func(w io.Writer) (io.WriteCloser, error)
+	// Decrypt déchiffre la clé d'un dépôt chiffré
+	Decrypt func(r io.Reader) (io.Reader, error)
+}
+
+// Repository est un dépôt de sauvegardes incrémentales : les fichiers sont découpés
+// en chunks adressés par leur SHA-256 et chaque snapshot ne stocke qu'un manifeste
+type Repository struct {
+	backend Backend
+	aead    cipher.AEAD
+	known   map[string]bool
+}
+
+// Exists indique si un dépôt a déjà été initialisé sur le backend
+func Exists(backend Backend) (bool, error) {
+	return backend.Exists(configName)
+}
+
+// Open ouvre le dépôt et l'initialise s'il n'existe pas encore
+func Open(backend Backend, opts Options) (*Repository, error) {
+	r := &Repository{backend: backend}
+
+	exists, err := backend.Exists(configName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	if !exists {
+		cfg := repoConfig{Version: repoVersion, ChunkSize: ChunkSize}
+		if opts.Encrypt != nil {
+			if err := r.initKey(opts.Encrypt); err != nil {
+				return nil, fmt.Errorf("failed to initialize repository key: %w", err)
+			}
+			cfg.Encrypted = true
+		}
+
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := backend.Put(configName, bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("failed to initialize repository: %w", err)
+		}
+		return r, nil
+	}
+
+	rc, err := backend.Get(configName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository config: %w", err)
+	}
+	defer rc.Close()
+
+	var cfg repoConfig
+	if err := json.NewDecoder(rc).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid repository config: %w", err)
+	}
+	if cfg.Version != repoVersion {
+		return nil, fmt.Errorf("unsupported repository version %d", cfg.Version)
+	}
+
+	if !cfg.Encrypted {
+		if opts.Encrypt != nil {
+			return nil, fmt.Errorf("repository is not encrypted, remove it or disable encryption")
+		}
+		return r, nil
+	}
+
+	if opts.Decrypt == nil {
+		return nil, fmt.Errorf("repository is encrypted")
+	}
+	if err := r.loadKey(opts.Decrypt); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func chunkName(hash string) string {
+	return chunksDir + hash[:2] + "/" + hash
+}
+
+func manifestName(id string) string {
+	return snapshotsDir + id + manifestExt
+}
+
+// IDs retourne les identifiants des snapshots, du plus récent au plus ancien
+func (r *Repository) IDs() ([]string, error) {
+	names, err := r.backend.List(snapshotsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, name := range names {
+		if strings.HasSuffix(name, manifestExt) {
+			ids = append(ids, strings.TrimSuffix(path.Base(name), manifestExt))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// Load lit le manifeste d'un snapshot
+func (r *Repository) Load(id string) (*Snapshot, error) {
+	data, err := r.getObject(manifestName(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", id, err)
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", id, err)
+	}
+	return &snap, nil
+}
+
+// Latest retourne le snapshot le plus récent, ou nil si le dépôt est vide
+func (r *Repository) Latest() (*Snapshot, error) {
+	ids, err := r.IDs()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	return r.Load(ids[0])
+}
+
+func (r *Repository) loadKnownChunks() error {
+	if r.known != nil {
+		return nil
+	}
+
+	names, err := r.backend.List(chunksDir)
+	if err != nil {
+		return fmt.Errorf("failed to list chunks: %w", err)
+	}
+
+	r.known = make(map[string]bool, len(names))
+	for _, name := range names {
+		r.known[path.Base(name)] = true
+	}
+	return nil
+}
+
+// walk parcourt la source et appelle fn avec le chemin relatif (séparateurs "/") de chaque entrée
+// qui n'est pas exclue par filter
+func walk(source string, filter *ignore.Matcher, fn func(rel, abs string, info fs.FileInfo) error) error {
+	return ignore.Walk(source, filter, func(abs string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, abs)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		return fn(filepath.ToSlash(rel), abs, info)
+	})
+}
+
+// unchanged indique si le fichier peut réutiliser les chunks du snapshot précédent
+func (r *Repository) unchanged(prev Entry, ok bool, info fs.FileInfo) bool {
+	if !ok || prev.Type != TypeFile || prev.Size != info.Size() || !prev.ModTime.Equal(info.ModTime()) {
+		return false
+	}
+	for _, hash := range prev.Chunks {
+		if !r.known[hash] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Repository) parentEntries() (map[string]Entry, error) {
+	parent, err := r.Latest()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]Entry)
+	if parent != nil {
+		for _, e := range parent.Entries {
+			entries[e.Path] = e
+		}
+	}
+	return entries, nil
+}
+
+// PendingSize estime la quantité de données à lire pour le prochain snapshot
+// (fichiers nouveaux ou modifiés depuis le dernier snapshot)
+func (r *Repository) PendingSize(source string, filter *ignore.Matcher) (int64, error) {
+	if err := r.loadKnownChunks(); err != nil {
+		return 0, err
+	}
+	previous, err := r.parentEntries()
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	err = walk(source, filter, func(rel, _ string, info fs.FileInfo) error {
+		if info.Mode().IsRegular() {
+			prev, ok := previous[rel]
+			if !r.unchanged(prev, ok, info) {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size, err
+}
+
+// Create crée un nouveau snapshot de source, sans les fichiers exclus par filter (qui peut être nil).
+// Seuls les chunks absents du dépôt sont écrits.
+func (r *Repository) Create(source string, filter *ignore.Matcher) (*Snapshot, Stats, error) {
+	var stats Stats
+
+	source, err := filepath.Abs(source)
+	if err != nil {
+		return nil, stats, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	if err := r.loadKnownChunks(); err != nil {
+		return nil, stats, err
+	}
+	previous, err := r.parentEntries()
+	if err != nil {
+		return nil, stats, err
+	}
+
+	now := time.Now()
+	snap := &Snapshot{
+		ID:     now.Format(IDFormat),
+		Time:   now,
+		Source: source,
+	}
+
+	if exists, err := r.backend.Exists(manifestName(snap.ID)); err != nil {
+		return nil, stats, err
+	} else if exists {
+		return nil, stats, fmt.Errorf("snapshot %s already exists", snap.ID)
+	}
+
+	err = walk(source, filter, func(rel, abs string, info fs.FileInfo) error {
+		entry := Entry{
+			Path:    rel,
+			Mode:    info.Mode().Perm(),
+			ModTime: info.ModTime(),
+		}
+
+		switch {
+		case info.IsDir():
+			entry.Type = TypeDir
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(abs)
+			if err != nil {
+				return fmt.Errorf("failed to read link %s: %w", abs, err)
+			}
+			entry.Type = TypeSymlink
+			entry.Link = link
+		case info.Mode().IsRegular():
+			entry.Type = TypeFile
+			entry.Size = info.Size()
+			stats.Files++
+
+			if prev, ok := previous[rel]; r.unchanged(prev, ok, info) {
+				entry.Chunks = prev.Chunks
+				stats.UnchangedFiles++
+				stats.ReusedChunks += len(prev.Chunks)
+				break
+			}
+
+			chunks, err := r.storeFile(abs, &stats)
+			if err != nil {
+				return err
+			}
+			entry.Chunks = chunks
+		default:
+			// Sockets, pipes... ne sont pas sauvegardés
+			return nil
+		}
+
+		snap.Entries = append(snap.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, stats, err
+	}
+
+	// Le manifeste est écrit en dernier : un snapshot interrompu ne laisse que des chunks orphelins
+	if err := r.saveManifest(snap); err != nil {
+		return nil, stats, err
+	}
+
+	return snap, stats, nil
+}
+
+func (r *Repository) storeFile(abs string, stats *Stats) ([]string, error) {
+	file, err := os.Open(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", abs, err)
+	}
+	defer file.Close()
+
+	var chunks []string
+	buf := make([]byte, ChunkSize)
+	for {
+		n, err := io.ReadFull(file, buf)
+		if n > 0 {
+			hash, err := r.storeChunk(buf[:n], stats)
+			if err != nil {
+				return nil, fmt.Errorf("failed to store %s: %w", abs, err)
+			}
+			chunks = append(chunks, hash)
+			stats.BytesRead += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", abs, err)
+		}
+	}
+	return chunks, nil
+}
+
+func (r *Repository) storeChunk(data []byte, stats *Stats) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if r.known[hash] {
+		stats.ReusedChunks++
+		return hash, nil
+	}
+
+	written, err := r.putObject(chunkName(hash), data)
+	if err != nil {
+		return "", err
+	}
+	stats.BytesWritten += int64(written)
+
+	r.known[hash] = true
+	stats.NewChunks++
+	return hash, nil
+}
+
+func (r *Repository) saveManifest(snap *Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if _, err := r.putObject(manifestName(snap.ID), data); err != nil {
+		return fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	return nil
+}
+
+// readChunk lit un chunk et vérifie que son contenu correspond à son hash
+func (r *Repository) readChunk(hash string) ([]byte, error) {
+	data, err := r.getObject(chunkName(hash))
+	if err != nil {
+		return nil, fmt.Errorf("chunk %s is unreadable: %w", hash, err)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != hash {
+		return nil, fmt.Errorf("chunk %s is corrupted: checksum mismatch", hash)
+	}
+	return data, nil
+}
+
+// Check vérifie que tous les chunks du snapshot existent. Si readData est vrai,
+// chaque chunk est relu et son checksum vérifié.
+func (r *Repository) Check(snap *Snapshot, readData bool) error {
+	seen := make(map[string]bool)
+	for _, e := range snap.Entries {
+		for _, hash := range e.Chunks {
+			if seen[hash] {
+				continue
+			}
+			seen[hash] = true
+
+			if readData {
+				if _, err := r.readChunk(hash); err != nil {
+					return fmt.Errorf("%s: %w", e.Path, err)
+				}
+				continue
+			}
+
+			exists, err := r.backend.Exists(chunkName(hash))
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("%s: chunk %s is missing", e.Path, hash)
+			}
+		}
+	}
+	return nil
+}
+
+// Restore restaure les entrées du snapshot dans target. Si match n'est pas nil,
+// seules les entrées pour lesquelles il retourne vrai sont restaurées.
+func (r *Repository) Restore(snap *Snapshot, target string, match func(path string) bool) (int, error) {
+	var dirs []Entry
+	var links []safepath.Symlink
+	restored := 0
+
+	for _, e := range snap.Entries {
+		if match != nil && !match(e.Path) {
+			continue
+		}
+		if !filepath.IsLocal(filepath.FromSlash(e.Path)) {
+			return restored, fmt.Errorf("unsafe path in snapshot: %s", e.Path)
+		}
+
+		dest := filepath.Join(target, filepath.FromSlash(e.Path))
+
+		// Ne jamais créer un dossier ou écrire un fichier à travers un dossier qui est un lien
+		if e.Type != TypeSymlink && !safepath.Parents(target, e.Path, e.Type == TypeDir) {
+			return restored, fmt.Errorf("unsafe path in snapshot: %s is inside a symbolic link", e.Path)
+		}
+
+		switch e.Type {
+		case TypeDir:
+			if err := os.MkdirAll(dest, 0755); err != nil {
+				return restored, fmt.Errorf("failed to create directory %s: %w", dest, err)
+			}
+			dirs = append(dirs, e)
+		case TypeSymlink:
+			// Les liens sont créés après les fichiers, qui ne peuvent donc pas les traverser
+			links = append(links, safepath.Symlink{Path: e.Path, Target: e.Link})
+		case TypeFile:
+			if err := r.restoreFile(e, dest); err != nil {
+				return restored, err
+			}
+			restored++
+		}
+	}
+
+	rejected, err := safepath.CreateLinks(target, links)
+	if err != nil {
+		return restored, err
+	}
+	if len(rejected) > 0 {
+		return restored, fmt.Errorf("unsafe %s in snapshot: %s -> %s", rejected[0].Reason, rejected[0].Path, rejected[0].Target)
+	}
+
+	// Les dates des dossiers sont appliquées en dernier, l'écriture des fichiers les modifiant
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dest := filepath.Join(target, filepath.FromSlash(dirs[i].Path))
+		os.Chmod(dest, dirs[i].Mode)
+		os.Chtimes(dest, dirs[i].ModTime, dirs[i].ModTime)
+	}
+
+	return restored, nil
+}
+
+func (r *Repository) restoreFile(e Entry, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dest, err)
+	}
+
+	// Ne jamais écrire à travers un lien symbolique existant
+	if err := safepath.RemoveLink(dest); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, e.Mode)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", dest, err)
+	}
+
+	for _, hash := range e.Chunks {
+		data, err := r.readChunk(hash)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("%s: %w", e.Path, err)
+		}
+		if _, err := file.Write(data); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write file %s: %w", dest, err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(dest, e.Mode); err != nil {
+		return err
+	}
+	return os.Chtimes(dest, e.ModTime, e.ModTime)
+}
+
+// Forget supprime le manifeste d'un snapshot. Ses chunks sont libérés par Prune.
+func (r *Repository) Forget(id string) error {
+	return r.backend.Delete(manifestName(id))
+}
+
+// Prune supprime les chunks qui ne sont plus référencés par aucun snapshot
+func (r *Repository) Prune() (int, error) {
+	ids, err := r.IDs()
+	if err != nil {
+		return 0, err
+	}
+
+	used := make(map[string]bool)
+	for _, id := range ids {
+		snap, err := r.Load(id)
+		if err != nil {
+			// Ne rien supprimer si un manifeste est illisible : ses chunks sont peut-être encore utiles
+			return 0, err
+		}
+		for _, e := range snap.Entries {
+			for _, hash := range e.Chunks {
+				used[hash] = true
+			}
+		}
+	}
+
+	names, err := r.backend.List(chunksDir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, name := range names {
+		hash := path.Base(name)
+		if used[hash] {
+			continue
+		}
+		if err := r.backend.Delete(name); err != nil {
+			return removed, err
+		}
+		if r.known != nil {
+			delete(r.known, hash)
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,179 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// memoryBackend est un dépôt en mémoire
+type memoryBackend map[string][]byte
+
+func (m memoryBackend) Put(name string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	m[name] = data
+	return err
+}
+
+func (m memoryBackend) Get(name string) (io.ReadCloser, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m memoryBackend) Exists(name string) (bool, error) {
+	_, ok := m[name]
+	return ok, nil
+}
+
+func (m memoryBackend) List(prefix string) ([]string, error) {
+	var names []string
+	for name := range m {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (m memoryBackend) Delete(name string) error {
+	delete(m, name)
+	return nil
+}
+
+func TestRestoreRejectsEscapes(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "note.md"), []byte("payload"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Open(memoryBackend{}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, _, err := repo.Create(source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload Entry
+	for _, e := range snap.Entries {
+		if e.Path == "note.md" {
+			payload = e
+		}
+	}
+	file := func(path string) Entry {
+		e := payload
+		e.Path = path
+		return e
+	}
+	link := func(path, target string) Entry {
+		return Entry{Path: path, Type: TypeSymlink, Mode: 0777 | fs.ModeSymlink, Link: target}
+	}
+
+	tests := []struct {
+		name    string
+		entries []Entry
+		// existing crée des liens sous la destination avant la restauration
+		existing map[string]string
+		wantErr  bool
+		// want sont des chemins restaurés qui doivent exister
+		want []string
+	}{
+		{
+			name:    "absolute link",
+			entries: []Entry{link("a", filepath.Join(os.TempDir(), "outside")), file("a/x.md")},
+			wantErr: true,
+		},
+		{
+			name:    "link outside the target",
+			entries: []Entry{link("up", "../outside"), file("up/x.md")},
+			wantErr: true,
+		},
+		{
+			name:    "chained links",
+			entries: []Entry{link("a/b", ".."), link("a/b/c", ".."), file("a/b/c/x.md")},
+			wantErr: true,
+		},
+		{
+			name:     "existing link in the target",
+			existing: map[string]string{"vault": "../outside"},
+			entries:  []Entry{file("vault/x.md")},
+			wantErr:  true,
+		},
+		{
+			name:     "existing link replaced by a file",
+			existing: map[string]string{"note.md": "../outside/victim.md"},
+			entries:  []Entry{file("note.md")},
+			want:     []string{"note.md"},
+		},
+		{
+			name:    "links inside the target",
+			entries: []Entry{file("notes/a.md"), link("notes/link.md", "a.md"), link("sub/up", "../notes")},
+			want:    []string{"notes/a.md", "notes/link.md", "sub/up"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			target := filepath.Join(root, "target")
+			outside := filepath.Join(root, "outside")
+			for _, dir := range []string{target, outside} {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(outside, "victim.md"), []byte("victim"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for name, link := range tt.existing {
+				if err := os.Symlink(link, filepath.Join(target, name)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := repo.Restore(&Snapshot{Entries: tt.entries}, target, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Restore error = %v, want error %v", err, tt.wantErr)
+			}
+
+			entries, _ := os.ReadDir(outside)
+			data, _ := os.ReadFile(filepath.Join(outside, "victim.md"))
+			if len(entries) != 1 || string(data) != "victim" {
+				t.Errorf("restore wrote outside the target: %d files, victim.md = %q", len(entries), data)
+			}
+			escaped := filepath.WalkDir(target, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || d.Type()&fs.ModeSymlink == 0 {
+					return err
+				}
+				rel, _ := filepath.Rel(target, p)
+				if _, ok := tt.existing[filepath.ToSlash(rel)]; ok {
+					return nil
+				}
+				resolved, err := filepath.EvalSymlinks(p)
+				if err != nil {
+					return nil
+				}
+				realTarget, _ := filepath.EvalSymlinks(target)
+				if rel, err := filepath.Rel(realTarget, resolved); err != nil || (rel != "." && !filepath.IsLocal(rel)) {
+					return errors.New(p + " -> " + resolved)
+				}
+				return nil
+			})
+			if escaped != nil {
+				t.Errorf("restored link leads outside the target: %v", escaped)
+			}
+			for _, want := range tt.want {
+				if _, err := os.Lstat(filepath.Join(target, filepath.FromSlash(want))); err != nil {
+					t.Errorf("%s not restored: %v", want, err)
+				}
+			}
+		})
+	}
+}
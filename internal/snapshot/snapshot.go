@@ -0,0 +1,50 @@
+package snapshot
+
+import (
+	"io/fs"
+	"time"
+)
+
+const (
+	TypeDir     = "dir"
+	TypeFile    = "file"
+	TypeSymlink = "symlink"
+)
+
+// Entry décrit un fichier, dossier ou lien symbolique d'un snapshot
+type Entry struct {
+	Path    string      `json:"path"`
+	Type    string      `json:"type"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mtime"`
+	Size    int64       `json:"size,omitempty"`
+	Chunks  []string    `json:"chunks,omitempty"`
+	Link    string      `json:"link,omitempty"`
+}
+
+// Snapshot est le manifeste d'une sauvegarde : il référence les chunks de chaque fichier
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Entries []Entry   `json:"entries"`
+}
+
+// Size retourne la taille totale des fichiers du snapshot
+func (s *Snapshot) Size() int64 {
+	var size int64
+	for _, e := range s.Entries {
+		size += e.Size
+	}
+	return size
+}
+
+// Stats résume le travail effectué lors de la création d'un snapshot
+type Stats struct {
+	Files          int
+	UnchangedFiles int
+	NewChunks      int
+	ReusedChunks   int
+	BytesRead      int64
+	BytesWritten   int64
+}
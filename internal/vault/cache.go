@@ -0,0 +1,117 @@
+package vault
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/coyls/obs-cli/internal/crypt"
+)
+
+// cacheVersion change quand le format des notes analysées change, ce qui invalide les caches existants
+const cacheVersion = 1
+
+// cacheFile est l'index d'un vault enregistré entre deux commandes, compressé avec gzip
+// (dont la somme de contrôle détecte un fichier corrompu)
+type cacheFile struct {
+	Version int     `json:"version"`
+	Vault   string  `json:"vault"`
+	Notes   []*Note `json:"notes"`
+}
+
+// CachePath retourne le fichier de cache de l'index du vault, dans le dossier de cache de l'utilisateur
+func (v *Vault) CachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(v.Path))
+	return filepath.Join(dir, "obs-cli", "index", hex.EncodeToString(sum[:8])+".json.gz"), nil
+}
+
+// readCache retourne les notes du cache par chemin. Il retourne nil sans erreur si le cache
+// n'existe pas, et une erreur s'il est illisible ou ne correspond pas au vault.
+func (v *Vault) readCache(path string) (map[string]*Note, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("corrupted index cache %s: %w", path, err)
+	}
+	// Tout lire avant de décoder pour que gzip vérifie la somme de contrôle
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("corrupted index cache %s: %w", path, err)
+	}
+
+	var cache cacheFile
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("corrupted index cache %s: %w", path, err)
+	}
+	if cache.Version != cacheVersion {
+		return nil, fmt.Errorf("index cache %s has version %d, expected %d", path, cache.Version, cacheVersion)
+	}
+	if cache.Vault != v.Path {
+		return nil, fmt.Errorf("index cache %s belongs to %s", path, cache.Vault)
+	}
+
+	notes := make(map[string]*Note, len(cache.Notes))
+	for _, note := range cache.Notes {
+		if note == nil || note.Path == "" {
+			return nil, fmt.Errorf("corrupted index cache %s: note without path", path)
+		}
+		notes[note.Path] = note
+	}
+	return notes, nil
+}
+
+// writeCache enregistre les notes de l'index, sauf les notes privées dont le contenu analysé
+// (titres, liens, propriétés) ne doit pas être copié en clair hors du vault
+func (v *Vault) writeCache(path string, notes []*Note) error {
+	public := make([]*Note, 0, len(notes))
+	for _, note := range notes {
+		if !note.Private {
+			public = append(public, note)
+		}
+	}
+	data, err := json.Marshal(cacheFile{Version: cacheVersion, Vault: v.Path, Notes: public})
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	// Le cache n'est lisible que par l'utilisateur, y compris s'il a été créé avant
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return err
+	}
+	if err := crypt.WriteFileAtomic(path, buf.Bytes()); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
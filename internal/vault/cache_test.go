@@ -0,0 +1,362 @@
+package vault
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCacheSkipsPrivateNotes(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	v := &Vault{Name: "test", Path: t.TempDir()}
+	for name, content := range map[string]string{
+		"Public.md":  "# Public heading\n",
+		"Private.md": "---\ntags: [private]\n---\n# Secret heading\n",
+		"Inline.md":  "# Another secret\n#private\n",
+	} {
+		if err := os.WriteFile(v.Abs(name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index, err := v.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Stats.CacheError != nil {
+		t.Fatalf("cache error: %v", index.Stats.CacheError)
+	}
+
+	path, err := v.CachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []struct {
+		path string
+		mode os.FileMode
+	}{{path, 0600}, {filepath.Dir(path), 0700}} {
+		info, err := os.Stat(p.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != p.mode {
+			t.Errorf("%s has mode %v, want %v", p.path, info.Mode().Perm(), p.mode)
+		}
+	}
+
+	cached, err := v.readCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cached["Public.md"]; !ok || len(cached) != 1 {
+		t.Errorf("cached notes %v, want only Public.md", keys(cached))
+	}
+
+	// Les notes privées sont analysées à chaque chargement, sans réécrire le cache
+	index, err = v.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index.Note("Private.md"); index.Stats.Parsed != 2 || !ok {
+		t.Errorf("parsed %d notes, want the 2 private notes", index.Stats.Parsed)
+	}
+}
+
+func keys(notes map[string]*Note) string {
+	var names []string
+	for name := range notes {
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// newCacheVault crée un vault temporaire avec files et un dossier de cache qui lui est propre
+func newCacheVault(t *testing.T, files map[string]string) *Vault {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	v := &Vault{Name: "test", Path: t.TempDir()}
+	for name, content := range files {
+		writeNote(t, v, name, content)
+	}
+	return v
+}
+
+func writeNote(t *testing.T, v *Vault, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(v.Abs(name)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(v.Abs(name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Une date fixe distingue les modifications de même taille faites dans la même seconde
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(v.Abs(name), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var cacheNotes = map[string]string{
+	"A.md":        "# A\n",
+	"B.md":        "# B\n",
+	"Folder/C.md": "# C\n[[A]]\n",
+}
+
+func TestLoadIncremental(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(t *testing.T, v *Vault)
+		parsed  int
+		removed int
+		notes   int
+		// heading est le titre attendu de A.md après le changement
+		heading string
+	}{
+		{
+			name:    "unchanged",
+			change:  func(*testing.T, *Vault) {},
+			notes:   3,
+			heading: "A",
+		},
+		{
+			name:    "size changed",
+			change:  func(t *testing.T, v *Vault) { writeNote(t, v, "A.md", "# A changed\n") },
+			parsed:  1,
+			notes:   3,
+			heading: "A changed",
+		},
+		{
+			name: "modification time changed",
+			change: func(t *testing.T, v *Vault) {
+				writeNote(t, v, "A.md", "# Z\n")
+				modTime := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+				if err := os.Chtimes(v.Abs("A.md"), modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			},
+			parsed:  1,
+			notes:   3,
+			heading: "Z",
+		},
+		{
+			name:    "same size and time",
+			change:  func(t *testing.T, v *Vault) { writeNote(t, v, "A.md", "# Z\n") },
+			notes:   3,
+			heading: "A",
+		},
+		{
+			name: "notes deleted",
+			change: func(t *testing.T, v *Vault) {
+				os.Remove(v.Abs("B.md"))
+				os.Remove(v.Abs("Folder/C.md"))
+			},
+			removed: 2,
+			notes:   1,
+			heading: "A",
+		},
+		{
+			name: "note and attachment added",
+			change: func(t *testing.T, v *Vault) {
+				writeNote(t, v, "D.md", "# D\n")
+				writeNote(t, v, "image.png", "PNG")
+			},
+			parsed:  1,
+			notes:   4,
+			heading: "A",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newCacheVault(t, cacheNotes)
+			if index, err := v.Load(); err != nil || index.Stats.Parsed != len(cacheNotes) {
+				t.Fatalf("first load: %v, %+v", err, index.Stats)
+			}
+
+			tt.change(t, v)
+			index, err := v.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			stats := index.Stats
+			if stats.CacheError != nil || stats.Parsed != tt.parsed || stats.Removed != tt.removed || stats.Notes != tt.notes {
+				t.Errorf("stats = %+v, want %d parsed, %d removed, %d notes", stats, tt.parsed, tt.removed, tt.notes)
+			}
+			if note, ok := index.Note("A.md"); !ok || len(note.Headings) == 0 || note.Headings[0].Text != tt.heading {
+				t.Errorf("A.md = %+v, want heading %q", note, tt.heading)
+			}
+
+			// Le cache mis à jour est relu sans rien analyser
+			if index, err := v.Load(); err != nil || index.Stats.Parsed != 0 || index.Stats.Removed != 0 {
+				t.Errorf("reload: %v, %+v", err, index.Stats)
+			}
+		})
+	}
+}
+
+// gzipJSON compresse la valeur JSON d'un faux cache
+func gzipJSON(t *testing.T, value any) []byte {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write(data)
+	writer.Close()
+	return buf.Bytes()
+}
+
+func TestLoadCorruptedCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, v *Vault, valid []byte) []byte
+	}{
+		{
+			name:    "truncated",
+			corrupt: func(_ *testing.T, _ *Vault, valid []byte) []byte { return valid[:len(valid)/2] },
+		},
+		{
+			name: "checksum mismatch",
+			corrupt: func(_ *testing.T, _ *Vault, valid []byte) []byte {
+				data := append([]byte(nil), valid...)
+				data[len(data)-5] ^= 0xff
+				return data
+			},
+		},
+		{
+			name:    "garbage",
+			corrupt: func(*testing.T, *Vault, []byte) []byte { return []byte("not a cache") },
+		},
+		{
+			name: "invalid JSON",
+			corrupt: func(t *testing.T, _ *Vault, _ []byte) []byte {
+				var buf bytes.Buffer
+				writer := gzip.NewWriter(&buf)
+				writer.Write([]byte("{\"version\":"))
+				writer.Close()
+				return buf.Bytes()
+			},
+		},
+		{
+			name: "version mismatch",
+			corrupt: func(t *testing.T, v *Vault, _ []byte) []byte {
+				return gzipJSON(t, cacheFile{Version: cacheVersion + 1, Vault: v.Path})
+			},
+		},
+		{
+			name: "vault mismatch",
+			corrupt: func(t *testing.T, _ *Vault, _ []byte) []byte {
+				return gzipJSON(t, cacheFile{Version: cacheVersion, Vault: "/another/vault"})
+			},
+		},
+		{
+			name: "note without path",
+			corrupt: func(t *testing.T, v *Vault, _ []byte) []byte {
+				return gzipJSON(t, cacheFile{Version: cacheVersion, Vault: v.Path, Notes: []*Note{{}}})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newCacheVault(t, cacheNotes)
+			if _, err := v.Load(); err != nil {
+				t.Fatal(err)
+			}
+			path, err := v.CachePath()
+			if err != nil {
+				t.Fatal(err)
+			}
+			valid, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.corrupt(t, v, valid), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			index, err := v.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if index.Stats.CacheError == nil {
+				t.Error("corrupted cache not reported")
+			}
+			if index.Stats.Parsed != len(cacheNotes) || index.Stats.Notes != len(cacheNotes) {
+				t.Errorf("stats = %+v, want every note parsed", index.Stats)
+			}
+
+			cached, err := v.readCache(path)
+			if err != nil || len(cached) != len(cacheNotes) {
+				t.Errorf("cache not rewritten: %v, %d notes", err, len(cached))
+			}
+		})
+	}
+}
+
+func TestRebuildIgnoresCache(t *testing.T) {
+	v := newCacheVault(t, cacheNotes)
+	if _, err := v.Load(); err != nil {
+		t.Fatal(err)
+	}
+	path, err := v.CachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Un cache valide mais faux, que Load croirait : A.md a la même taille et la même date
+	cached, err := v.readCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached["A.md"].Headings = []Heading{{Level: 1, Text: "Stale", Line: 1}}
+	var notes []*Note
+	for _, note := range cached {
+		notes = append(notes, note)
+	}
+	if err := v.writeCache(path, notes); err != nil {
+		t.Fatal(err)
+	}
+	if index, err := v.Load(); err != nil || index.Stats.Parsed != 0 {
+		t.Fatalf("load: %v, %+v", err, index.Stats)
+	}
+
+	index, err := v.Rebuild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Stats.CacheError != nil || index.Stats.Parsed != len(cacheNotes) {
+		t.Errorf("stats = %+v, want every note parsed", index.Stats)
+	}
+	if note, _ := index.Note("A.md"); note.Headings[0].Text != "A" {
+		t.Errorf("rebuilt A.md heading = %q, want A", note.Headings[0].Text)
+	}
+
+	// Le cache est remplacé par l'index reconstruit
+	reloaded, err := v.Load()
+	if err != nil || reloaded.Stats.Parsed != 0 {
+		t.Fatalf("load after rebuild: %v, %+v", err, reloaded.Stats)
+	}
+	if note, _ := reloaded.Note("A.md"); note.Headings[0].Text != "A" {
+		t.Errorf("A.md heading after rebuild = %q, want A", note.Headings[0].Text)
+	}
+
+	// Même s'il est illisible
+	if err := os.WriteFile(path, []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if index, err := v.Rebuild(); err != nil || index.Stats.CacheError != nil {
+		t.Errorf("rebuild over a corrupted cache: %v, %v", err, index.Stats.CacheError)
+	}
+}
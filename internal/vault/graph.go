@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"path"
+	"sort"
+)
+
+// Graph est le graphe des liens du vault : un nœud par note, et par pièce jointe liée si
+// demandé, une arête par couple de fichiers liés
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Node est un fichier du graphe, identifié par son chemin relatif au vault
+type Node struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Attachment bool     `json:"attachment,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// Edge relie une note à un fichier qu'elle cite Links fois, dont Embeds fois en l'intégrant
+type Edge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Links  int    `json:"links"`
+	Embeds int    `json:"embeds,omitempty"`
+}
+
+// Graph construit le graphe des liens résolus entre les notes, et vers les pièces jointes si
+// attachments est vrai. Les liens d'une note vers elle-même sont ignorés.
+func (i *Index) Graph(attachments bool) *Graph {
+	graph := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+	linked := make(map[string]bool)
+	edges := make(map[[2]string]*Edge)
+
+	for _, note := range i.Notes() {
+		graph.Nodes = append(graph.Nodes, Node{ID: note.Path, Name: note.Name(), Tags: note.Tags})
+
+		for _, l := range note.Links {
+			if l.Target == "" {
+				continue
+			}
+			target, ok := i.Resolve(note.Path, l.Target)
+			if !ok || target == note.Path {
+				continue
+			}
+			if !IsNote(target) {
+				if !attachments {
+					continue
+				}
+				linked[target] = true
+			}
+
+			key := [2]string{note.Path, target}
+			edge, ok := edges[key]
+			if !ok {
+				edge = &Edge{Source: note.Path, Target: target}
+				edges[key] = edge
+			}
+			edge.Links++
+			if l.Embed {
+				edge.Embeds++
+			}
+		}
+	}
+
+	for _, file := range i.Files() {
+		if linked[file] {
+			graph.Nodes = append(graph.Nodes, Node{ID: file, Name: path.Base(file), Attachment: true})
+		}
+	}
+	sort.Slice(graph.Nodes, func(a, b int) bool { return graph.Nodes[a].ID < graph.Nodes[b].ID })
+
+	for _, edge := range edges {
+		graph.Edges = append(graph.Edges, *edge)
+	}
+	sort.Slice(graph.Edges, func(a, b int) bool {
+		if graph.Edges[a].Source != graph.Edges[b].Source {
+			return graph.Edges[a].Source < graph.Edges[b].Source
+		}
+		return graph.Edges[a].Target < graph.Edges[b].Target
+	})
+	return graph
+}
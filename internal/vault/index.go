@@ -0,0 +1,225 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/link"
+)
+
+// Index contient les fichiers d'un vault et ses notes analysées
+type Index struct {
+	Vault *Vault
+	// Stats décrit le chargement de l'index depuis le cache
+	Stats Stats
+	// files sont tous les fichiers du vault, pièces jointes comprises
+	files []string
+	notes map[string]*Note
+	links *link.Index
+	// backlinks associe chaque fichier aux liens qui y mènent, calculé à la première demande
+	backlinks map[string][]Reference
+}
+
+// Reference est un lien d'une note vers un fichier du vault
+type Reference struct {
+	// Source est la note qui contient le lien
+	Source string
+	Link   link.Link
+}
+
+// Stats décrit le chargement d'un index
+type Stats struct {
+	Notes int
+	// Parsed est le nombre de notes analysées, absentes du cache ou modifiées depuis
+	Parsed int
+	// Removed est le nombre de notes du cache qui n'existent plus
+	Removed int
+	// CacheError explique pourquoi le cache n'a pas pu être lu, toutes les notes étant alors
+	// analysées, ou enregistré
+	CacheError error
+}
+
+// Load charge l'index du vault depuis le cache, en n'analysant que les notes dont la taille ou
+// la date de modification ont changé, puis met le cache à jour. Un cache illisible est
+// ignoré : toutes les notes sont alors analysées.
+func (v *Vault) Load() (*Index, error) {
+	return v.load(true)
+}
+
+// Rebuild analyse toutes les notes du vault sans lire le cache, puis le remplace
+func (v *Vault) Rebuild() (*Index, error) {
+	return v.load(false)
+}
+
+func (v *Vault) load(useCache bool) (*Index, error) {
+	files, err := v.Files()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vault files: %w", err)
+	}
+
+	cachePath, cacheErr := v.CachePath()
+	var cached map[string]*Note
+	if cacheErr == nil && useCache {
+		cached, cacheErr = v.readCache(cachePath)
+	}
+
+	var stats Stats
+	// changed indique que le cache ne correspond plus aux notes ; les notes privées, qui n'y
+	// sont jamais enregistrées, ne le modifient pas
+	changed := cached == nil
+	notes := make(map[string]*Note)
+	for _, file := range files {
+		if !IsNote(file) {
+			continue
+		}
+		info, err := os.Stat(v.Abs(file))
+		if err != nil {
+			return nil, err
+		}
+		if note, ok := cached[file]; ok && note.Size == info.Size() && note.ModTime.Equal(info.ModTime()) {
+			notes[file] = note
+			continue
+		}
+		note, err := v.parseFile(file, info)
+		if err != nil {
+			return nil, err
+		}
+		notes[file] = note
+		stats.Parsed++
+		if _, ok := cached[file]; ok || !note.Private {
+			changed = true
+		}
+	}
+	for file := range cached {
+		if _, ok := notes[file]; !ok {
+			stats.Removed++
+			changed = true
+		}
+	}
+	stats.Notes = len(notes)
+
+	index := NewIndex(v, files, notes)
+	if cachePath != "" && changed {
+		if err := v.writeCache(cachePath, index.Notes()); err != nil && cacheErr == nil {
+			cacheErr = fmt.Errorf("failed to write index cache: %w", err)
+		}
+	}
+	stats.CacheError = cacheErr
+	index.Stats = stats
+	return index, nil
+}
+
+// ParseFile lit et analyse la note rel du vault
+func (v *Vault) ParseFile(rel string) (*Note, error) {
+	info, err := os.Stat(v.Abs(rel))
+	if err != nil {
+		return nil, err
+	}
+	return v.parseFile(rel, info)
+}
+
+func (v *Vault) parseFile(rel string, info os.FileInfo) (*Note, error) {
+	content, err := os.ReadFile(v.Abs(rel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	note := Parse(rel, content)
+	note.ModTime = info.ModTime()
+	note.Size = info.Size()
+	return note, nil
+}
+
+// NewIndex construit l'index d'un vault à partir de ses fichiers et de ses notes déjà analysées
+func NewIndex(v *Vault, files []string, notes map[string]*Note) *Index {
+	return &Index{Vault: v, files: files, notes: notes, links: link.NewIndex(files)}
+}
+
+// IsNote indique si un fichier est une note Markdown
+func IsNote(file string) bool {
+	return strings.EqualFold(path.Ext(file), ".md")
+}
+
+// Files retourne tous les fichiers du vault, pièces jointes comprises
+func (i *Index) Files() []string {
+	return i.files
+}
+
+// Notes retourne les notes du vault, triées par chemin
+func (i *Index) Notes() []*Note {
+	notes := make([]*Note, 0, len(i.notes))
+	for _, note := range i.notes {
+		notes = append(notes, note)
+	}
+	sort.Slice(notes, func(a, b int) bool { return notes[a].Path < notes[b].Path })
+	return notes
+}
+
+// Note retourne la note de chemin rel, relatif au vault
+func (i *Index) Note(rel string) (*Note, bool) {
+	note, ok := i.notes[rel]
+	return note, ok
+}
+
+// Find retourne la note désignée comme dans un lien depuis la racine du vault : par son chemin
+// ou son nom, l'extension .md pouvant être omise
+func (i *Index) Find(name string) (*Note, bool) {
+	file, ok := i.links.Resolve("", name)
+	if !ok {
+		return nil, false
+	}
+	return i.Note(file)
+}
+
+// Resolve retourne le fichier visé par un lien de la note source, comme Obsidian le résout
+func (i *Index) Resolve(source, target string) (string, bool) {
+	return i.links.Resolve(source, target)
+}
+
+// LinkText retourne le texte de cible d'un lien l de la note source pour qu'il mène au fichier
+// dest, dans le même style que l (nom seul, chemin relatif ou depuis la racine)
+func (i *Index) LinkText(source, dest string, l link.Link) string {
+	return i.links.Format(source, dest, i.links.StyleOf(source, l), l)
+}
+
+// Backlinks retourne les liens des notes du vault qui mènent au fichier rel, triés par note
+func (i *Index) Backlinks(rel string) []Reference {
+	if i.backlinks == nil {
+		i.backlinks = make(map[string][]Reference)
+		for _, note := range i.Notes() {
+			for _, l := range note.Links {
+				if l.Target == "" {
+					continue
+				}
+				if target, ok := i.Resolve(note.Path, l.Target); ok {
+					i.backlinks[target] = append(i.backlinks[target], Reference{Source: note.Path, Link: l})
+				}
+			}
+		}
+	}
+	return i.backlinks[rel]
+}
+
+// Tagged retourne les notes qui portent le tag ou l'un de ses sous-tags
+func (i *Index) Tagged(tag string) []*Note {
+	var notes []*Note
+	for _, note := range i.Notes() {
+		if note.HasTag(tag) {
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// Tags retourne le nombre de notes de chaque tag
+func (i *Index) Tags() map[string]int {
+	counts := make(map[string]int)
+	for _, note := range i.notes {
+		for _, tag := range note.Tags {
+			counts[strings.ToLower(tag)]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,167 @@
+package vault
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadTestVault(t *testing.T) *Index {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	path, err := filepath.Abs(testVault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := (&Vault{Name: "test", Path: path}).Rebuild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+func TestIndexFiles(t *testing.T) {
+	index := loadTestVault(t)
+	want := []string{
+		"Archive/2023/Alpha.md",
+		"Archive/2023/Notes.md",
+		"Beta.md",
+		"Home.md",
+		"Projects/Alpha.md",
+		"Projects/Plan B.md",
+		"assets/logo.png",
+		"diagram.png",
+	}
+	if files := index.Files(); !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %q, want %q", files, want)
+	}
+	if len(index.Notes()) != 6 {
+		t.Errorf("%d notes, want 6", len(index.Notes()))
+	}
+}
+
+func TestIndexResolve(t *testing.T) {
+	index := loadTestVault(t)
+	tests := []struct {
+		source, target string
+		want           string
+	}{
+		{"Home.md", "Projects/Alpha", "Projects/Alpha.md"},
+		{"Home.md", "Projects/Alpha.md", "Projects/Alpha.md"},
+		{"Home.md", "/Projects/Alpha", "Projects/Alpha.md"},
+		{"Home.md", "Beta", "Beta.md"},
+		{"Home.md", "beta", "Beta.md"},
+		// Le plus proche de la racine parmi les notes de même nom
+		{"Home.md", "Alpha", "Projects/Alpha.md"},
+		{"Home.md", "2023/Alpha", "Archive/2023/Alpha.md"},
+		// Celui du dossier de la note d'abord
+		{"Archive/2023/Notes.md", "Alpha", "Archive/2023/Alpha.md"},
+		{"Archive/2023/Notes.md", "Plan B", "Projects/Plan B.md"},
+		{"Projects/Plan B.md", "Alpha", "Projects/Alpha.md"},
+		{"Projects/Alpha.md", "../Home", "Home.md"},
+		{"Projects/Alpha.md", "./Plan B", "Projects/Plan B.md"},
+		{"Home.md", "diagram.png", "diagram.png"},
+		{"Home.md", "logo.png", "assets/logo.png"},
+		{"Home.md", "", "Home.md"},
+		{"Home.md", "Missing", ""},
+		{"Home.md", "../Home", ""},
+		{"Home.md", "workspace.json", ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := index.Resolve(tt.source, tt.target)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, %v, want %q", tt.source, tt.target, got, ok, tt.want)
+		}
+	}
+}
+
+func TestIndexBacklinks(t *testing.T) {
+	index := loadTestVault(t)
+	type ref struct {
+		source, target string
+		line           int
+	}
+	tests := []struct {
+		file string
+		want []ref
+	}{
+		{
+			// Le lien [[#Links]] de la note vers elle-même n'est pas un rétrolien
+			file: "Home.md",
+			want: []ref{
+				{"Beta.md", "Home", 6},
+				{"Projects/Alpha.md", "../Home", 11},
+				{"Projects/Alpha.md", "Home", 11},
+			},
+		},
+		{
+			file: "Projects/Alpha.md",
+			want: []ref{
+				{"Home.md", "Projects/Alpha", 7},
+				{"Home.md", "Alpha", 7},
+				{"Projects/Plan B.md", "Alpha", 5},
+			},
+		},
+		{
+			file: "Archive/2023/Alpha.md",
+			want: []ref{
+				{"Archive/2023/Notes.md", "Alpha", 1},
+				{"Projects/Plan B.md", "Archive/2023/Alpha", 6},
+			},
+		},
+		{
+			file: "Projects/Plan B.md",
+			want: []ref{
+				{"Archive/2023/Notes.md", "Plan B", 1},
+				{"Home.md", "Projects/Plan B.md", 8},
+			},
+		},
+		{file: "diagram.png", want: []ref{{"Home.md", "diagram.png", 7}}},
+		{file: "assets/logo.png", want: []ref{{"Home.md", "assets/logo.png", 8}}},
+		{file: "Beta.md", want: []ref{{"Home.md", "Beta", 7}}},
+		{file: "Archive/2023/Notes.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var got []ref
+			for _, r := range index.Backlinks(tt.file) {
+				got = append(got, ref{r.Source, r.Link.Target, r.Link.Line})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("backlinks = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexTagged(t *testing.T) {
+	index := loadTestVault(t)
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{"project", []string{"Archive/2023/Alpha.md", "Home.md", "Projects/Alpha.md"}},
+		{"#project", []string{"Archive/2023/Alpha.md", "Home.md", "Projects/Alpha.md"}},
+		{"project/alpha", []string{"Projects/Alpha.md"}},
+		{"Project/Alpha", []string{"Projects/Alpha.md"}},
+		{"area", []string{"Home.md"}},
+		{"beta", []string{"Beta.md"}},
+		{"proj", nil},
+		{"ignored", nil},
+		{"2024", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, note := range index.Tagged(tt.tag) {
+			got = append(got, note.Path)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tagged(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package vault
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/coyls/obs-cli/internal/crypt"
+	"github.com/coyls/obs-cli/internal/link"
+	"gopkg.in/yaml.v3"
+)
+
+// Note est le contenu analysé d'une note Markdown
+type Note struct {
+	// Path est le chemin de la note relatif au vault, séparé par des "/"
+	Path    string
+	ModTime time.Time
+	Size    int64
+	// Frontmatter contient les propriétés YAML de la note ; nil sans frontmatter ou s'il est invalide
+	Frontmatter map[string]any `json:",omitempty"`
+	// FrontmatterError est l'erreur d'analyse d'un frontmatter invalide
+	FrontmatterError string      `json:",omitempty"`
+	Aliases          []string    `json:",omitempty"`
+	Tags             []string    `json:",omitempty"`
+	Headings         []Heading   `json:",omitempty"`
+	Blocks           []Block     `json:",omitempty"`
+	Links            []link.Link `json:",omitempty"`
+	// Encrypted indique une note chiffrée par obs-cli : seul son frontmatter est analysé
+	Encrypted bool `json:",omitempty"`
+	// Private indique une note privée non chiffrée, qui n'est jamais enregistrée dans le cache
+	Private bool `json:"-"`
+}
+
+// Heading est un titre de la note
+type Heading struct {
+	Level int
+	Text  string
+	Line  int
+}
+
+// Block est un bloc de la note désigné par un identifiant ^id
+type Block struct {
+	ID   string
+	Line int
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	blockPattern   = regexp.MustCompile(`(?:^|\s)\^([A-Za-z0-9-]+)[ \t]*$`)
+	// Un tag contient au moins un caractère qui n'est pas un chiffre
+	tagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+	inlineCode = regexp.MustCompile("`+[^`\n]*`+")
+)
+
+// Name retourne le nom de la note, sans dossier ni extension
+func (n *Note) Name() string {
+	return strings.TrimSuffix(path.Base(n.Path), path.Ext(n.Path))
+}
+
+// Parse analyse le contenu d'une note : frontmatter, titres, blocs, tags et liens
+func Parse(rel string, content []byte) *Note {
+	note := &Note{Path: rel, Size: int64(len(content))}
+
+	front, body := crypt.SplitFrontmatter(content)
+	if front != nil {
+		note.parseFrontmatter(front)
+	}
+	if crypt.IsEncrypted(content) {
+		note.Encrypted = true
+		note.Links = link.Parse(front)
+		return note
+	}
+	note.Links = link.Parse(content)
+	note.Private = crypt.IsPrivate(content)
+
+	firstLine := bytes.Count(front, []byte("\n")) + 1
+	inCode := false
+	for i, line := range strings.Split(string(body), "\n") {
+		number := firstLine + i
+		line = strings.TrimRight(line, "\r")
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		line = inlineCode.ReplaceAllStringFunc(line, func(code string) string {
+			return strings.Repeat(" ", len(code))
+		})
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			note.Headings = append(note.Headings, Heading{Level: len(m[1]), Text: m[2], Line: number})
+		}
+		if m := blockPattern.FindStringSubmatch(line); m != nil {
+			note.Blocks = append(note.Blocks, Block{ID: m[1], Line: number})
+		}
+		for _, m := range tagPattern.FindAllStringSubmatch(line, -1) {
+			note.addTag(m[1])
+		}
+	}
+	return note
+}
+
+// parseFrontmatter lit les propriétés, les alias et les tags du frontmatter
+func (n *Note) parseFrontmatter(front []byte) {
+	inner := bytes.TrimSuffix(bytes.TrimSpace(front), []byte("---"))
+	inner = bytes.TrimPrefix(inner, []byte("---"))
+
+	var properties map[string]any
+	if err := yaml.Unmarshal(inner, &properties); err != nil {
+		n.FrontmatterError = err.Error()
+		return
+	}
+	n.Frontmatter = normalize(properties).(map[string]any)
+
+	for _, key := range []string{"aliases", "alias"} {
+		n.Aliases = append(n.Aliases, listProperty(properties[key], ",")...)
+	}
+	for _, key := range []string{"tags", "tag"} {
+		for _, tag := range listProperty(properties[key], ", ") {
+			n.addTag(strings.TrimPrefix(tag, "#"))
+		}
+	}
+}
+
+// normalize convertit les valeurs YAML dans les types qu'elles ont une fois relues depuis le
+// cache JSON : dates en texte, nombres en float64, clés en texte
+func normalize(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalize(item)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	case time.Time:
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format(time.RFC3339)
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return value
+}
+
+// listProperty retourne les valeurs d'une propriété liste, ou d'un texte séparé par separators
+func listProperty(value any, separators string) []string {
+	var values []string
+	switch v := value.(type) {
+	case string:
+		for _, item := range strings.FieldsFunc(v, func(r rune) bool { return strings.ContainsRune(separators, r) }) {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				values = append(values, strings.TrimSpace(s))
+			}
+		}
+	}
+	return values
+}
+
+// addTag ajoute un tag s'il n'est pas déjà présent, sans tenir compte de la casse
+func (n *Note) addTag(tag string) {
+	if tag == "" {
+		return
+	}
+	for _, existing := range n.Tags {
+		if strings.EqualFold(existing, tag) {
+			return
+		}
+	}
+	n.Tags = append(n.Tags, tag)
+}
+
+// HasTag indique si la note porte le tag ou l'un de ses sous-tags (#projet/acme pour #projet)
+func (n *Note) HasTag(tag string) bool {
+	tag = strings.TrimPrefix(tag, "#")
+	for _, t := range n.Tags {
+		if strings.EqualFold(t, tag) || (len(t) > len(tag) && strings.EqualFold(t[:len(tag)+1], tag+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Heading retourne le titre désigné par la partie d'un lien après '#'. Comme Obsidian, un
+// chemin de titres (Titre#Sous-titre) désigne le dernier, et la casse et les espaces
+// multiples sont ignorés.
+func (n *Note) Heading(fragment string) (Heading, bool) {
+	if i := strings.LastIndex(fragment, "#"); i >= 0 {
+		fragment = fragment[i+1:]
+	}
+	want := normalizeHeading(fragment)
+	for _, heading := range n.Headings {
+		if normalizeHeading(heading.Text) == want {
+			return heading, true
+		}
+	}
+	return Heading{}, false
+}
+
+// Block retourne le bloc d'identifiant id, sans le '^'
+func (n *Note) Block(id string) (Block, bool) {
+	id = strings.TrimPrefix(id, "^")
+	for _, block := range n.Blocks {
+		if strings.EqualFold(block.ID, id) {
+			return block, true
+		}
+	}
+	return Block{}, false
+}
+
+// normalizeHeading simplifie un titre pour le comparer au texte d'un lien, qui ne peut pas
+// contenir certains caractères
+func normalizeHeading(text string) string {
+	text = strings.Map(func(r rune) rune {
+		switch r {
+		case '[', ']', '|', '^', '#', ':', '\\':
+			return ' '
+		}
+		return r
+	}, text)
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
@@ -0,0 +1,182 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/coyls/obs-cli/internal/crypt"
+)
+
+// testVault est un vault d'exemple qui couvre les formes de frontmatter, de tags et de liens
+const testVault = "testdata/vault"
+
+func parseTestNote(t *testing.T, rel string) *Note {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(testVault, filepath.FromSlash(rel)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Parse(rel, content)
+}
+
+func TestParseFrontmatter(t *testing.T) {
+	tests := []struct {
+		file       string
+		aliases    []string
+		tags       []string
+		properties []string
+		invalid    bool
+	}{
+		{
+			file:       "Home.md",
+			aliases:    []string{"Start", "Accueil"},
+			tags:       []string{"project", "area/work", "inline", "area/home"},
+			properties: []string{"aliases", "tags"},
+		},
+		{
+			file:       "Projects/Alpha.md",
+			aliases:    []string{"First", "Premier"},
+			tags:       []string{"project/alpha"},
+			properties: []string{"aliases", "tags"},
+		},
+		{
+			file:       "Archive/2023/Alpha.md",
+			tags:       []string{"archive", "project"},
+			properties: []string{"tag"},
+		},
+		{
+			file:    "Beta.md",
+			tags:    []string{"beta"},
+			invalid: true,
+		},
+		{
+			file: "Projects/Plan B.md",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			note := parseTestNote(t, tt.file)
+			if !reflect.DeepEqual(note.Aliases, tt.aliases) {
+				t.Errorf("aliases = %q, want %q", note.Aliases, tt.aliases)
+			}
+			if !reflect.DeepEqual(note.Tags, tt.tags) {
+				t.Errorf("tags = %q, want %q", note.Tags, tt.tags)
+			}
+			if (note.FrontmatterError != "") != tt.invalid {
+				t.Errorf("frontmatter error = %q, want invalid %v", note.FrontmatterError, tt.invalid)
+			}
+			if tt.invalid && note.Frontmatter != nil {
+				t.Errorf("invalid frontmatter parsed as %v", note.Frontmatter)
+			}
+			for _, key := range tt.properties {
+				if _, ok := note.Frontmatter[key]; !ok {
+					t.Errorf("property %s missing from %v", key, note.Frontmatter)
+				}
+			}
+		})
+	}
+}
+
+func TestParseStructure(t *testing.T) {
+	tests := []struct {
+		file     string
+		headings []Heading
+		blocks   []Block
+	}{
+		{
+			file:     "Home.md",
+			headings: []Heading{{Level: 1, Text: "Home", Line: 5}, {Level: 2, Text: "Links", Line: 11}},
+			blocks:   []Block{{ID: "intro-1", Line: 19}},
+		},
+		{
+			file:     "Projects/Alpha.md",
+			headings: []Heading{{Level: 1, Text: "Alpha", Line: 7}, {Level: 2, Text: "Goals", Line: 9}},
+			blocks:   []Block{{ID: "goals", Line: 11}},
+		},
+		{
+			file:     "Beta.md",
+			headings: []Heading{{Level: 1, Text: "Beta", Line: 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			note := parseTestNote(t, tt.file)
+			if !reflect.DeepEqual(note.Headings, tt.headings) {
+				t.Errorf("headings = %+v, want %+v", note.Headings, tt.headings)
+			}
+			if !reflect.DeepEqual(note.Blocks, tt.blocks) {
+				t.Errorf("blocks = %+v, want %+v", note.Blocks, tt.blocks)
+			}
+		})
+	}
+}
+
+func TestParseLinks(t *testing.T) {
+	type want struct {
+		target, fragment, alias string
+		embed, markdown         bool
+		line                    int
+	}
+	tests := []struct {
+		file  string
+		links []want
+	}{
+		{
+			file: "Home.md",
+			links: []want{
+				{target: "Projects/Alpha", line: 7},
+				{target: "Beta", alias: "the beta", line: 7},
+				{target: "Alpha", fragment: "Goals", line: 7},
+				{fragment: "Links", line: 7},
+				{target: "diagram.png", embed: true, line: 7},
+				{target: "Projects/Plan B.md", fragment: "Steps", alias: "the plan", markdown: true, line: 8},
+				{target: "assets/logo.png", alias: "logo", embed: true, markdown: true, line: 8},
+			},
+		},
+		{
+			file: "Beta.md",
+			links: []want{
+				{target: "Missing", line: 6},
+				{target: "Home", fragment: "^intro-1", line: 6},
+			},
+		},
+		{
+			file: "Projects/Alpha.md",
+			links: []want{
+				{target: "../Home", line: 11},
+				{target: "Home", fragment: "Links", alias: "home links", line: 11},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			note := parseTestNote(t, tt.file)
+			var got []want
+			for _, l := range note.Links {
+				got = append(got, want{target: l.Target, fragment: l.Fragment, alias: l.Alias, embed: l.Embed, markdown: l.Markdown, line: l.Line})
+			}
+			if !reflect.DeepEqual(got, tt.links) {
+				t.Errorf("links =\n%+v\nwant\n%+v", got, tt.links)
+			}
+		})
+	}
+}
+
+func TestParseEncrypted(t *testing.T) {
+	content := []byte("---\ntags: [private]\nrelated: \"[[Home]]\"\n---\n" + crypt.BeginMarker + "\n#secret [[Hidden]]\n")
+	note := Parse("Secret.md", content)
+	if !note.Encrypted {
+		t.Fatal("note not recognized as encrypted")
+	}
+	if len(note.Links) != 1 || note.Links[0].Target != "Home" {
+		t.Errorf("links of an encrypted note = %+v, want only the frontmatter link", note.Links)
+	}
+	if !reflect.DeepEqual(note.Tags, []string{"private"}) {
+		t.Errorf("tags of an encrypted note = %q, want only the frontmatter tags", note.Tags)
+	}
+}
@@ -0,0 +1,72 @@
+package vault
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/coyls/obs-cli/internal/config"
+	"github.com/coyls/obs-cli/internal/link"
+)
+
+// Vault est un vault configuré et son dossier sur le disque
+type Vault struct {
+	// Name est le nom du vault dans la configuration
+	Name string
+	// Path est le chemin absolu du dossier du vault, liens symboliques résolus
+	Path   string
+	Config *config.VaultConfig
+}
+
+// Open retourne le vault name de la configuration, ou le vault par défaut si name est vide
+func Open(cfg *config.Config, name string) (*Vault, error) {
+	var names []string
+	if name != "" {
+		names = []string{name}
+	}
+	selected, err := cfg.SelectVaults(names, false)
+	if err != nil {
+		return nil, err
+	}
+	vaultConfig, exists := cfg.GetVaultConfig(selected[0])
+	if !exists {
+		return nil, fmt.Errorf("vault '%s' not found in configuration", selected[0])
+	}
+
+	path, err := filepath.Abs(filepath.Join(cfg.Config.Root, vaultConfig.VaultPath))
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return &Vault{Name: selected[0], Path: path, Config: vaultConfig}, nil
+}
+
+// Abs retourne le chemin sur le disque d'un chemin relatif au vault
+func (v *Vault) Abs(rel string) string {
+	return filepath.Join(v.Path, filepath.FromSlash(rel))
+}
+
+// Rel retourne le chemin relatif au vault, séparé par des "/", d'un chemin sur le disque,
+// s'il est dans le vault
+func (v *Vault) Rel(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(v.Path, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// Files liste les fichiers du vault, relatifs au vault, sans les dossiers cachés
+// (.obsidian, .trash, .git...)
+func (v *Vault) Files() ([]string, error) {
+	return link.Files(v.Path)
+}
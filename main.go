@@ -4,11 +4,23 @@ import (
 	"log"
 
 	"github.com/coyls/obs-cli/cmd/archive"
+	"github.com/coyls/obs-cli/cmd/backlinks"
 	"github.com/coyls/obs-cli/cmd/callouts"
+	"github.com/coyls/obs-cli/cmd/conflicts"
 	"github.com/coyls/obs-cli/cmd/cp"
+	"github.com/coyls/obs-cli/cmd/decrypt"
+	"github.com/coyls/obs-cli/cmd/encrypt"
+	"github.com/coyls/obs-cli/cmd/graph"
+	"github.com/coyls/obs-cli/cmd/history"
+	"github.com/coyls/obs-cli/cmd/index"
+	"github.com/coyls/obs-cli/cmd/links"
 	"github.com/coyls/obs-cli/cmd/mv"
 	"github.com/coyls/obs-cli/cmd/pull"
 	"github.com/coyls/obs-cli/cmd/push"
+	"github.com/coyls/obs-cli/cmd/restore"
+	"github.com/coyls/obs-cli/cmd/status"
+	"github.com/coyls/obs-cli/cmd/sync"
+	"github.com/coyls/obs-cli/cmd/watch"
 	"github.com/spf13/cobra"
 )
 
@@ -28,10 +40,22 @@ func Execute() {
 func main() {
 	rootCmd.AddCommand(push.GetCommand())
 	rootCmd.AddCommand(pull.GetCommand())
+	rootCmd.AddCommand(sync.GetCommand())
+	rootCmd.AddCommand(status.GetCommand())
+	rootCmd.AddCommand(conflicts.GetCommand())
+	rootCmd.AddCommand(history.GetCommand())
+	rootCmd.AddCommand(restore.GetCommand())
+	rootCmd.AddCommand(watch.GetCommand())
 	rootCmd.AddCommand(mv.GetCommand())
 	rootCmd.AddCommand(cp.GetCommand())
+	rootCmd.AddCommand(index.GetCommand())
+	rootCmd.AddCommand(links.GetCommand())
+	rootCmd.AddCommand(backlinks.GetCommand())
+	rootCmd.AddCommand(graph.GetCommand())
 	rootCmd.AddCommand(callouts.GetCommand())
 	rootCmd.AddCommand(archive.GetCommand())
+	rootCmd.AddCommand(encrypt.GetCommand())
+	rootCmd.AddCommand(decrypt.GetCommand())
 
 	Execute()
 }